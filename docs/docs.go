@@ -19,6 +19,120 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/outbox/requeue": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "lets the outbox relay publish the parked entries again; entries are parked after outbox_max_attempts permanent errors, like a topic without producer; only admins may access this endpoint",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "requeue parked outbox entries",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OutboxRequeueResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "501": {
+                        "description": "Not Implemented"
+                    }
+                }
+            }
+        },
+        "/admin/replay": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "status of the running or last replay; only admins may access this endpoint",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "replay status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ReplayStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "handle messages of the deployment topic again in the background; mode range replays a partition range, mode rebuild pauses the kafka consumers, replays the whole topic into the stored deployments and removes deployments without remaining commands afterwards; replayed commands are not audited and neither store history versions nor send done notifications; rebuild is not supported with analytics events and requires single_instance; only admins may access this endpoint",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "replay deployment commands",
+                "parameters": [
+                    {
+                        "description": "replay request",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ReplayRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/api.ReplayStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "501": {
+                        "description": "Not Implemented"
+                    }
+                }
+            }
+        },
         "/device-groups": {
             "post": {
                 "security": [
@@ -58,6 +172,9 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "409": {
+                        "description": "Conflict"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
@@ -114,6 +231,53 @@ const docTemplate = `{
             }
         },
         "/events/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "get the deployed analytics pipelines and conditional event descriptions of an event",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "event"
+                ],
+                "summary": "get event details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "event id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.EventDetails"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
             "head": {
                 "security": [
                     {
@@ -178,6 +342,63 @@ const docTemplate = `{
                 }
             }
         },
+        "/pipeline-credentials/token": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "exchange the credentialRef of a pipeline for a user token; only available if pipeline_auth_mode is credential_reference; only service tokens of the pipeline_credential_clients may access this endpoint",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pipeline-credentials"
+                ],
+                "summary": "exchange pipeline credential",
+                "parameters": [
+                    {
+                        "description": "credentialRef of the pipeline config",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.PipelineCredentialRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.PipelineCredentialResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
+                    }
+                }
+            }
+        },
         "/process-deployments": {
             "put": {
                 "security": [
@@ -217,8 +438,128 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/process-deployments/{id}/versions": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "list the stored versions of a deployment with their generated event descriptions, newest first; only admins and the deployment owner may access this endpoint",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deployment"
+                ],
+                "summary": "list deployment versions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "deployment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.DeploymentVersion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "501": {
+                        "description": "Not Implemented"
+                    }
+                }
+            }
+        },
+        "/process-deployments/{id}/versions/{version}/rollback": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "deploy a previous deployment version again; if kafka is used, the rollback is published as deployment command and stored as new version when it is handled, the response contains the rolled back version; otherwise the rollback is applied locally and the response contains the new version; only admins and the deployment owner may access this endpoint",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deployment"
+                ],
+                "summary": "rollback deployment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "deployment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "version",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/api.DeploymentVersion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
                     "500": {
                         "description": "Internal Server Error"
+                    },
+                    "501": {
+                        "description": "Not Implemented"
                     }
                 }
             }
@@ -267,6 +608,9 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "409": {
+                        "description": "Conflict"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
@@ -275,12 +619,141 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "api.DeploymentVersion": {
+            "type": "object",
+            "properties": {
+                "activation": {
+                    "$ref": "#/definitions/model.Activation"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "changed_by": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "deployment": {
+                    "$ref": "#/definitions/model.Deployment"
+                },
+                "deployment_id": {
+                    "type": "string"
+                },
+                "descriptions": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "filter_criteria": {
+                    "type": "object"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "removed": {
+                    "type": "boolean"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.EventDetails": {
+            "type": "object",
+            "properties": {
+                "conditional_events": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "pipelines": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                }
+            }
+        },
         "api.EventStates": {
             "type": "object",
             "additionalProperties": {
                 "type": "boolean"
             }
         },
+        "api.OutboxRequeueResult": {
+            "type": "object",
+            "properties": {
+                "requeued": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.PipelineCredentialRequest": {
+            "type": "object",
+            "properties": {
+                "credential_ref": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PipelineCredentialResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ReplayRequest": {
+            "type": "object",
+            "properties": {
+                "end_offset": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "partition": {
+                    "type": "integer"
+                },
+                "start_offset": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ReplayStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "finished": {
+                    "type": "string"
+                },
+                "handled": {
+                    "type": "integer"
+                },
+                "request": {
+                    "$ref": "#/definitions/model.ReplayRequest"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "skipped": {
+                    "description": "messages skipped because they are invalid or refer to missing resources",
+                    "type": "integer"
+                },
+                "started": {
+                    "type": "string"
+                }
+            }
+        },
         "deploymentmodel.ConditionalEvent": {
             "type": "object",
             "properties": {
@@ -763,9 +1236,58 @@ const docTemplate = `{
                 }
             }
         },
+        "model.Activation": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "location": {
+                    "description": "IANA time zone of the schedule; default UTC",
+                    "type": "string"
+                },
+                "schedule": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ActivationSchedule"
+                    }
+                },
+                "until": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ActivationSchedule": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "description": "15:04",
+                    "type": "string"
+                },
+                "start": {
+                    "description": "15:04",
+                    "type": "string"
+                },
+                "weekdays": {
+                    "description": "weekday of the window start, 0 = sunday; empty = every day",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
         "model.Deployment": {
             "type": "object",
             "properties": {
+                "activation": {
+                    "description": "if set, the events of the deployment are only deployed while the activation is active",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Activation"
+                        }
+                    ]
+                },
                 "description": {
                     "type": "string"
                 },
@@ -845,6 +1367,23 @@ const docTemplate = `{
                 }
             }
         },
+        "model.ReplayRequest": {
+            "type": "object",
+            "properties": {
+                "end_offset": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "partition": {
+                    "type": "integer"
+                },
+                "start_offset": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.Attribute": {
             "type": "object",
             "properties": {
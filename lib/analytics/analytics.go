@@ -25,17 +25,25 @@ import (
 	"time"
 )
 
+// names of the dependencies in errors
+const (
+	dependencyFlowParser         = "flow-parser"
+	dependencyFlowEngine         = "flow-engine"
+	dependencyPipelineRepository = "pipeline-repository"
+)
+
 type FactoryType struct{}
 
 var Factory = &FactoryType{}
 
 type Analytics struct {
-	config  config.Config
-	timeout time.Duration
-	auth    *auth.Auth
+	config      config.Config
+	timeout     time.Duration
+	auth        *auth.Auth
+	credentials interfaces.PipelineCredentials
 }
 
-func (this *FactoryType) New(ctx context.Context, config config.Config) (interfaces.Analytics, error) {
+func (this *FactoryType) New(ctx context.Context, config config.Config, credentials interfaces.PipelineCredentials) (interfaces.Analytics, error) {
 	timeout, err := time.ParseDuration(config.AnalyticsRequestTimeout)
 	if err != nil {
 		return nil, err
@@ -44,7 +52,7 @@ func (this *FactoryType) New(ctx context.Context, config config.Config) (interfa
 	if err != nil {
 		return nil, err
 	}
-	return &Analytics{config: config, timeout: timeout, auth: a}, nil
+	return &Analytics{config: config, timeout: timeout, auth: a, credentials: credentials}, nil
 }
 
 func trimIdParams(id string) (result string) {
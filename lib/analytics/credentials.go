@@ -0,0 +1,74 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package analytics
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"log"
+)
+
+const CredentialRefConfigName = "credentialRef"
+const CredentialUrlConfigName = "credentialUrl"
+
+// getCredentialConfig returns the node configs, with which the pipeline operator authenticates as user
+func (this *Analytics) getCredentialConfig(ctx context.Context, token auth.AuthToken, user string, deploymentId string, eventId string) (result []NodeConfig, err error) {
+	if this.credentials == nil {
+		return []NodeConfig{{Name: UserTokenConfigName, Value: string(token)}}, nil
+	}
+	key, err := this.credentials.Issue(ctx, user, deploymentId, eventId)
+	if err != nil {
+		return result, err
+	}
+	return []NodeConfig{
+		{Name: CredentialRefConfigName, Value: key},
+		{Name: CredentialUrlConfigName, Value: this.config.PipelineCredentialUrl},
+	}, nil
+}
+
+// ExchangePipelineCredential classifies credentials of removed users like unknown credentials as unauthorized
+func (this *Analytics) ExchangePipelineCredential(ctx context.Context, key string) (token auth.AuthToken, err error) {
+	if this.credentials == nil {
+		return token, errs.NotFound("", errors.New("pipeline credentials are not enabled"))
+	}
+	token, err = this.credentials.Exchange(ctx, key)
+	if errors.Is(err, auth.ErrUserDoesNotExist) {
+		return token, errs.Unauthorized("", err)
+	}
+	return token, err
+}
+
+// revokeCredential removes the credentials referenced in the operator configs of the pipeline
+func (this *Analytics) revokeCredential(ctx context.Context, user string, pipeline Pipeline) error {
+	if this.credentials == nil {
+		return nil
+	}
+	for _, operator := range pipeline.Operators {
+		key, ok := operator.Config[CredentialRefConfigName]
+		if !ok || key == "" {
+			continue
+		}
+		log.Println("revoke pipeline credential", user, pipeline.Id)
+		err := this.credentials.Revoke(ctx, key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
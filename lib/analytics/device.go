@@ -17,6 +17,7 @@
 package analytics
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/SENERGY-Platform/event-deployment/lib/auth"
@@ -26,10 +27,10 @@ import (
 	"strings"
 )
 
-func (this *Analytics) DeployDeviceWithMarshaller(token auth.AuthToken, label string, user string, deploymentId string, flowId string, eventId string, deviceId string, serviceId string, value string, path string, functionId string, aspectNodeId string, targetCharacteristicId string) (pipelineId string, err error) {
-	flowCells, err, code := this.GetFlowInputs(flowId, user)
+func (this *Analytics) DeployDeviceWithMarshaller(ctx context.Context, token auth.AuthToken, label string, user string, deploymentId string, flowId string, eventId string, deviceId string, serviceId string, value string, path string, functionId string, aspectNodeId string, targetCharacteristicId string) (pipelineId string, err error) {
+	flowCells, err := this.GetFlowInputs(ctx, flowId, user)
 	if err != nil {
-		log.Println("ERROR: unable to get flow inputs", err.Error(), code)
+		log.Println("ERROR: unable to get flow inputs", err.Error())
 		debug.PrintStack()
 		return "", err
 	}
@@ -54,6 +55,12 @@ func (this *Analytics) DeployDeviceWithMarshaller(token auth.AuthToken, label st
 		return "", err
 	}
 
+	credentialConfig, err := this.getCredentialConfig(ctx, token, user, deploymentId, eventId)
+	if err != nil {
+		debug.PrintStack()
+		return "", err
+	}
+
 	topicToServiceId := map[string]string{
 		ServiceIdToTopic(serviceId): serviceId,
 	}
@@ -63,7 +70,7 @@ func (this *Analytics) DeployDeviceWithMarshaller(token auth.AuthToken, label st
 		return "", err
 	}
 
-	pipeline, err, code := this.sendDeployRequest(token, user, PipelineRequest{
+	pipeline, err := this.sendDeployRequest(ctx, token, user, PipelineRequest{
 		FlowId:      flowId,
 		Name:        label,
 		Description: string(description),
@@ -80,7 +87,7 @@ func (this *Analytics) DeployDeviceWithMarshaller(token auth.AuthToken, label st
 						Path: strings.TrimSuffix(this.config.DevicePathPrefix, "."),
 					}},
 				}},
-				Config: []NodeConfig{
+				Config: append([]NodeConfig{
 					{
 						Name:  "value",
 						Value: value,
@@ -117,16 +124,12 @@ func (this *Analytics) DeployDeviceWithMarshaller(token auth.AuthToken, label st
 						Name:  "topicToServiceId",
 						Value: string(topicToServiceIdJson),
 					},
-					{
-						Name:  "userToken",
-						Value: string(token),
-					},
-				},
+				}, credentialConfig...),
 			},
 		},
 	})
 	if err != nil {
-		log.Println("ERROR: unable to deploy pipeline", err.Error(), code)
+		log.Println("ERROR: unable to deploy pipeline", err.Error())
 		debug.PrintStack()
 		return "", err
 	}
@@ -134,10 +137,10 @@ func (this *Analytics) DeployDeviceWithMarshaller(token auth.AuthToken, label st
 	return pipelineId, nil
 }
 
-func (this *Analytics) DeployDevice(token auth.AuthToken, label string, user string, deploymentId string, flowId string, eventId string, deviceId string, serviceId string, value string, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (pipelineId string, err error) {
-	flowCells, err, code := this.GetFlowInputs(flowId, user)
+func (this *Analytics) DeployDevice(ctx context.Context, token auth.AuthToken, label string, user string, deploymentId string, flowId string, eventId string, deviceId string, serviceId string, value string, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (pipelineId string, err error) {
+	flowCells, err := this.GetFlowInputs(ctx, flowId, user)
 	if err != nil {
-		log.Println("ERROR: unable to get flow inputs", err.Error(), code)
+		log.Println("ERROR: unable to get flow inputs", err.Error())
 		debug.PrintStack()
 		return "", err
 	}
@@ -161,6 +164,12 @@ func (this *Analytics) DeployDevice(token auth.AuthToken, label string, user str
 		return "", err
 	}
 
+	credentialConfig, err := this.getCredentialConfig(ctx, token, user, deploymentId, eventId)
+	if err != nil {
+		debug.PrintStack()
+		return "", err
+	}
+
 	convertFrom := ""
 	convertTo := ""
 	converterUrl := ""
@@ -181,7 +190,7 @@ func (this *Analytics) DeployDevice(token auth.AuthToken, label string, user str
 		}
 	}
 
-	pipeline, err, code := this.sendDeployRequest(token, user, PipelineRequest{
+	pipeline, err := this.sendDeployRequest(ctx, token, user, PipelineRequest{
 		FlowId:      flowId,
 		Name:        label,
 		Description: string(description),
@@ -198,7 +207,7 @@ func (this *Analytics) DeployDevice(token auth.AuthToken, label string, user str
 						Path: path,
 					}},
 				}},
-				Config: []NodeConfig{
+				Config: append([]NodeConfig{
 					{
 						Name:  "value",
 						Value: value,
@@ -231,16 +240,12 @@ func (this *Analytics) DeployDevice(token auth.AuthToken, label string, user str
 						Name:  "castExtensions",
 						Value: castExtensionsJson,
 					},
-					{
-						Name:  "userToken",
-						Value: string(token),
-					},
-				},
+				}, credentialConfig...),
 			},
 		},
 	})
 	if err != nil {
-		log.Println("ERROR: unable to deploy pipeline", err.Error(), code)
+		log.Println("ERROR: unable to deploy pipeline", err.Error())
 		debug.PrintStack()
 		return "", err
 	}
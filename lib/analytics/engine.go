@@ -18,9 +18,11 @@ package analytics
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"log"
 	"net/http"
 	"net/url"
@@ -34,11 +36,20 @@ func ServiceIdToTopic(id string) string {
 	return id
 }
 
-func (this *Analytics) Remove(user string, pipelineId string) error {
+func (this *Analytics) Remove(ctx context.Context, user string, pipelineId string) error {
+	var pipeline Pipeline
+	if this.credentials != nil {
+		var err error
+		pipeline, err = this.getPipeline(ctx, user, pipelineId)
+		if err != nil {
+			log.Println("WARNING: unable to load pipeline --> credential will not be revoked", pipelineId, err)
+		}
+	}
 	client := http.Client{
 		Timeout: this.timeout,
 	}
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"DELETE",
 		this.config.FlowEngineUrl+"/pipeline/"+url.PathEscape(pipelineId),
 		nil,
@@ -51,20 +62,20 @@ func (this *Analytics) Remove(user string, pipelineId string) error {
 	resp, err := client.Do(req)
 	if err != nil {
 		debug.PrintStack()
-		return err
+		return errs.FromRequestError(dependencyFlowEngine, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
 		debug.PrintStack()
-		return errors.New("unexpected statuscode")
+		return errs.FromStatusCode(dependencyFlowEngine, resp.StatusCode, errors.New("unexpected statuscode"))
 	}
-	return nil
+	return this.revokeCredential(ctx, user, pipeline)
 }
 
-func (this *Analytics) sendDeployRequest(token auth.AuthToken, user string, request PipelineRequest) (result Pipeline, err error, code int) {
+func (this *Analytics) sendDeployRequest(ctx context.Context, token auth.AuthToken, user string, request PipelineRequest) (result Pipeline, err error) {
 	body, err := json.Marshal(request)
 	if err != nil {
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 	if this.config.Debug {
 		log.Println("DEBUG: deploy event pipeline", string(body))
@@ -72,14 +83,15 @@ func (this *Analytics) sendDeployRequest(token auth.AuthToken, user string, requ
 	client := http.Client{
 		Timeout: this.timeout,
 	}
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
 		this.config.FlowEngineUrl+"/pipeline",
 		bytes.NewBuffer(body),
 	)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 	token.UseInRequest(req)
 	req.Header.Set("X-UserId", user)
@@ -89,23 +101,23 @@ func (this *Analytics) sendDeployRequest(token auth.AuthToken, user string, requ
 	resp, err := client.Do(req)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, errs.FromRequestError(dependencyFlowEngine, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		debug.PrintStack()
-		return result, errors.New("unexpected statuscode"), resp.StatusCode
+		return result, errs.FromStatusCode(dependencyFlowEngine, resp.StatusCode, errors.New("unexpected statuscode"))
 	}
 
 	err = json.NewDecoder(resp.Body).Decode(&result)
-	return result, err, http.StatusOK
+	return result, err
 }
 
-func (this *Analytics) sendUpdateRequest(token auth.AuthToken, user string, request PipelineRequest) (result Pipeline, err error, code int) {
+func (this *Analytics) sendUpdateRequest(ctx context.Context, token auth.AuthToken, user string, request PipelineRequest) (result Pipeline, err error) {
 	body, err := json.Marshal(request)
 	if err != nil {
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 	if this.config.Debug {
 		log.Println("DEBUG: deploy event pipeline", string(body))
@@ -113,14 +125,15 @@ func (this *Analytics) sendUpdateRequest(token auth.AuthToken, user string, requ
 	client := http.Client{
 		Timeout: this.timeout,
 	}
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"PUT",
 		this.config.FlowEngineUrl+"/pipeline",
 		bytes.NewBuffer(body),
 	)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 	req.Header.Set("X-UserId", user)
 	token.UseInRequest(req)
@@ -131,15 +144,15 @@ func (this *Analytics) sendUpdateRequest(token auth.AuthToken, user string, requ
 	resp, err := client.Do(req)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, errs.FromRequestError(dependencyFlowEngine, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		debug.PrintStack()
-		return result, errors.New("unexpected statuscode"), resp.StatusCode
+		return result, errs.FromStatusCode(dependencyFlowEngine, resp.StatusCode, errors.New("unexpected statuscode"))
 	}
 
 	err = json.NewDecoder(resp.Body).Decode(&result)
-	return result, err, http.StatusOK
+	return result, err
 }
@@ -17,8 +17,10 @@
 package analytics
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"io"
 	"log"
 	"net/http"
@@ -26,30 +28,31 @@ import (
 	"runtime/debug"
 )
 
-func (this *Analytics) GetFlowInputs(id string, user string) (result []FlowModelCell, err error, code int) {
+func (this *Analytics) GetFlowInputs(ctx context.Context, id string, user string) (result []FlowModelCell, err error) {
 	client := http.Client{
 		Timeout: this.timeout,
 	}
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		this.config.FlowParserUrl+"/flow/getinputs/"+url.PathEscape(id),
 		nil,
 	)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 	req.Header.Set("X-UserId", user)
 	resp, err := client.Do(req)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, errs.FromRequestError(dependencyFlowParser, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		debug.PrintStack()
-		return result, errors.New("unexpected statuscode"), resp.StatusCode
+		return result, errs.FromStatusCode(dependencyFlowParser, resp.StatusCode, errors.New("unexpected statuscode"))
 	}
 
 	temp, err := io.ReadAll(resp.Body)
@@ -57,7 +60,7 @@ func (this *Analytics) GetFlowInputs(id string, user string) (result []FlowModel
 	if err != nil {
 		log.Println("ERROR:", err, string(temp))
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
-	return result, err, http.StatusOK
+	return result, err
 }
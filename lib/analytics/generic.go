@@ -17,21 +17,22 @@
 package analytics
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"log"
-	"net/http"
 	"runtime/debug"
 )
 
-func (this *Analytics) DeployGenericSource(token auth.AuthToken, label string, user string, desc model.GroupEventDescription, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (pipelineId string, err error) {
-	flowCells, err, code := this.GetFlowInputs(desc.FlowId, user)
+func (this *Analytics) DeployGenericSource(ctx context.Context, token auth.AuthToken, label string, user string, desc model.GroupEventDescription, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (pipelineId string, err error) {
+	flowCells, err := this.GetFlowInputs(ctx, desc.FlowId, user)
 	if err != nil {
-		log.Println("ERROR: unable to get flow inputs", err.Error(), code)
-		if code == http.StatusNotFound || code == http.StatusForbidden || code == http.StatusUnauthorized {
-			log.Println("unable to find flow (ignore deployment)", code, err)
+		log.Println("ERROR: unable to get flow inputs", err.Error())
+		if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrForbidden) {
+			log.Println("unable to find flow (ignore deployment)", err)
 			err = nil
 		}
 		return "", err
@@ -57,6 +58,12 @@ func (this *Analytics) DeployGenericSource(token auth.AuthToken, label string, u
 		return "", err
 	}
 
+	credentialConfig, err := this.getCredentialConfig(ctx, token, user, desc.DeploymentId, desc.EventId)
+	if err != nil {
+		debug.PrintStack()
+		return "", err
+	}
+
 	inputs := []NodeInput{}
 
 	inputs = append(inputs, NodeInput{
@@ -98,7 +105,7 @@ func (this *Analytics) DeployGenericSource(token auth.AuthToken, label string, u
 			{
 				NodeId: flowCells[0].Id,
 				Inputs: inputs,
-				Config: []NodeConfig{
+				Config: append([]NodeConfig{
 					{
 						Name:  "value",
 						Value: desc.OperatorValue,
@@ -131,18 +138,14 @@ func (this *Analytics) DeployGenericSource(token auth.AuthToken, label string, u
 						Name:  "castExtensions",
 						Value: castExtensionsJson,
 					},
-					{
-						Name:  "userToken",
-						Value: string(token),
-					},
-				},
+				}, credentialConfig...),
 			},
 		},
 	}
 
-	pipeline, err, code := this.sendDeployRequest(token, user, request)
+	pipeline, err := this.sendDeployRequest(ctx, token, user, request)
 	if err != nil {
-		log.Println("ERROR: unable to deploy pipeline", err.Error(), code)
+		log.Println("ERROR: unable to deploy pipeline", err.Error())
 		debug.PrintStack()
 		return "", err
 	}
@@ -17,29 +17,30 @@
 package analytics
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"log"
-	"net/http"
 	"runtime/debug"
 	"strings"
 )
 
-func (this *Analytics) DeployGroup(token auth.AuthToken, label string, user string, desc model.GroupEventDescription, serviceIds []string, serviceToDeviceIdsMapping map[string][]string, serviceToPathsMapping map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, castExtensions []model.ConverterExtension, useMarshaller bool) (pipelineId string, err error) {
+func (this *Analytics) DeployGroup(ctx context.Context, token auth.AuthToken, label string, user string, desc model.GroupEventDescription, serviceIds []string, serviceToDeviceIdsMapping map[string][]string, serviceToPathsMapping map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, castExtensions []model.ConverterExtension, useMarshaller bool) (pipelineId string, err error) {
 	if this.config.Debug {
 		log.Println("DEBUG: DeployGroup()")
 	}
-	request, err := this.getPipelineRequestForGroupDeployment(token, label, user, desc, serviceIds, serviceToDeviceIdsMapping, serviceToPathsMapping, serviceToPathAndCharacteristic, castExtensions, useMarshaller)
+	request, err := this.getPipelineRequestForGroupDeployment(ctx, token, label, user, desc, serviceIds, serviceToDeviceIdsMapping, serviceToPathsMapping, serviceToPathAndCharacteristic, castExtensions, useMarshaller)
 	if err != nil {
 		log.Println("ERROR: getPipelineRequestForGroupDeployment()", err.Error())
 		debug.PrintStack()
 		return "", err
 	}
-	pipeline, err, code := this.sendDeployRequest(token, user, request)
+	pipeline, err := this.sendDeployRequest(ctx, token, user, request)
 	if err != nil {
-		log.Println("ERROR: unable to deploy pipeline", err.Error(), code)
+		log.Println("ERROR: unable to deploy pipeline", err.Error())
 		debug.PrintStack()
 		return "", err
 	}
@@ -47,27 +48,27 @@ func (this *Analytics) DeployGroup(token auth.AuthToken, label string, user stri
 	return pipelineId, nil
 }
 
-func (this *Analytics) UpdateGroupDeployment(token auth.AuthToken, pipelineId string, label string, user string, desc model.GroupEventDescription, serviceIds []string, serviceToDeviceIdsMapping map[string][]string, serviceToPathsMapping map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, castExtensions []model.ConverterExtension, useMarshaller bool) (err error) {
-	request, err := this.getPipelineRequestForGroupDeployment(token, label, user, desc, serviceIds, serviceToDeviceIdsMapping, serviceToPathsMapping, serviceToPathAndCharacteristic, castExtensions, useMarshaller)
+func (this *Analytics) UpdateGroupDeployment(ctx context.Context, token auth.AuthToken, pipelineId string, label string, user string, desc model.GroupEventDescription, serviceIds []string, serviceToDeviceIdsMapping map[string][]string, serviceToPathsMapping map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, castExtensions []model.ConverterExtension, useMarshaller bool) (err error) {
+	request, err := this.getPipelineRequestForGroupDeployment(ctx, token, label, user, desc, serviceIds, serviceToDeviceIdsMapping, serviceToPathsMapping, serviceToPathAndCharacteristic, castExtensions, useMarshaller)
 	if err != nil {
 		return err
 	}
 	request.Id = pipelineId
-	_, err, code := this.sendUpdateRequest(token, user, request)
+	_, err = this.sendUpdateRequest(ctx, token, user, request)
 	if err != nil {
-		log.Println("ERROR: unable to deploy pipeline", err.Error(), code)
+		log.Println("ERROR: unable to deploy pipeline", err.Error())
 		debug.PrintStack()
 		return err
 	}
 	return nil
 }
 
-func (this *Analytics) getPipelineRequestForGroupDeployment(token auth.AuthToken, label string, user string, desc model.GroupEventDescription, serviceIds []string, serviceToDeviceIdsMapping map[string][]string, serviceToPathsMapping map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, castExtensions []model.ConverterExtension, useMarshaller bool) (request PipelineRequest, err error) {
-	flowCells, err, code := this.GetFlowInputs(desc.FlowId, user)
+func (this *Analytics) getPipelineRequestForGroupDeployment(ctx context.Context, token auth.AuthToken, label string, user string, desc model.GroupEventDescription, serviceIds []string, serviceToDeviceIdsMapping map[string][]string, serviceToPathsMapping map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, castExtensions []model.ConverterExtension, useMarshaller bool) (request PipelineRequest, err error) {
+	flowCells, err := this.GetFlowInputs(ctx, desc.FlowId, user)
 	if err != nil {
-		log.Println("ERROR: unable to get flow inputs", err.Error(), code)
-		if code == http.StatusNotFound || code == http.StatusForbidden || code == http.StatusUnauthorized {
-			log.Println("unable to find flow (ignore deployment)", code, err)
+		log.Println("ERROR: unable to get flow inputs", err.Error())
+		if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrForbidden) {
+			log.Println("unable to find flow (ignore deployment)", err)
 			err = nil
 		}
 		return request, err
@@ -88,12 +89,20 @@ func (this *Analytics) getPipelineRequestForGroupDeployment(token auth.AuthToken
 		DeploymentId:  desc.DeploymentId,
 		FlowId:        desc.FlowId,
 		UseMarshaller: desc.UseMarshaller,
+
+		AdditionalFilterCriteria: desc.AdditionalFilterCriteria,
 	})
 	if err != nil {
 		debug.PrintStack()
 		return request, err
 	}
 
+	credentialConfig, err := this.getCredentialConfig(ctx, token, user, desc.DeploymentId, desc.EventId)
+	if err != nil {
+		debug.PrintStack()
+		return request, err
+	}
+
 	inputs := []NodeInput{}
 	for _, serviceId := range serviceIds {
 		deviceIdList := []string{}
@@ -179,7 +188,7 @@ func (this *Analytics) getPipelineRequestForGroupDeployment(token auth.AuthToken
 				{
 					NodeId: flowCells[0].Id,
 					Inputs: inputs,
-					Config: []NodeConfig{
+					Config: append([]NodeConfig{
 						{
 							Name:  "value",
 							Value: desc.OperatorValue,
@@ -212,11 +221,7 @@ func (this *Analytics) getPipelineRequestForGroupDeployment(token auth.AuthToken
 							Name:  "topicToServiceId",
 							Value: string(topicToServiceIdJson),
 						},
-						{
-							Name:  "userToken",
-							Value: string(token),
-						},
-					},
+					}, credentialConfig...),
 				},
 			},
 		}, nil
@@ -230,7 +235,7 @@ func (this *Analytics) getPipelineRequestForGroupDeployment(token auth.AuthToken
 				{
 					NodeId: flowCells[0].Id,
 					Inputs: inputs,
-					Config: []NodeConfig{
+					Config: append([]NodeConfig{
 						{
 							Name:  "value",
 							Value: desc.OperatorValue,
@@ -267,11 +272,7 @@ func (this *Analytics) getPipelineRequestForGroupDeployment(token auth.AuthToken
 							Name:  "topicToPathAndCharacteristic",
 							Value: string(topicToPathAndCharacteristicStr),
 						},
-						{
-							Name:  "userToken",
-							Value: string(token),
-						},
-					},
+					}, credentialConfig...),
 				},
 			},
 		}, nil
@@ -17,23 +17,24 @@
 package analytics
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"log"
-	"net/http"
 	"runtime/debug"
 )
 
-func (this *Analytics) DeployImport(token auth.AuthToken, label string, user string, desc model.GroupEventDescription, topic string, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (pipelineId string, err error) {
-	request, err := this.getPipelineRequestForImportDeployment(token, label, user, desc, topic, path, castFrom, castTo, castExtensions)
+func (this *Analytics) DeployImport(ctx context.Context, token auth.AuthToken, label string, user string, desc model.GroupEventDescription, topic string, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (pipelineId string, err error) {
+	request, err := this.getPipelineRequestForImportDeployment(ctx, token, label, user, desc, topic, path, castFrom, castTo, castExtensions)
 	if err != nil {
 		return "", err
 	}
-	pipeline, err, code := this.sendDeployRequest(token, user, request)
+	pipeline, err := this.sendDeployRequest(ctx, token, user, request)
 	if err != nil {
-		log.Println("ERROR: unable to deploy pipeline", err.Error(), code)
+		log.Println("ERROR: unable to deploy pipeline", err.Error())
 		debug.PrintStack()
 		return "", err
 	}
@@ -41,12 +42,12 @@ func (this *Analytics) DeployImport(token auth.AuthToken, label string, user str
 	return pipelineId, nil
 }
 
-func (this *Analytics) getPipelineRequestForImportDeployment(token auth.AuthToken, label string, user string, desc model.GroupEventDescription, topic string, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (request PipelineRequest, err error) {
-	flowCells, err, code := this.GetFlowInputs(desc.FlowId, user)
+func (this *Analytics) getPipelineRequestForImportDeployment(ctx context.Context, token auth.AuthToken, label string, user string, desc model.GroupEventDescription, topic string, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (request PipelineRequest, err error) {
+	flowCells, err := this.GetFlowInputs(ctx, desc.FlowId, user)
 	if err != nil {
-		log.Println("ERROR: unable to get flow inputs", err.Error(), code)
-		if code == http.StatusNotFound || code == http.StatusForbidden || code == http.StatusUnauthorized {
-			log.Println("unable to find flow (ignore deployment)", code, err)
+		log.Println("ERROR: unable to get flow inputs", err.Error())
+		if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrForbidden) {
+			log.Println("unable to find flow (ignore deployment)", err)
 			err = nil
 		}
 		return request, err
@@ -72,6 +73,12 @@ func (this *Analytics) getPipelineRequestForImportDeployment(token auth.AuthToke
 		return request, err
 	}
 
+	credentialConfig, err := this.getCredentialConfig(ctx, token, user, desc.DeploymentId, desc.EventId)
+	if err != nil {
+		debug.PrintStack()
+		return request, err
+	}
+
 	inputs := []NodeInput{}
 
 	inputs = append(inputs, NodeInput{
@@ -113,7 +120,7 @@ func (this *Analytics) getPipelineRequestForImportDeployment(token auth.AuthToke
 			{
 				NodeId: flowCells[0].Id,
 				Inputs: inputs,
-				Config: []NodeConfig{
+				Config: append([]NodeConfig{
 					{
 						Name:  "value",
 						Value: desc.OperatorValue,
@@ -146,11 +153,7 @@ func (this *Analytics) getPipelineRequestForImportDeployment(token auth.AuthToke
 						Name:  "castExtensions",
 						Value: castExtensionsJson,
 					},
-					{
-						Name:  "userToken",
-						Value: string(token),
-					},
-				},
+				}, credentialConfig...),
 			},
 		},
 	}, nil
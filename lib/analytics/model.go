@@ -17,7 +17,7 @@
 package analytics
 
 import (
-	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -58,8 +58,10 @@ type NodeValue struct {
 
 type Pipeline struct {
 	Id          uuid.UUID  `json:"id,omitempty"`
+	FlowId      string     `json:"flowId,omitempty"`
 	Name        string     `json:"name,omitempty"`
 	Description string     `json:"description,omitempty"`
+	WindowTime  int        `json:"windowTime,omitempty"`
 	Operators   []Operator `json:"operators,omitempty"`
 }
 
@@ -126,18 +128,4 @@ type Flow struct {
 	Model       FlowModel `json:"model"`
 }
 
-type EventPipelineDescription struct {
-	GenericEventSource *models.GenericEventSource `json:"generic_event_source,omitempty"`
-	ImportId           string                     `json:"import_id,omitempty"`
-	DeviceGroupId      string                     `json:"device_group_id,omitempty"`
-	DeviceId           string                     `json:"device_id,omitempty"`
-	ServiceId          string                     `json:"service_id,omitempty"`
-	FunctionId         string                     `json:"function_id,omitempty"`
-	AspectId           string                     `json:"aspect_id,omitempty"`
-	ValuePath          string                     `json:"value_path,omitempty"`
-	OperatorValue      string                     `json:"operator_value"`
-	EventId            string                     `json:"event_id"`
-	DeploymentId       string                     `json:"deployment_id"`
-	FlowId             string                     `json:"flow_id,omitempty"`
-	UseMarshaller      bool                       `json:"use_marshaller,omitempty"`
-}
+type EventPipelineDescription = model.EventPipelineDescription
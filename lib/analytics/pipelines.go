@@ -17,17 +17,20 @@
 package analytics
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"net/http"
 	"runtime/debug"
 	"strconv"
+	"strings"
 )
 
-func (this *Analytics) GetPipelinesByDeploymentId(owner string, deploymentId string) (pipelineIds []string, err error) {
+func (this *Analytics) GetPipelinesByDeploymentId(ctx context.Context, owner string, deploymentId string) (pipelineIds []string, err error) {
 	pipelineIds = []string{}
-	pipelines, err := this.getPipelines(owner)
+	pipelines, err := this.getPipelines(ctx, owner)
 	if err != nil {
 		return pipelineIds, err
 	}
@@ -46,8 +49,8 @@ func (this *Analytics) GetPipelinesByDeploymentId(owner string, deploymentId str
 	return pipelineIds, nil
 }
 
-func (this *Analytics) GetPipelineByEventId(owner string, eventId string) (pipelineId string, exists bool, err error) {
-	pipelines, err := this.getPipelines(owner)
+func (this *Analytics) GetPipelineByEventId(ctx context.Context, owner string, eventId string) (pipelineId string, exists bool, err error) {
+	pipelines, err := this.getPipelines(ctx, owner)
 	if err != nil {
 		return pipelineId, exists, err
 	}
@@ -66,10 +69,47 @@ func (this *Analytics) GetPipelineByEventId(owner string, eventId string) (pipel
 	return "", false, nil
 }
 
-func (this *Analytics) GetPipelinesByDeviceGroupId(owner string, groupId string) (pipelineIds []string, pipelineToGroupDescription map[string]model.GroupEventDescription, pipelineNames map[string]string, err error) {
+func (this *Analytics) GetEventPipelines(ctx context.Context, owner string, eventId string) (result []model.EventPipelineDetails, err error) {
+	pipelines, err := this.getPipelines(ctx, owner)
+	if err != nil {
+		return result, err
+	}
+	for _, pipeline := range pipelines {
+		desc := EventPipelineDescription{}
+		err = json.Unmarshal([]byte(pipeline.Description), &desc)
+		if err != nil {
+			//candidate does not use event pipeline description format -> is not event pipeline -> is not searched pipeline
+			err = nil
+			continue
+		}
+		if desc.EventId != eventId {
+			continue
+		}
+		details := model.EventPipelineDetails{
+			PipelineId:  pipeline.Id.String(),
+			Name:        pipeline.Name,
+			Description: desc,
+			Inputs:      []model.EventPipelineInput{},
+		}
+		for _, operator := range pipeline.Operators {
+			for _, topic := range operator.InputTopics {
+				details.Inputs = append(details.Inputs, model.EventPipelineInput{
+					OperatorId: operator.Id,
+					Topic:      topic.Name,
+					FilterType: topic.FilterType,
+					FilterIds:  strings.Split(topic.FilterValue, ","),
+				})
+			}
+		}
+		result = append(result, details)
+	}
+	return result, nil
+}
+
+func (this *Analytics) GetPipelinesByDeviceGroupId(ctx context.Context, owner string, groupId string) (pipelineIds []string, pipelineToGroupDescription map[string]model.GroupEventDescription, pipelineNames map[string]string, err error) {
 	pipelineToGroupDescription = map[string]model.GroupEventDescription{}
 	pipelineNames = map[string]string{}
-	pipelines, err := this.getPipelines(owner)
+	pipelines, err := this.getPipelines(ctx, owner)
 	if err != nil {
 		return pipelineIds, pipelineToGroupDescription, pipelineNames, err
 	}
@@ -100,9 +140,9 @@ func (this *Analytics) GetPipelinesByDeviceGroupId(owner string, groupId string)
 	return pipelineIds, pipelineToGroupDescription, pipelineNames, err
 }
 
-func (this *Analytics) GetEventStates(owner string, eventIds []string) (states map[string]bool, err error) {
+func (this *Analytics) GetEventStates(ctx context.Context, owner string, eventIds []string) (states map[string]bool, err error) {
 	states = map[string]bool{}
-	pipelines, err := this.getPipelines(owner)
+	pipelines, err := this.getPipelines(ctx, owner)
 	if err != nil {
 		return states, err
 	}
@@ -129,11 +169,11 @@ func (this *Analytics) GetEventStates(owner string, eventIds []string) (states m
 	return states, nil
 }
 
-func (this *Analytics) getPipelines(user string) (pipelines []Pipeline, err error) {
+func (this *Analytics) getPipelines(ctx context.Context, user string) (pipelines []Pipeline, err error) {
 	limit := int(this.config.AnalyticsPipelineBatchSize)
 	offset := 0
 	for {
-		temp, err := this.getSomePipelines(user, limit, offset)
+		temp, err := this.getSomePipelines(ctx, user, limit, offset)
 		if err != nil {
 			return pipelines, err
 		}
@@ -148,11 +188,12 @@ func (this *Analytics) getPipelines(user string) (pipelines []Pipeline, err erro
 	}
 }
 
-func (this *Analytics) getSomePipelines(user string, limit int, offset int) (pipelines []Pipeline, err error) {
+func (this *Analytics) getSomePipelines(ctx context.Context, user string, limit int, offset int) (pipelines []Pipeline, err error) {
 	client := http.Client{
 		Timeout: this.timeout,
 	}
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		this.config.PipelineRepoUrl+"/pipeline?limit="+strconv.Itoa(limit)+"&offset="+strconv.Itoa(offset),
 		nil,
@@ -165,12 +206,12 @@ func (this *Analytics) getSomePipelines(user string, limit int, offset int) (pip
 	resp, err := client.Do(req)
 	if err != nil {
 		debug.PrintStack()
-		return pipelines, err
+		return pipelines, errs.FromRequestError(dependencyPipelineRepository, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
 		debug.PrintStack()
-		return pipelines, errors.New("unexpected statuscode")
+		return pipelines, errs.FromStatusCode(dependencyPipelineRepository, resp.StatusCode, errors.New("unexpected statuscode"))
 	}
 	var pipelinesResp PipelinesResponse
 	err = json.NewDecoder(resp.Body).Decode(&pipelinesResp)
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"log"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+const UserTokenConfigName = "userToken"
+
+// GetPipelinesWithExpiredUserToken returns event pipelines of owner with a userToken config, which expires within buffer
+func (this *Analytics) GetPipelinesWithExpiredUserToken(ctx context.Context, owner string, buffer time.Duration) (pipelineIds []string, err error) {
+	pipelines, err := this.getPipelines(ctx, owner)
+	if err != nil {
+		return pipelineIds, err
+	}
+	for _, pipeline := range pipelines {
+		desc := EventPipelineDescription{}
+		err = json.Unmarshal([]byte(pipeline.Description), &desc)
+		if err != nil {
+			//candidate does not use event pipeline description format -> is not event pipeline -> is not searched pipeline
+			err = nil
+			continue
+		}
+		if userTokenExpires(pipeline, buffer) {
+			pipelineIds = append(pipelineIds, pipeline.Id.String())
+		}
+	}
+	return pipelineIds, nil
+}
+
+// UpdatePipelineUserToken redeploys the pipeline unchanged, except for the userToken config, which is set to token
+func (this *Analytics) UpdatePipelineUserToken(ctx context.Context, token auth.AuthToken, owner string, pipelineId string) (err error) {
+	pipeline, err := this.getPipeline(ctx, owner, pipelineId)
+	if err != nil {
+		return err
+	}
+	request := PipelineRequest{
+		Id:          pipeline.Id.String(),
+		FlowId:      pipeline.FlowId,
+		Name:        pipeline.Name,
+		Description: pipeline.Description,
+		WindowTime:  pipeline.WindowTime,
+	}
+	for _, operator := range pipeline.Operators {
+		node := PipelineNode{NodeId: operator.Id}
+		for _, topic := range operator.InputTopics {
+			input := NodeInput{
+				FilterIds:  topic.FilterValue,
+				FilterType: topic.FilterType,
+				TopicName:  topic.Name,
+			}
+			for _, mapping := range topic.Mappings {
+				input.Values = append(input.Values, NodeValue{Name: mapping.Dest, Path: mapping.Source})
+			}
+			node.Inputs = append(node.Inputs, input)
+		}
+		for name, value := range operator.Config {
+			if name == UserTokenConfigName {
+				value = string(token)
+			}
+			node.Config = append(node.Config, NodeConfig{Name: name, Value: value})
+		}
+		sort.Slice(node.Config, func(i, j int) bool {
+			return node.Config[i].Name < node.Config[j].Name
+		})
+		request.Nodes = append(request.Nodes, node)
+	}
+	_, err = this.sendUpdateRequest(ctx, token, owner, request)
+	if err != nil {
+		log.Println("ERROR: unable to update pipeline user token", pipelineId, err.Error())
+		return err
+	}
+	return nil
+}
+
+func userTokenExpires(pipeline Pipeline, buffer time.Duration) bool {
+	for _, operator := range pipeline.Operators {
+		token, ok := operator.Config[UserTokenConfigName]
+		if !ok || token == "" {
+			continue
+		}
+		expiration, ok, err := auth.TokenExpiration(token)
+		if err != nil {
+			log.Println("WARNING: unable to read userToken expiration of pipeline", pipeline.Id.String(), err)
+			continue
+		}
+		if ok && time.Until(expiration) < buffer {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *Analytics) getPipeline(ctx context.Context, user string, pipelineId string) (pipeline Pipeline, err error) {
+	client := http.Client{
+		Timeout: this.timeout,
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		this.config.PipelineRepoUrl+"/pipeline/"+url.PathEscape(pipelineId),
+		nil,
+	)
+	if err != nil {
+		debug.PrintStack()
+		return pipeline, err
+	}
+	req.Header.Set("X-UserId", user)
+	resp, err := client.Do(req)
+	if err != nil {
+		debug.PrintStack()
+		return pipeline, errs.FromRequestError(dependencyPipelineRepository, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		debug.PrintStack()
+		return pipeline, errs.FromStatusCode(dependencyPipelineRepository, resp.StatusCode, errors.New("unexpected statuscode"))
+	}
+	err = json.NewDecoder(resp.Body).Decode(&pipeline)
+	return pipeline, err
+}
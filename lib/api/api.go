@@ -19,6 +19,7 @@ package api
 import (
 	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/api/util"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
 	"github.com/SENERGY-Platform/service-commons/pkg/accesslog"
@@ -26,6 +27,7 @@ import (
 	"net/http"
 	"reflect"
 	"runtime"
+	"sync"
 	"time"
 )
 
@@ -33,9 +35,12 @@ import (
 
 var endpoints []func(*http.ServeMux, config.Config, interfaces.Events)
 
-func Start(ctx context.Context, config config.Config, ctrl interfaces.Events) error {
+func Start(ctx context.Context, wg *sync.WaitGroup, config config.Config, ctrl interfaces.Events) error {
 	log.Println("start api")
-	router := Router(config, ctrl)
+	router, err := Router(config, ctrl)
+	if err != nil {
+		return err
+	}
 	timeout, err := time.ParseDuration(config.HttpServerTimeout)
 	if err != nil {
 		log.Println("WARNING: invalid http server timeout --> no timeouts\n", err)
@@ -47,6 +52,12 @@ func Start(ctx context.Context, config config.Config, ctrl interfaces.Events) er
 		log.Println("WARNING: invalid http server read timeout --> no timeouts\n", err)
 		err = nil
 	}
+	shutdownTimeout, err := time.ParseDuration(config.ShutdownTimeout)
+	if err != nil {
+		log.Println("WARNING: invalid shutdown timeout --> use 10s\n", err)
+		shutdownTimeout = 10 * time.Second
+		err = nil
+	}
 	server := &http.Server{Addr: ":" + config.ApiPort, Handler: router, WriteTimeout: timeout, ReadTimeout: readtimeout}
 	go func() {
 		log.Println("Listening on ", server.Addr)
@@ -55,9 +66,14 @@ func Start(ctx context.Context, config config.Config, ctrl interfaces.Events) er
 			log.Fatal(err)
 		}
 	}()
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		<-ctx.Done()
-		log.Println("DEBUG: api shutdown", server.Shutdown(context.Background()))
+		//waits for running requests to finish
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		log.Println("DEBUG: api shutdown", server.Shutdown(shutdownCtx))
 	}()
 	return nil
 }
@@ -72,13 +88,27 @@ func Start(ctx context.Context, config config.Config, ctrl interfaces.Events) er
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
-func Router(config config.Config, ctrl interfaces.Events) http.Handler {
+func Router(config config.Config, ctrl interfaces.Events) (http.Handler, error) {
+	err := util.ValidateTrustedProxies(config.AuditTrustedProxies)
+	if err != nil {
+		return nil, err
+	}
 	router := http.NewServeMux()
 	for _, e := range endpoints {
 		log.Println("add endpoints: " + runtime.FuncForPC(reflect.ValueOf(e).Pointer()).Name())
 		e(router, config, ctrl)
 	}
-	log.Println("add logging and cors")
-	corsHandler := util.NewCors(router)
-	return accesslog.New(corsHandler)
+	verifier, err := auth.NewTokenVerifier(config)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := time.ParseDuration(config.HttpServerTimeout)
+	if err != nil {
+		timeout = 0
+	}
+	log.Println("add logging, cors, token verification and request timeout")
+	timeoutHandler := util.NewTimeout(router, timeout)
+	authHandler := util.NewAuthMiddleware(timeoutHandler, verifier, "/health", "/doc")
+	corsHandler := util.NewCors(authHandler)
+	return accesslog.New(corsHandler), nil
 }
@@ -0,0 +1,181 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/golang-jwt/jwt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eventsMock implements the methods used by the tested endpoints; calls to other methods panic
+type eventsMock struct {
+	interfaces.Events
+	exchange func(ctx context.Context, key string) (string, error)
+}
+
+func (this *eventsMock) ExchangePipelineCredential(ctx context.Context, key string) (string, error) {
+	return this.exchange(ctx, key)
+}
+
+func testToken(t *testing.T, claims auth.Claims) string {
+	if claims.ExpiresAt == 0 {
+		claims.ExpiresAt = time.Now().Add(time.Hour).Unix()
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims).SignedString([]byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+func testRequest(t *testing.T, router http.Handler, method string, path string, token string, body string) (code int, respBody string) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	temp, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Code, string(temp)
+}
+
+func TestPipelineCredentialExchangeAuthorization(t *testing.T) {
+	ctrl := &eventsMock{exchange: func(ctx context.Context, key string) (string, error) {
+		return "Bearer token_of_" + key, nil
+	}}
+	router, err := Router(&config.ConfigStruct{
+		DevDisableTokenVerification: true,
+		PipelineCredentialClients:   []string{"flow-engine"},
+	}, ctrl)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	body := `{"credential_ref":"key"}`
+
+	t.Run("without token", func(t *testing.T) {
+		code, _ := testRequest(t, router, http.MethodPost, "/pipeline-credentials/token", "", body)
+		if code != http.StatusUnauthorized {
+			t.Error(code)
+		}
+	})
+
+	t.Run("user token", func(t *testing.T) {
+		code, _ := testRequest(t, router, http.MethodPost, "/pipeline-credentials/token", testToken(t, auth.Claims{Sub: "user", AuthorizedParty: "frontend"}), body)
+		if code != http.StatusForbidden {
+			t.Error(code)
+		}
+	})
+
+	t.Run("pipeline credential client", func(t *testing.T) {
+		code, resp := testRequest(t, router, http.MethodPost, "/pipeline-credentials/token", testToken(t, auth.Claims{Sub: "service-account-flow-engine", AuthorizedParty: "flow-engine"}), body)
+		if code != http.StatusOK || !strings.Contains(resp, "token_of_key") {
+			t.Error(code, resp)
+		}
+	})
+}
+
+func TestRejectForgedToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	jwks := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		json.NewEncoder(writer).Encode(map[string][]map[string]string{"keys": {{
+			"kid": "k1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer jwks.Close()
+
+	exchanged := false
+	ctrl := &eventsMock{exchange: func(ctx context.Context, key string) (string, error) {
+		exchanged = true
+		return "Bearer token_of_" + key, nil
+	}}
+	router, err := Router(&config.ConfigStruct{
+		AuthEndpoint:              jwks.URL,
+		PipelineCredentialClients: []string{"flow-engine"},
+	}, ctrl)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	claims := auth.Claims{
+		Sub:             "service-account-flow-engine",
+		Issuer:          jwks.URL + "/auth/realms/master",
+		AuthorizedParty: "flow-engine",
+		ExpiresAt:       time.Now().Add(time.Hour).Unix(),
+	}
+	sign := func(key *rsa.PrivateKey) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, &claims)
+		token.Header["kid"] = "k1"
+		result, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + result
+	}
+	body := `{"credential_ref":"key"}`
+
+	t.Run("forged", func(t *testing.T) {
+		foreign, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		code, _ := testRequest(t, router, http.MethodPost, "/pipeline-credentials/token", sign(foreign), body)
+		if code != http.StatusUnauthorized || exchanged {
+			t.Error(code, exchanged)
+		}
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		code, _ := testRequest(t, router, http.MethodPost, "/pipeline-credentials/token", testToken(t, claims), body)
+		if code != http.StatusUnauthorized || exchanged {
+			t.Error(code, exchanged)
+		}
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		code, _ := testRequest(t, router, http.MethodPost, "/pipeline-credentials/token", sign(key), body)
+		if code != http.StatusOK || !exchanged {
+			t.Error(code, exchanged)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package api
+
+import (
+	"github.com/SENERGY-Platform/event-deployment/lib/api/util"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"net/http"
+	"time"
+)
+
+// audited runs the mutation and writes an audit entry with the token subject, endpoint and caller ip of the request.
+// if the entry can not be written, the request fails, even if the mutation succeeded; mutations are idempotent and may be repeated by the client.
+func audited(conf config.Config, ctrl interfaces.Events, request *http.Request, action string, deploymentId string, groupId string, mutation func() error) error {
+	start := time.Now()
+	entry := model.AuditEntry{
+		Time:          config.TimeNow(),
+		Who:           util.GetClaims(request).Sub,
+		Action:        action,
+		DeploymentId:  deploymentId,
+		DeviceGroupId: groupId,
+		Source: model.AuditSource{
+			Kind:     model.AuditSourceHttp,
+			Endpoint: request.Pattern,
+			CallerIp: util.GetCallerIp(request, conf.AuditTrustedProxies),
+		},
+	}
+	err := mutation()
+	entry.Finish(time.Since(start), err)
+	auditErr := ctrl.Audit(entry)
+	if auditErr != nil {
+		return auditErr
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/api/util"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"slices"
+)
+
+func init() {
+	endpoints = append(endpoints, PipelineCredentialsEndpoints)
+}
+
+type PipelineCredentialRequest struct {
+	CredentialRef string `json:"credential_ref"`
+}
+
+type PipelineCredentialResponse struct {
+	Token string `json:"token"`
+}
+
+// PipelineCredentialsEndpoints godoc
+// @Summary      exchange pipeline credential
+// @Description  exchange the credentialRef of a pipeline for a user token; only available if pipeline_auth_mode is credential_reference; only service tokens of the pipeline_credential_clients may access this endpoint
+// @Tags         pipeline-credentials
+// @Accept       json
+// @Produce      json
+// @Security Bearer
+// @Param        message body PipelineCredentialRequest true "credentialRef of the pipeline config"
+// @Success      200 {object} PipelineCredentialResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Failure      503
+// @Router       /pipeline-credentials/token [POST]
+func PipelineCredentialsEndpoints(router *http.ServeMux, config config.Config, ctrl interfaces.Events) {
+	router.HandleFunc("POST /pipeline-credentials/token", func(writer http.ResponseWriter, request *http.Request) {
+		claims := util.GetClaims(request)
+		if !slices.Contains(config.PipelineCredentialClients, claims.AuthorizedParty) {
+			http.Error(writer, "only pipeline credential clients may use this endpoint", http.StatusForbidden)
+			return
+		}
+		msg := PipelineCredentialRequest{}
+		err := json.NewDecoder(request.Body).Decode(&msg)
+		if err != nil || msg.CredentialRef == "" {
+			http.Error(writer, "expect credential_ref", http.StatusBadRequest)
+			return
+		}
+		token, err := ctrl.ExchangePipelineCredential(request.Context(), msg.CredentialRef)
+		if err != nil {
+			http.Error(writer, err.Error(), errs.StatusCode(err))
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(PipelineCredentialResponse{Token: token})
+		if err != nil {
+			log.Println("ERROR:", err)
+			debug.PrintStack()
+		}
+	})
+}
@@ -18,10 +18,12 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/api/util"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
-	"github.com/SENERGY-Platform/service-commons/pkg/jwt"
 	"net/http"
 )
 
@@ -44,21 +46,18 @@ func init() {
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      409
 // @Failure      500
 // @Router       /process-deployments [PUT]
 func SetDeploymentEndpoint(router *http.ServeMux, config config.Config, ctrl interfaces.Events) {
 	router.HandleFunc("PUT /process-deployments", func(writer http.ResponseWriter, request *http.Request) {
-		token, err := jwt.Parse(request.Header.Get("Authorization"))
-		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
-			return
-		}
-		if !token.IsAdmin() {
+		claims := util.GetClaims(request)
+		if !claims.IsAdmin() {
 			http.Error(writer, "only admins may use this endpoint", http.StatusUnauthorized)
 			return
 		}
 		var deployment model.Deployment
-		err = json.NewDecoder(request.Body).Decode(&deployment)
+		err := json.NewDecoder(request.Body).Decode(&deployment)
 		if err != nil {
 			http.Error(writer, err.Error(), http.StatusBadRequest)
 			return
@@ -71,9 +70,18 @@ func SetDeploymentEndpoint(router *http.ServeMux, config config.Config, ctrl int
 			http.Error(writer, "missing deployment userid", http.StatusBadRequest)
 			return
 		}
-		err = ctrl.Deploy(deployment.UserId, deployment.Deployment)
+		if deployment.Activation != nil {
+			err = deployment.Activation.Validate()
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		err = audited(config, ctrl, request, model.AuditActionPut, deployment.Id, "", func() error {
+			return ctrl.Deploy(request.Context(), deployment.UserId, deployment)
+		})
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			http.Error(writer, err.Error(), mutationStatusCode(err))
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
@@ -92,24 +100,23 @@ func SetDeploymentEndpoint(router *http.ServeMux, config config.Config, ctrl int
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      409
 // @Failure      500
 // @Router       /process-deployments/{userid}/{deplid} [DELETE]
 func DeleteDeploymentEndpoint(router *http.ServeMux, config config.Config, ctrl interfaces.Events) {
 	router.HandleFunc("DELETE /process-deployments/{userid}/{deplid}", func(writer http.ResponseWriter, request *http.Request) {
 		userid := request.PathValue("userid")
 		deplid := request.PathValue("deplid")
-		token, err := jwt.Parse(request.Header.Get("Authorization"))
-		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
-			return
-		}
-		if !token.IsAdmin() {
+		claims := util.GetClaims(request)
+		if !claims.IsAdmin() {
 			http.Error(writer, "only admins may use this endpoint", http.StatusUnauthorized)
 			return
 		}
-		err = ctrl.Remove(userid, deplid)
+		err := audited(config, ctrl, request, model.AuditActionDelete, deplid, "", func() error {
+			return ctrl.Remove(request.Context(), userid, deplid)
+		})
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			http.Error(writer, err.Error(), mutationStatusCode(err))
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
@@ -127,27 +134,34 @@ func DeleteDeploymentEndpoint(router *http.ServeMux, config config.Config, ctrl
 // @Failure      401
 // @Failure      403
 // @Failure      404
+// @Failure      409
 // @Failure      500
 // @Router       /device-groups/{id} [POST]
 func UpdateDeploymentsOfDeviceGroup(router *http.ServeMux, config config.Config, ctrl interfaces.Events) {
 	router.HandleFunc("POST /device-groups/{id}", func(writer http.ResponseWriter, request *http.Request) {
-		token, err := jwt.Parse(request.Header.Get("Authorization"))
-		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
-			return
-		}
-		if !token.IsAdmin() {
+		claims := util.GetClaims(request)
+		if !claims.IsAdmin() {
 			http.Error(writer, "only admins may use this endpoint", http.StatusUnauthorized)
 			return
 		}
 
 		id := request.PathValue("id")
 
-		err = ctrl.UpdateDeviceGroup(id)
+		err := audited(config, ctrl, request, model.AuditActionGroupUpdate, "", id, func() error {
+			return ctrl.UpdateDeviceGroup(request.Context(), id, nil)
+		})
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			http.Error(writer, err.Error(), mutationStatusCode(err))
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
 	})
 }
+
+// mutationStatusCode returns http.StatusConflict for conflicts like a running rebuild replay, other errors are internal errors
+func mutationStatusCode(err error) int {
+	if errors.Is(err, errs.ErrConflict) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
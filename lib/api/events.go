@@ -17,14 +17,19 @@
 package api
 
 import (
+	"encoding/json"
 	"github.com/SENERGY-Platform/event-deployment/lib/api/util"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"log"
 	"net/http"
+	"runtime/debug"
 )
 
 func init() {
-	endpoints = append(endpoints, EventsEndpoints)
+	endpoints = append(endpoints, EventsEndpoints, EventDetailsEndpoints)
 }
 
 // EventsEndpoints godoc
@@ -44,7 +49,40 @@ func init() {
 func EventsEndpoints(router *http.ServeMux, config config.Config, ctrl interfaces.Events) {
 	router.HandleFunc("HEAD /events/{id}", func(writer http.ResponseWriter, request *http.Request) {
 		id := request.PathValue("id")
-		code := ctrl.CheckEvent(util.GetAuthToken(request), id)
+		code := ctrl.CheckEvent(request.Context(), util.GetAuthToken(request), id)
 		writer.WriteHeader(code)
 	})
 }
+
+type EventDetails = model.EventDetails
+
+// EventDetailsEndpoints godoc
+// @Summary      get event details
+// @Description  get the deployed analytics pipelines and conditional event descriptions of an event
+// @Tags         event
+// @Produce      json
+// @Security Bearer
+// @Param        id path string true "event id"
+// @Success      200 {object} EventDetails
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /events/{id} [GET]
+func EventDetailsEndpoints(router *http.ServeMux, config config.Config, ctrl interfaces.Events) {
+	router.HandleFunc("GET /events/{id}", func(writer http.ResponseWriter, request *http.Request) {
+		id := request.PathValue("id")
+		details, err := ctrl.GetEventDetails(request.Context(), util.GetAuthToken(request), id)
+		if err != nil {
+			http.Error(writer, err.Error(), errs.StatusCode(err))
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(details)
+		if err != nil {
+			log.Println("ERROR:", err)
+			debug.PrintStack()
+		}
+	})
+}
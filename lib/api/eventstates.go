@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"github.com/SENERGY-Platform/event-deployment/lib/api/util"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
 	"log"
 	"net/http"
@@ -54,9 +55,9 @@ func EventStatesEndpoints(router *http.ServeMux, config config.Config, ctrl inte
 		if idstring != "" {
 			ids = strings.Split(strings.Replace(idstring, " ", "", -1), ",")
 		}
-		states, err, code := ctrl.GetEventStates(util.GetAuthToken(request), ids)
+		states, err := ctrl.GetEventStates(request.Context(), util.GetAuthToken(request), ids)
 		if err != nil {
-			http.Error(writer, err.Error(), code)
+			http.Error(writer, err.Error(), errs.StatusCode(err))
 			return
 		}
 		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
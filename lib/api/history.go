@@ -0,0 +1,110 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/api/util"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+)
+
+func init() {
+	endpoints = append(endpoints, ListDeploymentVersionsEndpoint, RollbackDeploymentEndpoint)
+}
+
+type DeploymentVersion = model.DeploymentVersion
+
+// ListDeploymentVersionsEndpoint godoc
+// @Summary      list deployment versions
+// @Description  list the stored versions of a deployment with their generated event descriptions, newest first; only admins and the deployment owner may access this endpoint
+// @Tags         deployment
+// @Produce      json
+// @Security Bearer
+// @Param        id path string true "deployment id"
+// @Success      200 {array} DeploymentVersion
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Failure      501
+// @Router       /process-deployments/{id}/versions [GET]
+func ListDeploymentVersionsEndpoint(router *http.ServeMux, config config.Config, ctrl interfaces.Events) {
+	router.HandleFunc("GET /process-deployments/{id}/versions", func(writer http.ResponseWriter, request *http.Request) {
+		versions, err := ctrl.ListDeploymentVersions(request.Context(), util.GetAuthToken(request), request.PathValue("id"))
+		if err != nil {
+			http.Error(writer, err.Error(), errs.StatusCode(err))
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(versions)
+		if err != nil {
+			log.Println("ERROR:", err)
+			debug.PrintStack()
+		}
+	})
+}
+
+// RollbackDeploymentEndpoint godoc
+// @Summary      rollback deployment
+// @Description  deploy a previous deployment version again; if kafka is used, the rollback is published as deployment command and stored as new version when it is handled, the response contains the rolled back version; otherwise the rollback is applied locally and the response contains the new version; only admins and the deployment owner may access this endpoint
+// @Tags         deployment
+// @Produce      json
+// @Security Bearer
+// @Param        id path string true "deployment id"
+// @Param        version path integer true "version"
+// @Success      202 {object} DeploymentVersion
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      409
+// @Failure      500
+// @Failure      501
+// @Router       /process-deployments/{id}/versions/{version}/rollback [POST]
+func RollbackDeploymentEndpoint(router *http.ServeMux, config config.Config, ctrl interfaces.Events) {
+	router.HandleFunc("POST /process-deployments/{id}/versions/{version}/rollback", func(writer http.ResponseWriter, request *http.Request) {
+		version, err := strconv.ParseInt(request.PathValue("version"), 10, 64)
+		if err != nil {
+			http.Error(writer, "invalid version: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var result model.DeploymentVersion
+		err = audited(config, ctrl, request, model.AuditActionRollback, request.PathValue("id"), "", func() (err error) {
+			result, err = ctrl.RollbackDeployment(request.Context(), util.GetAuthToken(request), request.PathValue("id"), version)
+			return err
+		})
+		if err != nil {
+			http.Error(writer, err.Error(), errs.StatusCode(err))
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(http.StatusAccepted)
+		err = json.NewEncoder(writer).Encode(result)
+		if err != nil {
+			log.Println("ERROR:", err)
+			debug.PrintStack()
+		}
+	})
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/api/util"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+func init() {
+	endpoints = append(endpoints, RequeueOutboxEntriesEndpoint)
+}
+
+type OutboxRequeueResult = model.OutboxRequeueResult
+
+// RequeueOutboxEntriesEndpoint godoc
+// @Summary      requeue parked outbox entries
+// @Description  lets the outbox relay publish the parked entries again; entries are parked after outbox_max_attempts permanent errors, like a topic without producer; only admins may access this endpoint
+// @Tags         admin
+// @Produce      json
+// @Security Bearer
+// @Success      200 {object} OutboxRequeueResult
+// @Failure      401
+// @Failure      500
+// @Failure      501
+// @Router       /admin/outbox/requeue [POST]
+func RequeueOutboxEntriesEndpoint(router *http.ServeMux, config config.Config, ctrl interfaces.Events) {
+	router.HandleFunc("POST /admin/outbox/requeue", func(writer http.ResponseWriter, request *http.Request) {
+		claims := util.GetClaims(request)
+		if !claims.IsAdmin() {
+			http.Error(writer, "only admins may use this endpoint", http.StatusUnauthorized)
+			return
+		}
+		result, err := ctrl.RequeueOutboxEntries(request.Context())
+		if err != nil {
+			http.Error(writer, err.Error(), errs.StatusCode(err))
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(result)
+		if err != nil {
+			log.Println("ERROR:", err)
+			debug.PrintStack()
+		}
+	})
+}
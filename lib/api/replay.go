@@ -0,0 +1,104 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/api/util"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+func init() {
+	endpoints = append(endpoints, StartReplayEndpoint, GetReplayStatusEndpoint)
+}
+
+type ReplayRequest = model.ReplayRequest
+type ReplayStatus = model.ReplayStatus
+
+// StartReplayEndpoint godoc
+// @Summary      replay deployment commands
+// @Description  handle messages of the deployment topic again in the background; mode range replays a partition range, mode rebuild pauses the kafka consumers, replays the whole topic into the stored deployments and removes deployments without remaining commands afterwards; replayed commands are not audited and neither store history versions nor send done notifications; rebuild is not supported with analytics events and requires single_instance; only admins may access this endpoint
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security Bearer
+// @Param        message body ReplayRequest true "replay request"
+// @Success      202 {object} ReplayStatus
+// @Failure      400
+// @Failure      401
+// @Failure      409
+// @Failure      500
+// @Failure      501
+// @Router       /admin/replay [POST]
+func StartReplayEndpoint(router *http.ServeMux, config config.Config, ctrl interfaces.Events) {
+	router.HandleFunc("POST /admin/replay", func(writer http.ResponseWriter, request *http.Request) {
+		claims := util.GetClaims(request)
+		if !claims.IsAdmin() {
+			http.Error(writer, "only admins may use this endpoint", http.StatusUnauthorized)
+			return
+		}
+		var replayRequest model.ReplayRequest
+		err := json.NewDecoder(request.Body).Decode(&replayRequest)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		status, err := ctrl.StartReplay(replayRequest)
+		if err != nil {
+			http.Error(writer, err.Error(), errs.StatusCode(err))
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.WriteHeader(http.StatusAccepted)
+		err = json.NewEncoder(writer).Encode(status)
+		if err != nil {
+			log.Println("ERROR:", err)
+			debug.PrintStack()
+		}
+	})
+}
+
+// GetReplayStatusEndpoint godoc
+// @Summary      replay status
+// @Description  status of the running or last replay; only admins may access this endpoint
+// @Tags         admin
+// @Produce      json
+// @Security Bearer
+// @Success      200 {object} ReplayStatus
+// @Failure      401
+// @Router       /admin/replay [GET]
+func GetReplayStatusEndpoint(router *http.ServeMux, config config.Config, ctrl interfaces.Events) {
+	router.HandleFunc("GET /admin/replay", func(writer http.ResponseWriter, request *http.Request) {
+		claims := util.GetClaims(request)
+		if !claims.IsAdmin() {
+			http.Error(writer, "only admins may use this endpoint", http.StatusUnauthorized)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err := json.NewEncoder(writer).Encode(ctrl.GetReplayStatus())
+		if err != nil {
+			log.Println("ERROR:", err)
+			debug.PrintStack()
+		}
+	})
+}
@@ -16,8 +16,46 @@
 
 package util
 
-import "net/http"
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"net/http"
+	"slices"
+)
 
+type claimsContextKey struct{}
+
+// GetAuthToken returns the Authorization header; requests reaching the endpoints passed the AuthMiddleware
 func GetAuthToken(req *http.Request) string {
 	return req.Header.Get("Authorization")
 }
+
+// GetClaims returns the claims of the verified token
+func GetClaims(req *http.Request) (claims auth.Claims) {
+	claims, _ = req.Context().Value(claimsContextKey{}).(auth.Claims)
+	return claims
+}
+
+func NewAuthMiddleware(handler http.Handler, verifier *auth.TokenVerifier, publicPaths ...string) *AuthMiddleware {
+	return &AuthMiddleware{handler: handler, verifier: verifier, publicPaths: publicPaths}
+}
+
+// AuthMiddleware rejects requests without valid token, except for requests to publicPaths
+type AuthMiddleware struct {
+	handler     http.Handler
+	verifier    *auth.TokenVerifier
+	publicPaths []string
+}
+
+func (this *AuthMiddleware) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if slices.Contains(this.publicPaths, req.URL.Path) {
+		this.handler.ServeHTTP(res, req)
+		return
+	}
+	claims, err := this.verifier.Verify(GetAuthToken(req))
+	if err != nil {
+		http.Error(res, "invalid token: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	this.handler.ServeHTTP(res, req.WithContext(context.WithValue(req.Context(), claimsContextKey{}, claims)))
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package util
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// GetCallerIp returns the remote address of the request. X-Forwarded-For is only used, if the remote address is a trusted proxy;
+// the right-most entry, that is not a trusted proxy itself, is the caller. entries left of it are set by the client and can not be trusted.
+func GetCallerIp(req *http.Request, trustedProxies []string) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	if !isTrustedProxy(host, trustedProxies) {
+		return host
+	}
+	forwarded := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		entry := strings.TrimSpace(forwarded[i])
+		if entry == "" {
+			continue
+		}
+		if !isTrustedProxy(entry, trustedProxies) {
+			return entry
+		}
+		host = entry
+	}
+	return host
+}
+
+// ValidateTrustedProxies checks that every entry is an ip address or a cidr prefix
+func ValidateTrustedProxies(trustedProxies []string) error {
+	for _, proxy := range trustedProxies {
+		if _, err := parsePrefix(proxy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isTrustedProxy(ip string, trustedProxies []string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, proxy := range trustedProxies {
+		prefix, err := parsePrefix(proxy)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePrefix(proxy string) (netip.Prefix, error) {
+	if strings.Contains(proxy, "/") {
+		return netip.ParsePrefix(proxy)
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetCallerIp(t *testing.T) {
+	trusted := []string{"10.0.0.1", "172.16.0.0/12"}
+	if err := ValidateTrustedProxies(trusted); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := ValidateTrustedProxies([]string{"proxy"}); err == nil {
+		t.Error("expected error for invalid trusted proxy")
+	}
+	cases := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		trusted    []string
+		expected   string
+	}{
+		{name: "remote", remoteAddr: "192.168.0.5:1234", expected: "192.168.0.5"},
+		{name: "untrusted forwarded", remoteAddr: "192.168.0.5:1234", forwarded: []string{"1.2.3.4"}, trusted: trusted, expected: "192.168.0.5"},
+		{name: "no trusted proxies", remoteAddr: "10.0.0.1:1234", forwarded: []string{"1.2.3.4"}, expected: "10.0.0.1"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:1234", forwarded: []string{"1.2.3.4"}, trusted: trusted, expected: "1.2.3.4"},
+		{name: "spoofed entry", remoteAddr: "10.0.0.1:1234", forwarded: []string{"6.6.6.6, 1.2.3.4"}, trusted: trusted, expected: "1.2.3.4"},
+		{name: "proxy chain", remoteAddr: "10.0.0.1:1234", forwarded: []string{"6.6.6.6, 1.2.3.4", "172.17.0.2"}, trusted: trusted, expected: "1.2.3.4"},
+		{name: "only proxies", remoteAddr: "10.0.0.1:1234", forwarded: []string{"172.17.0.2"}, trusted: trusted, expected: "172.17.0.2"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = c.remoteAddr
+			for _, value := range c.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			actual := GetCallerIp(req, c.trusted)
+			if actual != c.expected {
+				t.Error(actual, c.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// NewTimeout sets a deadline on the context of every request, which is passed to all downstream calls of the request.
+// a timeout <= 0 disables the deadline.
+func NewTimeout(handler http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return handler
+	}
+	return &TimeoutMiddleware{handler: handler, timeout: timeout}
+}
+
+type TimeoutMiddleware struct {
+	handler http.Handler
+	timeout time.Duration
+}
+
+func (this *TimeoutMiddleware) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), this.timeout)
+	defer cancel()
+	this.handler.ServeHTTP(res, req.WithContext(ctx))
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"log"
+)
+
+// values of config.AuditSinks
+const (
+	SinkMongo = "mongo"
+	SinkKafka = "kafka"
+	SinkFile  = "file"
+)
+
+type Sink interface {
+	Write(entry model.AuditEntry) error
+}
+
+// SinkFunc allows repository methods like deployments.Deployments.AddAuditEntry to be used as Sink
+type SinkFunc func(entry model.AuditEntry) error
+
+func (this SinkFunc) Write(entry model.AuditEntry) error {
+	return this(entry)
+}
+
+// Log writes every entry to all sinks. failed writes are returned, so that the mutation is reported as failed
+// (api) or retried (kafka) instead of succeeding without audit record; the other sinks are still written.
+type Log struct {
+	sinks []Sink
+}
+
+func New(sinks ...Sink) *Log {
+	return &Log{sinks: sinks}
+}
+
+func (this *Log) Write(entry model.AuditEntry) (err error) {
+	for _, sink := range this.sinks {
+		sinkErr := sink.Write(entry)
+		if sinkErr != nil {
+			msg, _ := json.Marshal(entry)
+			log.Println("ERROR: unable to write audit entry", sinkErr, string(msg))
+			err = errors.Join(err, sinkErr)
+		}
+	}
+	return err
+}
+
+// ProducerSink publishes entries as json, keyed by deployment id or device-group id
+type ProducerSink struct {
+	producer interfaces.Producer
+}
+
+func NewProducerSink(producer interfaces.Producer) (*ProducerSink, error) {
+	if producer == nil {
+		return nil, errors.New("missing audit producer")
+	}
+	return &ProducerSink{producer: producer}, nil
+}
+
+func (this *ProducerSink) Write(entry model.AuditEntry) error {
+	msg, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	key := entry.DeploymentId
+	if key == "" {
+		key = entry.DeviceGroupId
+	}
+	//entries of canceled or timed out mutations must be written too, see interfaces.Events.Audit
+	return this.producer.Produce(context.Background(), key, msg)
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileSink(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(ctx, wg, path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	failed := 0
+	auditLog := New(SinkFunc(func(entry model.AuditEntry) error {
+		failed++
+		return errors.New("test sink error")
+	}), sink)
+
+	entries := []model.AuditEntry{
+		{
+			Time:         time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+			Who:          "owner",
+			Action:       model.AuditActionPut,
+			DeploymentId: "d1",
+			Source:       model.AuditSource{Kind: model.AuditSourceKafka, Topic: "process-deployment", Partition: 1, Offset: 42},
+			Result:       model.AuditResultSuccess,
+			DurationMs:   12,
+		},
+		{
+			Time:          time.Date(2026, 3, 1, 12, 1, 0, 0, time.UTC),
+			Who:           "admin",
+			Action:        model.AuditActionGroupUpdate,
+			DeviceGroupId: "g1",
+			Source:        model.AuditSource{Kind: model.AuditSourceHttp, Endpoint: "POST /device-groups/{id}", CallerIp: "10.0.0.1"},
+			Result:        model.AuditResultError,
+			Error:         "test",
+		},
+	}
+	for _, entry := range entries {
+		err = auditLog.Write(entry)
+		if err == nil {
+			t.Error("expected error of failing sink")
+		}
+	}
+	if failed != len(entries) {
+		t.Error("failing sink should not prevent writes to other sinks", failed)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer file.Close()
+	actual := []model.AuditEntry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := model.AuditEntry{}
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		actual = append(actual, entry)
+	}
+	if !reflect.DeepEqual(actual, entries) {
+		t.Errorf("\n%#v\n%#v\n", actual, entries)
+	}
+
+	cancel()
+	wg.Wait()
+	err = sink.Write(entries[0])
+	if !errors.Is(err, os.ErrClosed) {
+		t.Error("expected closed file error", err)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"log"
+	"os"
+	"sync"
+)
+
+// FileSink appends one json line per entry; the file is closed when ctx is done
+type FileSink struct {
+	mux  sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(ctx context.Context, wg *sync.WaitGroup, path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	result := &FileSink{file: file}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		result.mux.Lock()
+		defer result.mux.Unlock()
+		err := result.file.Close()
+		if err != nil {
+			log.Println("ERROR: unable to close audit file", err)
+		}
+		result.file = nil
+	}()
+	return result, nil
+}
+
+func (this *FileSink) Write(entry model.AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if this.file == nil {
+		return os.ErrClosed
+	}
+	//a single write per line keeps lines intact, if the file is shared by multiple processes
+	_, err = this.file.Write(append(line, '\n'))
+	return err
+}
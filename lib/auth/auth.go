@@ -17,10 +17,12 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
-	"github.com/SENERGY-Platform/service-commons/pkg/cache"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/golang-jwt/jwt"
 	"io"
 	"log"
@@ -50,17 +52,18 @@ type OpenidToken struct {
 }
 
 type Auth struct {
-	openid         *OpenidToken
-	config         config.Config
-	userTokenCache *cache.Cache
+	openid      *OpenidToken
+	config      config.Config
+	credentials *CredentialStore
 }
 
-func NewAuth(config config.Config) (*Auth, error) {
-	c, err := cache.New(cache.Config{})
+func NewAuth(config config.Config) (result *Auth, err error) {
+	result = &Auth{config: config}
+	result.credentials, err = NewCredentialStore(result, config)
 	if err != nil {
 		return nil, err
 	}
-	return &Auth{config: config, userTokenCache: c}, nil
+	return result, nil
 }
 
 func NewAuthWithoutCache(config config.Config) *Auth {
@@ -99,22 +102,29 @@ func (this *Auth) Ensure() (token AuthToken, err error) {
 	return
 }
 
-var ErrUserDoesNotExist = errors.New("user does not exist")
+var ErrUserDoesNotExist = errs.NotFound("auth", errors.New("user does not exist"))
 
-func (this *Auth) GetUserToken(userid string) (token AuthToken, err error) {
-	return cache.Use(this.userTokenCache, "user_token."+userid, func() (AuthToken, error) {
-		return this.getUserTokenCheckExistence(userid)
-	}, func(token AuthToken) error {
-		return nil
-	}, time.Duration(this.config.UserTokenCacheLifespanInSec)*time.Second)
+// GetUserToken returns a token of the user, exchanged by keycloak.
+// errors may be ErrUserDoesNotExist or ErrAuthUnavailable.
+func (this *Auth) GetUserToken(ctx context.Context, userid string) (token AuthToken, err error) {
+	if this.credentials == nil {
+		openid, err := this.getUserTokenCheckExistence(ctx, userid)
+		return AuthToken("Bearer " + openid.AccessToken), err
+	}
+	return this.credentials.GetUserToken(ctx, userid)
+}
+
+// Credentials returns nil if the Auth was created by NewAuthWithoutCache.
+func (this *Auth) Credentials() *CredentialStore {
+	return this.credentials
 }
 
-func (this *Auth) getUserTokenCheckExistence(userid string) (token AuthToken, err error) {
-	token, err = this.getUserToken(userid)
+func (this *Auth) getUserTokenCheckExistence(ctx context.Context, userid string) (token OpenidToken, err error) {
+	token, err = this.getUserToken(ctx, userid)
 	if err != nil {
-		exists, err := this.UserExists(userid)
-		if err != nil {
-			return token, err
+		exists, existsErr := this.UserExists(ctx, userid)
+		if existsErr != nil {
+			return token, existsErr
 		}
 		if !exists {
 			return token, ErrUserDoesNotExist
@@ -123,45 +133,55 @@ func (this *Auth) getUserTokenCheckExistence(userid string) (token AuthToken, er
 	return token, err
 }
 
-func (this *Auth) getUserToken(userid string) (token AuthToken, err error) {
-	resp, err := http.PostForm(this.config.AuthEndpoint+"/auth/realms/master/protocol/openid-connect/token", url.Values{
+func (this *Auth) getUserToken(ctx context.Context, userid string) (token OpenidToken, err error) {
+	requesttime := time.Now()
+	form := url.Values{
 		"client_id":         {this.config.AuthClientId},
 		"client_secret":     {this.config.AuthClientSecret},
 		"grant_type":        {"urn:ietf:params:oauth:grant-type:token-exchange"},
 		"requested_subject": {userid},
-	})
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, this.config.AuthEndpoint+"/auth/realms/master/protocol/openid-connect/token", strings.NewReader(form.Encode()))
 	if err != nil {
-		return
+		return token, err
 	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := newHttpClient(this.config).Do(req)
+	if err != nil {
+		return token, fmt.Errorf("%w: %w", ErrAuthUnavailable, err)
+	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		log.Println("ERROR: GetUserToken()", userid, resp.StatusCode, string(body))
-		err = errors.New("access denied")
-		resp.Body.Close()
-		return
+		if resp.StatusCode >= 500 {
+			return token, fmt.Errorf("%w: unexpected status code %v", ErrAuthUnavailable, resp.StatusCode)
+		}
+		return token, errors.New("access denied")
 	}
-	var openIdToken OpenidToken
-	err = json.NewDecoder(resp.Body).Decode(&openIdToken)
+	err = json.NewDecoder(resp.Body).Decode(&token)
 	if err != nil {
-		return
+		return token, err
 	}
-	return AuthToken("Bearer " + openIdToken.AccessToken), nil
+	token.RequestTime = requesttime
+	return token, nil
 }
 
-func (this *AuthToken) GetJSON(url string, result interface{}) (err error) {
-	resp, err := this.Get(url)
+// GetJSON classifies errors of the request with the errs package; dependency names the requested service in these errors
+func (this *AuthToken) GetJSON(ctx context.Context, dependency string, url string, result interface{}) (err error) {
+	resp, err := this.Get(ctx, url)
 	if err != nil {
-		return err
+		return errs.FromRequestError(dependency, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return errors.New("unexpected status code " + strconv.Itoa(resp.StatusCode))
+		return errs.FromStatusCode(dependency, resp.StatusCode, errors.New("unexpected status code "+strconv.Itoa(resp.StatusCode)))
 	}
 	return json.NewDecoder(resp.Body).Decode(&result)
 }
 
-func (this *AuthToken) Get(url string) (resp *http.Response, err error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (this *AuthToken) Get(ctx context.Context, url string) (resp *http.Response, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -170,9 +190,19 @@ func (this *AuthToken) Get(url string) (resp *http.Response, err error) {
 	return
 }
 
+// newHttpClient limits token requests by http_client_timeout (default DefaultExchangeTimeout),
+// so that a hanging auth service does not block callers, even if the timeout of http.DefaultClient is not set
+func newHttpClient(config config.Config) *http.Client {
+	timeout, err := time.ParseDuration(config.HttpClientTimeout)
+	if err != nil || timeout <= 0 {
+		timeout = DefaultExchangeTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
+
 func getOpenidToken(token *OpenidToken, config config.Config) (err error) {
 	requesttime := time.Now()
-	resp, err := http.PostForm(config.AuthEndpoint+"/auth/realms/master/protocol/openid-connect/token", url.Values{
+	resp, err := newHttpClient(config).PostForm(config.AuthEndpoint+"/auth/realms/master/protocol/openid-connect/token", url.Values{
 		"client_id":     {config.AuthClientId},
 		"client_secret": {config.AuthClientSecret},
 		"grant_type":    {"client_credentials"},
@@ -196,7 +226,7 @@ func getOpenidToken(token *OpenidToken, config config.Config) (err error) {
 
 func refreshOpenidToken(token *OpenidToken, config config.Config) (err error) {
 	requesttime := time.Now()
-	resp, err := http.PostForm(config.AuthEndpoint+"/auth/realms/master/protocol/openid-connect/token", url.Values{
+	resp, err := newHttpClient(config).PostForm(config.AuthEndpoint+"/auth/realms/master/protocol/openid-connect/token", url.Values{
 		"client_id":     {config.AuthClientId},
 		"client_secret": {config.AuthClientSecret},
 		"refresh_token": {token.RefreshToken},
@@ -250,30 +280,32 @@ type User struct {
 	//LastName   string                 `json:"lastName"`
 }
 
-func (this *Auth) GetUserById(id string) (user User, err error) {
+func (this *Auth) GetUserById(ctx context.Context, id string) (user User, err error) {
 	token, err := this.Ensure()
 	if err != nil {
 		return user, err
 	}
-	err = token.GetJSON(this.config.AuthEndpoint+"/auth/admin/realms/master/users/"+url.QueryEscape(id), &user)
+	err = token.GetJSON(ctx, "auth", this.config.AuthEndpoint+"/auth/admin/realms/master/users/"+url.QueryEscape(id), &user)
 	return
 }
 
-func (this *Auth) UserExists(id string) (exists bool, err error) {
+// UserExists returns ErrAuthUnavailable if the existence could not be checked
+func (this *Auth) UserExists(ctx context.Context, id string) (exists bool, err error) {
 	token, err := this.Ensure()
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
 	}
-	resp, err := token.Get(this.config.AuthEndpoint + "/auth/admin/realms/master/users/" + url.QueryEscape(id))
+	resp, err := token.Get(ctx, this.config.AuthEndpoint+"/auth/admin/realms/master/users/"+url.QueryEscape(id))
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("%w: %w", ErrAuthUnavailable, err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusNotFound {
 		return false, nil
 	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return false, errors.New(string(body))
+		return false, fmt.Errorf("%w: %v", ErrAuthUnavailable, string(body))
 	}
 	return true, nil
 }
@@ -0,0 +1,283 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"golang.org/x/sync/singleflight"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrAuthUnavailable is returned if keycloak could not be reached or answered with a server error,
+// or if the circuit breaker is open. in contrast to ErrUserDoesNotExist, a retry may succeed.
+var ErrAuthUnavailable = errs.Transient("auth", errors.New("auth unavailable"))
+
+// DefaultUserTokenRefreshBuffer is used if config.UserTokenRefreshBuffer is not set
+const DefaultUserTokenRefreshBuffer = 5 * time.Minute
+
+// DefaultExchangeTimeout limits a token exchange, if config.HttpClientTimeout is not set
+const DefaultExchangeTimeout = 30 * time.Second
+
+// CredentialStore holds exchanged user tokens.
+// after Start, tokens are refreshed in the background once they enter the refresh buffer before their expiration;
+// without Start, they are exchanged again when they are expired. concurrent exchanges for the same user are coalesced.
+// consecutive ErrAuthUnavailable failures open a circuit breaker with exponential backoff,
+// while it is open no requests are sent to keycloak.
+type CredentialStore struct {
+	auth            *Auth
+	lifespan        time.Duration
+	refreshBuffer   time.Duration
+	exchangeTimeout time.Duration
+	mux             sync.Mutex
+	credentials     map[string]credential
+	group           singleflight.Group
+	breaker         *circuitBreaker
+}
+
+type credential struct {
+	token      AuthToken
+	issued     time.Time
+	expiration time.Time
+}
+
+// refreshDue is true for valid tokens within the refresh buffer.
+// the buffer is limited to half of the token lifetime, so that short-lived tokens are not refreshed on every check.
+func (this credential) refreshDue(now time.Time, buffer time.Duration) bool {
+	if this.token == "" || !now.Before(this.expiration) {
+		return false
+	}
+	buffer = min(buffer, this.expiration.Sub(this.issued)/2)
+	return this.expiration.Sub(now) < buffer
+}
+
+func NewCredentialStore(auth *Auth, conf config.Config) (result *CredentialStore, err error) {
+	result = &CredentialStore{
+		auth:            auth,
+		lifespan:        time.Duration(conf.UserTokenCacheLifespanInSec) * time.Second,
+		credentials:     map[string]credential{},
+		refreshBuffer:   DefaultUserTokenRefreshBuffer,
+		exchangeTimeout: DefaultExchangeTimeout,
+		breaker: &circuitBreaker{
+			threshold:      conf.AuthCircuitBreakerThreshold,
+			initialBackoff: time.Second,
+			maxBackoff:     5 * time.Minute,
+		},
+	}
+	if conf.UserTokenRefreshBuffer != "" {
+		result.refreshBuffer, err = time.ParseDuration(conf.UserTokenRefreshBuffer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if conf.HttpClientTimeout != "" {
+		result.exchangeTimeout, err = time.ParseDuration(conf.HttpClientTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if conf.AuthBackoffInitial != "" {
+		result.breaker.initialBackoff, err = time.ParseDuration(conf.AuthBackoffInitial)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if conf.AuthBackoffMax != "" {
+		result.breaker.maxBackoff, err = time.ParseDuration(conf.AuthBackoffMax)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Start refreshes tokens, that enter the refresh buffer, until ctx is done.
+// tokens are checked in a quarter of the refresh buffer, so they are refreshed before their expiration.
+func (this *CredentialStore) Start(ctx context.Context, wg *sync.WaitGroup) {
+	if this.refreshBuffer <= 0 {
+		return
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(this.refreshBuffer / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				this.refreshDueTokens(ctx)
+			}
+		}
+	}()
+}
+
+func (this *CredentialStore) refreshDueTokens(ctx context.Context) {
+	now := time.Now()
+	due := []string{}
+	this.mux.Lock()
+	for userid, c := range this.credentials {
+		if c.refreshDue(now, this.refreshBuffer) {
+			due = append(due, userid)
+		}
+	}
+	this.mux.Unlock()
+	sort.Strings(due)
+	for _, userid := range due {
+		_, err := this.refresh(ctx, userid)
+		if errors.Is(err, ErrAuthUnavailable) {
+			log.Println("WARNING: unable to refresh user tokens in background", err)
+			return
+		}
+		if err != nil {
+			log.Println("WARNING: unable to refresh user token in background", userid, err)
+		}
+	}
+}
+
+func (this *CredentialStore) GetUserToken(ctx context.Context, userid string) (token AuthToken, err error) {
+	this.mux.Lock()
+	c, ok := this.credentials[userid]
+	valid := ok && c.token != "" && time.Now().Before(c.expiration)
+	this.mux.Unlock()
+	if valid {
+		return c.token, nil
+	}
+	return this.refresh(ctx, userid)
+}
+
+// Users returns the ids of all users a token has been requested for since startup, even if the token is expired.
+func (this *CredentialStore) Users() (result []string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for userid := range this.credentials {
+		result = append(result, userid)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// refresh coalesces concurrent exchanges of the user. the shared exchange keeps the values of the first ctx but is not canceled with it,
+// to not fail the other callers; it is limited by http_client_timeout, so that a hanging auth service does not block the user.
+// every caller stops waiting, when its own ctx is done.
+func (this *CredentialStore) refresh(ctx context.Context, userid string) (AuthToken, error) {
+	detached := context.WithoutCancel(ctx)
+	resultChan := this.group.DoChan(userid, func() (interface{}, error) {
+		exchangeCtx, cancel := context.WithTimeout(detached, this.exchangeTimeout)
+		defer cancel()
+		token, issued, expiration, err := this.exchange(exchangeCtx, userid)
+		this.mux.Lock()
+		defer this.mux.Unlock()
+		c := this.credentials[userid]
+		if err == nil {
+			c.token = token
+			c.issued = issued
+			c.expiration = expiration
+		}
+		if errors.Is(err, ErrUserDoesNotExist) {
+			delete(this.credentials, userid)
+		} else {
+			this.credentials[userid] = c
+		}
+		return token, err
+	})
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case result := <-resultChan:
+		token, _ := result.Val.(AuthToken)
+		return token, result.Err
+	}
+}
+
+func (this *CredentialStore) exchange(ctx context.Context, userid string) (token AuthToken, issued time.Time, expiration time.Time, err error) {
+	err = this.breaker.allow()
+	if err != nil {
+		return token, issued, expiration, err
+	}
+	openid, err := this.auth.getUserTokenCheckExistence(ctx, userid)
+	this.breaker.record(err)
+	if err != nil {
+		return token, issued, expiration, err
+	}
+	lifespan := this.lifespan
+	if openid.ExpiresIn > 0 && openid.ExpiresIn < lifespan.Seconds() {
+		lifespan = time.Duration(openid.ExpiresIn * float64(time.Second))
+	}
+	return AuthToken("Bearer " + openid.AccessToken), openid.RequestTime, openid.RequestTime.Add(lifespan), nil
+}
+
+// TokenExpiration reads the exp claim of token without verifying its signature.
+// tokens without (or with a non-positive) exp claim return ok == false.
+func TokenExpiration(token string) (expiration time.Time, ok bool, err error) {
+	claims, err := ParseUnverified(token)
+	if err != nil {
+		return expiration, false, err
+	}
+	if claims.ExpiresAt <= 0 {
+		return expiration, false, nil
+	}
+	return time.Unix(claims.ExpiresAt, 0), true, nil
+}
+
+type circuitBreaker struct {
+	threshold      int64
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	mux            sync.Mutex
+	failures       int64
+	openUntil      time.Time
+}
+
+func (this *circuitBreaker) allow() error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if time.Now().Before(this.openUntil) {
+		return fmt.Errorf("%w: circuit breaker open until %v", ErrAuthUnavailable, this.openUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// record counts consecutive ErrAuthUnavailable results; other errors show that keycloak is reachable.
+func (this *circuitBreaker) record(err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if !errors.Is(err, ErrAuthUnavailable) {
+		this.failures = 0
+		return
+	}
+	this.failures++
+	if this.failures < this.threshold || this.threshold <= 0 {
+		return
+	}
+	backoff := this.initialBackoff
+	for i := this.threshold; i < this.failures && backoff < this.maxBackoff; i++ {
+		backoff = backoff * 2
+	}
+	if backoff > this.maxBackoff {
+		backoff = this.maxBackoff
+	}
+	log.Println("WARNING: auth unavailable --> open circuit breaker for", backoff.String())
+	this.openUntil = time.Now().Add(backoff)
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type keycloakMock struct {
+	exchanges atomic.Int64
+	down      atomic.Bool
+	expiresIn float64
+}
+
+func (this *keycloakMock) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if this.down.Load() {
+		http.Error(writer, "down", http.StatusServiceUnavailable)
+		return
+	}
+	if strings.HasPrefix(request.URL.Path, "/auth/admin/realms/master/users/") {
+		if strings.HasSuffix(request.URL.Path, "/unknown") {
+			http.Error(writer, "not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(writer).Encode(User{Id: "user"})
+		return
+	}
+	expiresIn := this.expiresIn
+	if request.FormValue("grant_type") == "client_credentials" {
+		expiresIn = 3600
+	} else if request.FormValue("requested_subject") == "unknown" {
+		http.Error(writer, "unknown user", http.StatusBadRequest)
+		return
+	} else {
+		this.exchanges.Add(1)
+		time.Sleep(50 * time.Millisecond)
+	}
+	json.NewEncoder(writer).Encode(OpenidToken{
+		AccessToken: "token" + strconv.FormatInt(this.exchanges.Load(), 10),
+		ExpiresIn:   expiresIn,
+	})
+}
+
+func TestCredentialStore(t *testing.T) {
+	keycloak := &keycloakMock{expiresIn: 1}
+	server := httptest.NewServer(keycloak)
+	defer server.Close()
+
+	a, err := NewAuth(&config.ConfigStruct{
+		AuthEndpoint:                server.URL,
+		UserTokenCacheLifespanInSec: 3600,
+		UserTokenRefreshBuffer:      "500ms",
+		AuthCircuitBreakerThreshold: 2,
+		AuthBackoffInitial:          "200ms",
+		AuthBackoffMax:              "1s",
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("coalesce", func(t *testing.T) {
+		requests := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			requests.Add(1)
+			go func() {
+				defer requests.Done()
+				token, err := a.GetUserToken(context.Background(), "user")
+				if err != nil || token != "Bearer token1" {
+					t.Error(token, err)
+				}
+			}()
+		}
+		requests.Wait()
+		if exchanges := keycloak.exchanges.Load(); exchanges != 1 {
+			t.Error(exchanges)
+		}
+	})
+
+	t.Run("proactive refresh", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		wg := &sync.WaitGroup{}
+		a.Credentials().Start(ctx, wg)
+		time.Sleep(900 * time.Millisecond)
+		cancel()
+		wg.Wait()
+		token, err := a.GetUserToken(context.Background(), "user")
+		if err != nil || token != "Bearer token2" {
+			t.Error(token, err)
+		}
+		if exchanges := keycloak.exchanges.Load(); exchanges != 2 {
+			t.Error(exchanges)
+		}
+	})
+
+	t.Run("user does not exist", func(t *testing.T) {
+		_, err := a.GetUserToken(context.Background(), "unknown")
+		if !errors.Is(err, ErrUserDoesNotExist) {
+			t.Error(err)
+		}
+	})
+
+	t.Run("auth unavailable", func(t *testing.T) {
+		keycloak.down.Store(true)
+		time.Sleep(1200 * time.Millisecond)
+		for i := 0; i < 2; i++ {
+			_, err := a.GetUserToken(context.Background(), "user")
+			if !errors.Is(err, ErrAuthUnavailable) {
+				t.Error(err)
+			}
+		}
+		keycloak.down.Store(false)
+		_, err := a.GetUserToken(context.Background(), "user")
+		if !errors.Is(err, ErrAuthUnavailable) || !strings.Contains(err.Error(), "circuit breaker") {
+			t.Error(err)
+		}
+		time.Sleep(300 * time.Millisecond)
+		token, err := a.GetUserToken(context.Background(), "user")
+		if err != nil || token != "Bearer token3" {
+			t.Error(token, err)
+		}
+	})
+
+	t.Run("users", func(t *testing.T) {
+		users := a.Credentials().Users()
+		if len(users) != 1 || users[0] != "user" {
+			t.Error(users)
+		}
+	})
+
+	t.Run("canceled caller", func(t *testing.T) {
+		exchanges := keycloak.exchanges.Load()
+		result := make(chan error, 1)
+		go func() {
+			_, err := a.GetUserToken(context.Background(), "user2")
+			result <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := a.GetUserToken(ctx, "user2")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Error(err)
+		}
+		err = <-result
+		if err != nil {
+			t.Error("the shared exchange should not be canceled by another caller", err)
+		}
+		if actual := keycloak.exchanges.Load(); actual != exchanges+1 {
+			t.Error(actual, exchanges)
+		}
+
+		_, err = NewAuthWithoutCache(&config.ConfigStruct{AuthEndpoint: server.URL}).GetUserToken(ctx, "user3")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Error(err)
+		}
+	})
+
+	t.Run("hanging auth", func(t *testing.T) {
+		release := make(chan struct{})
+		hanging := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			select {
+			case <-release:
+			case <-request.Context().Done():
+			}
+		}))
+		defer hanging.Close()
+		defer close(release)
+		a, err := NewAuth(&config.ConfigStruct{AuthEndpoint: hanging.URL, HttpClientTimeout: "100ms"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		start := time.Now()
+		_, err = a.GetUserToken(context.Background(), "user")
+		if err == nil || time.Since(start) > time.Second {
+			t.Error("the exchange should be limited by the http client timeout", time.Since(start), err)
+		}
+	})
+}
+
+func TestRefreshDue(t *testing.T) {
+	now := time.Now()
+	c := credential{token: "Bearer token", issued: now.Add(-time.Minute), expiration: now.Add(time.Minute)}
+	if c.refreshDue(now, 5*time.Minute) {
+		t.Error("buffer must be limited to half of the token lifetime")
+	}
+	if !c.refreshDue(now.Add(30*time.Second), 5*time.Minute) {
+		t.Error("expected refresh in the second half of the token lifetime")
+	}
+	if c.refreshDue(now.Add(45*time.Second), 10*time.Second) {
+		t.Error("shorter buffers are used unchanged")
+	}
+	if c.refreshDue(now.Add(2*time.Minute), 5*time.Minute) {
+		t.Error("expired tokens are not refreshed in the background")
+	}
+}
+
+func TestTokenExpiration(t *testing.T) {
+	internal, err := GenerateInternalUserToken("user")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, ok, err := TokenExpiration(internal)
+	if err != nil || ok {
+		t.Error(ok, err)
+	}
+}
@@ -0,0 +1,264 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/golang-jwt/jwt"
+	"golang.org/x/sync/singleflight"
+	"log"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// min duration between two jwks requests caused by unknown key ids or failed requests
+const jwksMinRefreshInterval = 10 * time.Second
+
+const jwksRequestTimeout = 10 * time.Second
+
+type Claims struct {
+	Sub         string              `json:"sub,omitempty"`
+	Issuer      string              `json:"iss,omitempty"`
+	Audience    Audience            `json:"aud,omitempty"`
+	ExpiresAt   int64               `json:"exp,omitempty"`
+	NotBefore   int64               `json:"nbf,omitempty"`
+	RealmAccess map[string][]string `json:"realm_access,omitempty"`
+	//client, to which the token was issued
+	AuthorizedParty string `json:"azp,omitempty"`
+}
+
+func (this *Claims) Valid() error {
+	if this.Sub == "" {
+		return errors.New("missing subject")
+	}
+	now := time.Now().Unix()
+	if this.ExpiresAt == 0 {
+		return errors.New("missing expiration")
+	}
+	if now > this.ExpiresAt {
+		return errors.New("token is expired")
+	}
+	if this.NotBefore != 0 && now < this.NotBefore {
+		return errors.New("token is not valid yet")
+	}
+	return nil
+}
+
+func (this *Claims) IsAdmin() bool {
+	return slices.Contains(this.RealmAccess["roles"], "admin")
+}
+
+// Audience accepts the single string and the list form of the aud claim
+type Audience []string
+
+func (this *Audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if json.Unmarshal(b, &single) == nil {
+		*this = Audience{single}
+		return nil
+	}
+	var list []string
+	err := json.Unmarshal(b, &list)
+	if err != nil {
+		return err
+	}
+	*this = list
+	return nil
+}
+
+// ParseUnverified reads the claims of token without any check
+func ParseUnverified(token string) (claims Claims, err error) {
+	_, _, err = new(jwt.Parser).ParseUnverified(trimBearer(token), &claims)
+	return claims, err
+}
+
+// TokenVerifier checks signature, expiration, issuer and audience of tokens.
+// the signing keys are loaded from the jwks endpoint of the auth service and cached;
+// unknown key ids (key rotation) cause a reload of the keys.
+// keys are loaded outside the lock by one request at a time, concurrent requests wait for its result.
+type TokenVerifier struct {
+	jwksUrl       string
+	issuer        string
+	audience      string
+	cacheDuration time.Duration
+	disabled      bool
+	client        *http.Client
+	load          singleflight.Group
+	mux           sync.Mutex
+	keys          map[string]*rsa.PublicKey
+	loaded        time.Time
+	//last jwks request, successful or not
+	attempted time.Time
+}
+
+func NewTokenVerifier(conf config.Config) (result *TokenVerifier, err error) {
+	result = &TokenVerifier{
+		jwksUrl:       conf.AuthEndpoint + "/auth/realms/master/protocol/openid-connect/certs",
+		issuer:        conf.AuthTokenIssuer,
+		audience:      conf.AuthTokenAudience,
+		cacheDuration: time.Hour,
+		disabled:      conf.DevDisableTokenVerification,
+		client:        &http.Client{Timeout: jwksRequestTimeout},
+		keys:          map[string]*rsa.PublicKey{},
+	}
+	if result.issuer == "" {
+		result.issuer = conf.AuthEndpoint + "/auth/realms/master"
+	}
+	if conf.AuthJwksCacheDuration != "" {
+		result.cacheDuration, err = time.ParseDuration(conf.AuthJwksCacheDuration)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if result.disabled {
+		log.Println("WARNING: token verification is disabled --> only use for development")
+	}
+	return result, nil
+}
+
+func (this *TokenVerifier) Verify(token string) (claims Claims, err error) {
+	if this.disabled {
+		claims, err = ParseUnverified(token)
+		if err == nil && claims.Sub == "" {
+			err = errors.New("missing subject")
+		}
+		return claims, err
+	}
+	parser := jwt.Parser{ValidMethods: []string{"RS256", "RS384", "RS512"}}
+	_, err = parser.ParseWithClaims(trimBearer(token), &claims, this.getKey)
+	if err != nil {
+		return claims, err
+	}
+	if claims.Issuer != this.issuer {
+		return claims, errors.New("unexpected token issuer")
+	}
+	if this.audience != "" && !slices.Contains(claims.Audience, this.audience) {
+		return claims, errors.New("unexpected token audience")
+	}
+	return claims, nil
+}
+
+func (this *TokenVerifier) getKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	this.mux.Lock()
+	key, ok := this.keys[kid]
+	expired := time.Since(this.loaded) > this.cacheDuration
+	recentlyAttempted := time.Since(this.attempted) < jwksMinRefreshInterval
+	this.mux.Unlock()
+	if ok && !expired {
+		return key, nil
+	}
+	if recentlyAttempted {
+		if ok {
+			//keep using the known key until the jwks endpoint is available again
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown key id %v", kid)
+	}
+	keys, err, _ := this.load.Do("jwks", func() (interface{}, error) {
+		return this.reloadKeys()
+	})
+	if err != nil {
+		log.Println("ERROR: unable to load jwks", err)
+		if ok {
+			//keep using the known key until the jwks endpoint is available again
+			return key, nil
+		}
+		return nil, err
+	}
+	key, ok = keys.(map[string]*rsa.PublicKey)[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %v", kid)
+	}
+	return key, nil
+}
+
+// reloadKeys requests the jwks endpoint and replaces the cached keys on success
+func (this *TokenVerifier) reloadKeys() (keys map[string]*rsa.PublicKey, err error) {
+	keys, err = this.loadKeys()
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.attempted = time.Now()
+	if err != nil {
+		return keys, err
+	}
+	this.keys = keys
+	this.loaded = this.attempted
+	return keys, nil
+}
+
+type jwks struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func (this *TokenVerifier) loadKeys() (keys map[string]*rsa.PublicKey, err error) {
+	resp, err := this.client.Get(this.jwksUrl)
+	if err != nil {
+		return keys, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return keys, fmt.Errorf("unexpected jwks status code %v", resp.StatusCode)
+	}
+	set := jwks{}
+	err = json.NewDecoder(resp.Body).Decode(&set)
+	if err != nil {
+		return keys, err
+	}
+	keys = map[string]*rsa.PublicKey{}
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return keys, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return keys, err
+		}
+		keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func trimBearer(token string) string {
+	token = strings.TrimSpace(token)
+	if len(token) > 7 && strings.ToLower(token[:7]) == "bearer " {
+		return token[7:]
+	}
+	return token
+}
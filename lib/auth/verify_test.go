@@ -0,0 +1,292 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/golang-jwt/jwt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type jwksMock struct {
+	mux  sync.Mutex
+	keys map[string]*rsa.PrivateKey
+}
+
+func (this *jwksMock) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	set := map[string][]map[string]string{"keys": {}}
+	for kid, key := range this.keys {
+		set["keys"] = append(set["keys"], map[string]string{
+			"kid": kid,
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	json.NewEncoder(writer).Encode(set)
+}
+
+func (this *jwksMock) setKey(kid string) (*rsa.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.keys = map[string]*rsa.PrivateKey{kid: key}
+	return key, nil
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	result, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + result
+}
+
+func TestTokenVerifier(t *testing.T) {
+	jwks := &jwksMock{}
+	key1, err := jwks.setKey("k1")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+	issuer := server.URL + "/auth/realms/master"
+
+	verifier, err := NewTokenVerifier(&config.ConfigStruct{AuthEndpoint: server.URL, AuthTokenAudience: "event-deployment"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":          "user",
+			"iss":          issuer,
+			"aud":          []string{"account", "event-deployment"},
+			"exp":          time.Now().Add(time.Hour).Unix(),
+			"realm_access": map[string][]string{"roles": {"admin"}},
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		claims, err := verifier.Verify(signTestToken(t, key1, "k1", validClaims()))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if claims.Sub != "user" || !claims.IsAdmin() {
+			t.Error(claims)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		c := validClaims()
+		c["exp"] = time.Now().Add(-time.Minute).Unix()
+		_, err := verifier.Verify(signTestToken(t, key1, "k1", c))
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("issuer", func(t *testing.T) {
+		c := validClaims()
+		c["iss"] = "http://other/auth/realms/master"
+		_, err := verifier.Verify(signTestToken(t, key1, "k1", c))
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("audience", func(t *testing.T) {
+		c := validClaims()
+		c["aud"] = "account"
+		_, err := verifier.Verify(signTestToken(t, key1, "k1", c))
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("unsigned internal token", func(t *testing.T) {
+		token, err := GenerateInternalUserToken("user")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_, err = verifier.Verify(token)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("foreign key", func(t *testing.T) {
+		foreign, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_, err = verifier.Verify(signTestToken(t, foreign, "k1", validClaims()))
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		key2, err := jwks.setKey("k2")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		verifier.mux.Lock()
+		verifier.loaded = time.Now().Add(-jwksMinRefreshInterval)
+		verifier.attempted = verifier.loaded
+		verifier.mux.Unlock()
+		_, err = verifier.Verify(signTestToken(t, key2, "k2", validClaims()))
+		if err != nil {
+			t.Error(err)
+		}
+		_, err = verifier.Verify(signTestToken(t, key1, "k1", validClaims()))
+		if err == nil {
+			t.Error("expected error for removed key")
+		}
+	})
+
+	t.Run("dev mode", func(t *testing.T) {
+		dev, err := NewTokenVerifier(&config.ConfigStruct{DevDisableTokenVerification: true})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		token, err := GenerateInternalUserToken("user")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		claims, err := dev.Verify(token)
+		if err != nil || claims.Sub != "user" {
+			t.Error(claims, err)
+		}
+	})
+}
+
+func TestTokenVerifierUnavailableJwks(t *testing.T) {
+	jwks := &jwksMock{}
+	key1, err := jwks.setKey("k1")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var requests atomic.Int64
+	hang := make(chan struct{})
+	fail := atomic.Bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests.Add(1)
+		if fail.Load() {
+			<-hang
+			http.Error(writer, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		jwks.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+	defer close(hang)
+
+	verifier, err := NewTokenVerifier(&config.ConfigStruct{AuthEndpoint: server.URL})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	claims := jwt.MapClaims{
+		"sub": "user",
+		"iss": server.URL + "/auth/realms/master",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	_, err = verifier.Verify(signTestToken(t, key1, "k1", claims))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	fail.Store(true)
+	verifier.mux.Lock()
+	verifier.attempted = time.Now().Add(-jwksMinRefreshInterval)
+	verifier.mux.Unlock()
+	key2, err := jwks.setKey("k2")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	unknownKeyResult := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := verifier.Verify(signTestToken(t, key2, "k2", claims))
+			unknownKeyResult <- err
+		}()
+	}
+
+	t.Run("hanging jwks request does not block known keys", func(t *testing.T) {
+		time.Sleep(100 * time.Millisecond)
+		done := make(chan error, 1)
+		go func() {
+			_, err := verifier.Verify(signTestToken(t, key1, "k1", claims))
+			done <- err
+		}()
+		select {
+		case err = <-done:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(time.Second):
+			t.Error("verification blocked by jwks request")
+		}
+	})
+
+	t.Run("failed request is shared and not repeated", func(t *testing.T) {
+		hang <- struct{}{}
+		for range 2 {
+			err := <-unknownKeyResult
+			if err == nil {
+				t.Error("expected error")
+			}
+		}
+		_, err = verifier.Verify(signTestToken(t, key2, "k2", claims))
+		if err == nil {
+			t.Error("expected error")
+		}
+		if requests.Load() != 2 {
+			t.Error(requests.Load())
+		}
+	})
+}
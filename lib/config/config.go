@@ -52,45 +52,186 @@ type ConfigStruct struct {
 	ImportPathPrefix        string `json:"import_path_prefix"`
 	GenericSourcePathPrefix string `json:"generic_source_path_prefix"`
 
+	//repository of conditional events: "mongo" or "memory"; memory keeps descriptions and deployments only in this process
+	//the event-worker reads the descriptions from mongodb, so memory is meant for tests and development
+	ConditionalEventRepoType string `json:"conditional_event_repo_type"`
+
 	ConditionalEventRepoMongoUrl                   string `json:"conditional_event_repo_mongo_url"`
 	ConditionalEventRepoMongoTable                 string `json:"conditional_event_repo_mongo_table"`
 	ConditionalEventRepoMongoDescCollection        string `json:"conditional_event_repo_mongo_desc_collection"`
 	ConditionalEventRepoMongoDeploymentsCollection string `json:"conditional_event_repo_mongo_deployments_collection"`
 
+	//every created, updated and deleted event description is published as model.EventDescChange on this topic
+	//if not configured: no changes are published
+	ConditionalEventDescChangeTopic string `json:"conditional_event_desc_change_topic"`
+
+	//replaces the event descriptions of a deployment in a transaction; requires mongodb as replica set
+	//if not set: new descriptions are inserted before the old ones are removed, so that a deployment never has no events
+	ConditionalEventRepoMongoTransactions bool `json:"conditional_event_repo_mongo_transactions"`
+
+	//if not configured or no conditional event repo is configured: the service only starts with single_instance,
+	//because every instance would act as leader and run the background jobs
+	ConditionalEventRepoMongoLeaseCollection string `json:"conditional_event_repo_mongo_lease_collection"`
+	LeaderElectionLeaseDuration              string `json:"leader_election_lease_duration"`
+	//confirms, that only one instance of the service runs; allows to start without leader election and to rebuild the deployments with a replay
+	SingleInstance bool `json:"single_instance"`
+
+	//required if pipeline_auth_mode is credential_reference
+	ConditionalEventRepoMongoCredentialsCollection string `json:"conditional_event_repo_mongo_credentials_collection"`
+
+	//if not configured: activations of deployments are ignored and their events are always deployed
+	ConditionalEventRepoMongoScheduleCollection string `json:"conditional_event_repo_mongo_schedule_collection"`
+	ActivationSchedulerInterval                 string `json:"activation_scheduler_interval"`
+
+	//if not configured: no deployment history is kept and rollbacks are not possible
+	ConditionalEventRepoMongoHistoryCollection string `json:"conditional_event_repo_mongo_history_collection"`
+	//max versions kept per deployment; 0 = unlimited
+	DeploymentHistoryLimit int64 `json:"deployment_history_limit"`
+
+	//audit entries of deployment mutations are written to every listed sink: mongo, kafka and/or file
+	//mongo uses conditional_event_repo_mongo_audit_collection, kafka uses audit_topic and file appends json lines to audit_file
+	AuditSinks                               []string `json:"audit_sinks"`
+	ConditionalEventRepoMongoAuditCollection string   `json:"conditional_event_repo_mongo_audit_collection"`
+	AuditTopic                               string   `json:"audit_topic"`
+	AuditFile                                string   `json:"audit_file"`
+	//ip addresses or cidr ranges of proxies, whose X-Forwarded-For entries are used as caller ip of api audit entries; empty = only the remote address is used
+	AuditTrustedProxies []string `json:"audit_trusted_proxies"`
+
 	ImportRepositoryUrl string `json:"import_repository_url"`
 
+	//if not configured: only owners and admins may read the state of conditional events
+	PermissionsV2Url             string `json:"permissions_v2_url"`
+	PermissionsV2DeploymentTopic string `json:"permissions_v2_deployment_topic"`
+
 	DeviceRepositoryUrl string `json:"device_repository_url"`
 
+	//ttl per kind (function, concept, service, device_type_selectables, device_infos, group_infos, device_groups, device_types); kinds without ttl are not cached
+	DevicesCacheTtl map[string]string `json:"devices_cache_ttl"`
+
+	//topics of the device-repository used to invalidate the devices cache; if not configured: entries are only removed after their ttl
+	DeviceRepoDeviceTopic     string `json:"device_repo_device_topic"`
+	DeviceRepoDeviceTypeTopic string `json:"device_repo_device_type_topic"`
+	DeviceRepoFunctionTopic   string `json:"device_repo_function_topic"`
+	DeviceRepoAspectTopic     string `json:"device_repo_aspect_topic"`
+	DeviceRepoConceptTopic    string `json:"device_repo_concept_topic"`
+
 	//if not configured: no device-group updates handled
 	DeviceGroupTopic string `json:"device_group_topic"`
 
+	//device-group messages are collected per group (message key) until no message of the group arrived for the quiet period or its first message waited for the max delay (default 1m);
+	//each group is updated once per collection. if no quiet period is configured: every message is handled individually
+	DeviceGroupUpdateQuietPeriod string `json:"device_group_update_quiet_period"`
+	DeviceGroupUpdateMaxDelay    string `json:"device_group_update_max_delay"`
+
 	//if not configured: no deployment done events are published
 	DeploymentDoneTopic string `json:"deployment_done_topic"`
 
+	//deployment done notifications and event description changes are stored in this collection and published by a relay on the leader instance,
+	//entries are written in the mongo transaction of the deployment changes, which needs a replica set; requests to other services, like analytics pipelines and device lookups, are made before the transaction
+	//entries are written in the mongo transaction of the deployment changes, which needs a replica set
+	//failed entries are retried with exponential backoff from outbox_relay_interval up to 1m; transient errors like an unavailable kafka are retried without limit
+	//entries with outbox_max_attempts permanent errors, like a topic without producer, are parked until POST /admin/outbox/requeue (0 = no attempt limit)
+	//if not configured: messages are produced directly
+	ConditionalEventRepoMongoOutboxCollection string `json:"conditional_event_repo_mongo_outbox_collection"`
+	OutboxRelayInterval                       string `json:"outbox_relay_interval"`
+	OutboxMaxAttempts                         int64  `json:"outbox_max_attempts"`
+
 	//if not configured: events with groups not handled
 	AuthExpirationTimeBuffer float64 `json:"auth_expiration_time_buffer"`
 	AuthEndpoint             string  `json:"auth_endpoint"`
 	AuthClientId             string  `json:"auth_client_id" config:"secret"`
 	AuthClientSecret         string  `json:"auth_client_secret" config:"secret"`
 
+	//api tokens are verified with the jwks of auth_endpoint
+	//if not configured: issuer is auth_endpoint + "/auth/realms/master" and the audience is not checked
+	AuthTokenIssuer       string `json:"auth_token_issuer"`
+	AuthTokenAudience     string `json:"auth_token_audience"`
+	AuthJwksCacheDuration string `json:"auth_jwks_cache_duration"`
+
+	//only for development: api tokens are parsed without checking signature, expiration, issuer or audience
+	DevDisableTokenVerification bool `json:"dev_disable_token_verification"`
+
 	AnalyticsPipelineBatchSize int64  `json:"analytics_pipeline_batch_size"`
 	AnalyticsRequestTimeout    string `json:"analytics_request_timeout"`
 	HttpClientTimeout          string `json:"http_client_timeout"`
 	HttpServerTimeout          string `json:"http_server_timeout"`
 	HttpServerReadTimeout      string `json:"http_server_read_timeout"`
 
+	//max duration to wait for in-flight commands and requests on shutdown
+	ShutdownTimeout string `json:"shutdown_timeout"`
+
+	//deadline for the handling of a single kafka message, shared by all its downstream calls; empty = no deadline
+	CommandTimeout string `json:"command_timeout"`
+
 	EnableMultiplePaths        bool `json:"enable_multiple_paths"`
 	EnableAnalyticsEvents      bool `json:"enable_analytics_events"`
 	IgnoreAnalyticsEventErrors bool `json:"ignore_analytics_event_errors"`
 
 	UserTokenCacheLifespanInSec int64 `json:"user_token_cache_lifespan_in_sec"`
 
+	//user tokens expiring within this duration are refreshed in the background, at most half of their lifetime before their expiration
+	//if not configured: 5m; 0s disables the background refresh
+	UserTokenRefreshBuffer string `json:"user_token_refresh_buffer"`
+
+	//consecutive unavailable-errors until no requests are sent to keycloak for the current backoff; if 0: no circuit breaker
+	AuthCircuitBreakerThreshold int64  `json:"auth_circuit_breaker_threshold"`
+	AuthBackoffInitial          string `json:"auth_backoff_initial"`
+	AuthBackoffMax              string `json:"auth_backoff_max"`
+
+	//user_token (default): pipelines receive the user token as userToken config
+	//credential_reference: pipelines receive a revocable credentialRef and the credentialUrl to exchange it for a user token
+	PipelineAuthMode      string `json:"pipeline_auth_mode"`
+	PipelineCredentialUrl string `json:"pipeline_credential_url"`
+	//keycloak clients (azp claim), whose service tokens may exchange a credentialRef at pipeline_credential_url; e.g. the client of the flow-engine
+	PipelineCredentialClients []string `json:"pipeline_credential_clients"`
+
+	//interval in which the leader replaces expired user tokens in analytics pipelines; if not configured: no replacement
+	PipelineTokenRefreshInterval string `json:"pipeline_token_refresh_interval"`
+	//owners of deployed event pipelines, whose pipeline tokens are replaced; if not configured: only owners with a token requested since the start
+	ConditionalEventRepoMongoPipelineOwnerCollection string `json:"conditional_event_repo_mongo_pipeline_owner_collection"`
+
+	//sourcing of topics: "kafka" or "inprocess"; inprocess runs without message broker for development and tests
+	//inprocess topics are persisted in in_process_sourcing_dir if set and are only visible inside this process
+	Sourcing             string `json:"sourcing"`
+	InProcessSourcingDir string `json:"in_process_sourcing_dir"`
+
 	DisableKafka                  bool `json:"disable_kafka"`
 	DisableKafkaProcessDeployment bool `json:"disable_kafka_process_deployment"`
 	DisableKafkaDeviceGroupUpdate bool `json:"disable_kafka_device_group_update"`
 	DisableKafkaDoneProducer      bool `json:"disable_kafka_done_producer"`
 
 	InitTopics bool `json:"init_topics"`
+
+	//position of consumer groups without committed offsets: earliest, latest or a RFC3339 timestamp
+	//partitions with committed offsets always resume from them, so restarts do not rewind the consumers
+	//if not configured: consumers without committed offsets start at the first message
+	ConsumerStartPosition string `json:"consumer_start_position"`
+
+	//kafka_url may list several bootstrap brokers separated by comma
+	//without kafka_tls_ca_file the system cert pool is used; client certificates are optional
+	KafkaTls                   bool   `json:"kafka_tls"`
+	KafkaTlsCaFile             string `json:"kafka_tls_ca_file"`
+	KafkaTlsCertFile           string `json:"kafka_tls_cert_file"`
+	KafkaTlsKeyFile            string `json:"kafka_tls_key_file"`
+	KafkaTlsInsecureSkipVerify bool   `json:"kafka_tls_insecure_skip_verify"`
+
+	//plain, scram-sha-256 or scram-sha-512; if not configured: no sasl authentication
+	KafkaSaslMechanism string `json:"kafka_sasl_mechanism"`
+	KafkaSaslUser      string `json:"kafka_sasl_user"`
+	KafkaSaslPassword  string `json:"kafka_sasl_password" config:"secret"`
+
+	//settings of topics created by init_topics, by topic name; topics without entry use the "default" entry
+	//if no "default" entry exists: compacted topics with infinite retention, 1 partition and replication factor 1
+	KafkaTopicConfigs map[string]KafkaTopicConfig `json:"kafka_topic_configs"`
+	//drift between configured and actual settings of existing topics is logged on startup; if true: config values and partition counts are also corrected
+	KafkaTopicConfigCorrectDrift bool `json:"kafka_topic_config_correct_drift"`
+}
+
+// KafkaTopicConfig with 0 partitions or replication factor uses 1
+type KafkaTopicConfig struct {
+	Partitions        int32             `json:"partitions"`
+	ReplicationFactor int16             `json:"replication_factor"`
+	Config            map[string]string `json:"config"`
 }
 
 type Config = *ConfigStruct
@@ -162,7 +303,16 @@ func HandleEnvironmentVars(config Config) {
 				}
 				configValue.FieldByName(fieldName).Set(reflect.ValueOf(val))
 			}
-			if configValue.FieldByName(fieldName).Kind() == reflect.Map {
+			if configValue.FieldByName(fieldName).Kind() == reflect.Map && configValue.FieldByName(fieldName).Type().Elem().Kind() != reflect.String {
+				//maps with structured values are expected as json
+				value := reflect.New(configValue.FieldByName(fieldName).Type())
+				err := json.Unmarshal([]byte(envValue), value.Interface())
+				if err != nil {
+					log.Println("WARNING: invalid json in environment variable", envName, err)
+				} else {
+					configValue.FieldByName(fieldName).Set(value.Elem())
+				}
+			} else if configValue.FieldByName(fieldName).Kind() == reflect.Map {
 				value := map[string]string{}
 				for _, element := range strings.Split(envValue, ",") {
 					keyVal := strings.Split(element, ":")
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package credentials
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"time"
+)
+
+// values of config.PipelineAuthMode
+const (
+	//pipelines receive the exchanged user token as userToken config
+	ModeUserToken = "user_token"
+	//pipelines receive a credentialRef, which may be exchanged for a user token at config.PipelineCredentialUrl
+	ModeCredentialReference = "credential_reference"
+)
+
+var ErrUnknownCredential = errs.Unauthorized("", errors.New("unknown credential"))
+
+type Credential struct {
+	Id           string    `json:"id" bson:"_id"`
+	KeyHash      string    `json:"key_hash" bson:"key_hash"`
+	Owner        string    `json:"owner" bson:"owner"`
+	DeploymentId string    `json:"deployment_id" bson:"deployment_id"`
+	EventId      string    `json:"event_id" bson:"event_id"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+}
+
+type Repository interface {
+	AddCredential(ctx context.Context, credential Credential) error
+	GetCredential(ctx context.Context, keyHash string) (credential Credential, exists bool, err error)
+	RemoveCredential(ctx context.Context, id string) error
+}
+
+// Credentials manages opaque keys, which pipelines use instead of user tokens.
+// only the sha256 hash of a key is stored; a key is revoked by removing its credential.
+// every issued key is independent, so redeploying an event does not invalidate the keys of still running pipelines.
+type Credentials struct {
+	repo Repository
+	auth *auth.Auth
+}
+
+func New(conf config.Config, repo Repository) (*Credentials, error) {
+	a, err := auth.NewAuth(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &Credentials{repo: repo, auth: a}, nil
+}
+
+// Issue returns a new key for the event of the deployment; previously issued keys stay valid until they are revoked.
+func (this *Credentials) Issue(ctx context.Context, owner string, deploymentId string, eventId string) (key string, err error) {
+	buf := make([]byte, 32)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	key = hex.EncodeToString(buf)
+	err = this.repo.AddCredential(ctx, Credential{
+		Id:           config.NewId(),
+		KeyHash:      hash(key),
+		Owner:        owner,
+		DeploymentId: deploymentId,
+		EventId:      eventId,
+		CreatedAt:    config.TimeNow(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Revoke removes the credential of the key; unknown keys are ignored
+func (this *Credentials) Revoke(ctx context.Context, key string) error {
+	credential, exists, err := this.repo.GetCredential(ctx, hash(key))
+	if err != nil || !exists {
+		return err
+	}
+	return this.repo.RemoveCredential(ctx, credential.Id)
+}
+
+// Exchange returns a token of the key owner; errors may be ErrUnknownCredential, auth.ErrUserDoesNotExist or auth.ErrAuthUnavailable.
+func (this *Credentials) Exchange(ctx context.Context, key string) (token auth.AuthToken, err error) {
+	credential, exists, err := this.repo.GetCredential(ctx, hash(key))
+	if err != nil {
+		return token, err
+	}
+	if !exists {
+		return token, ErrUnknownCredential
+	}
+	return this.auth.GetUserToken(ctx, credential.Owner)
+}
+
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
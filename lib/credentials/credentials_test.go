@@ -0,0 +1,140 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type repoMock struct {
+	mux         sync.Mutex
+	credentials []Credential
+}
+
+func (this *repoMock) AddCredential(ctx context.Context, credential Credential) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.credentials = append(this.credentials, credential)
+	return nil
+}
+
+func (this *repoMock) GetCredential(ctx context.Context, keyHash string) (credential Credential, exists bool, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for _, c := range this.credentials {
+		if c.KeyHash == keyHash {
+			return c, true, nil
+		}
+	}
+	return credential, false, nil
+}
+
+func (this *repoMock) RemoveCredential(ctx context.Context, id string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	result := []Credential{}
+	for _, c := range this.credentials {
+		if c.Id != id {
+			result = append(result, c)
+		}
+	}
+	this.credentials = result
+	return nil
+}
+
+func TestCredentials(t *testing.T) {
+	keycloak := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		json.NewEncoder(writer).Encode(map[string]interface{}{
+			"access_token": "token_" + request.FormValue("requested_subject"),
+			"expires_in":   3600,
+		})
+	}))
+	defer keycloak.Close()
+
+	ctx := context.Background()
+	repo := &repoMock{}
+	c, err := New(&config.ConfigStruct{AuthEndpoint: keycloak.URL, UserTokenCacheLifespanInSec: 3600}, repo)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	key1, err := c.Issue(ctx, "user", "d1", "e1")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("only hash is stored", func(t *testing.T) {
+		for _, credential := range repo.credentials {
+			if strings.Contains(credential.KeyHash, key1) {
+				t.Error(credential)
+			}
+		}
+	})
+
+	t.Run("exchange", func(t *testing.T) {
+		token, err := c.Exchange(ctx, key1)
+		if err != nil || token != "Bearer token_user" {
+			t.Error(token, err)
+		}
+	})
+
+	t.Run("reissue keeps old key", func(t *testing.T) {
+		key2, err := c.Issue(ctx, "user", "d1", "e1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		token, err := c.Exchange(ctx, key1)
+		if err != nil || token != "Bearer token_user" {
+			t.Error(token, err)
+		}
+		token, err = c.Exchange(ctx, key2)
+		if err != nil || token != "Bearer token_user" {
+			t.Error(token, err)
+		}
+		err = c.Revoke(ctx, key1)
+		if err != nil {
+			t.Error(err)
+		}
+		_, err = c.Exchange(ctx, key1)
+		if !errors.Is(err, ErrUnknownCredential) {
+			t.Error(err)
+		}
+		token, err = c.Exchange(ctx, key2)
+		if err != nil || token != "Bearer token_user" {
+			t.Error(token, err)
+		}
+		err = c.Revoke(ctx, key2)
+		if err != nil {
+			t.Error(err)
+		}
+		_, err = c.Exchange(ctx, key2)
+		if !errors.Is(err, ErrUnknownCredential) {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,353 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package devices
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"golang.org/x/sync/singleflight"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cache kinds; used as keys in config.DevicesCacheTtl and as metric label
+const (
+	CacheKindFunction              = "function"
+	CacheKindConcept               = "concept"
+	CacheKindService               = "service"
+	CacheKindDeviceTypeSelectables = "device_type_selectables"
+	CacheKindDeviceInfos           = "device_infos"
+	CacheKindGroupInfos            = "group_infos"
+	CacheKindDeviceGroups          = "device_groups"
+	CacheKindDeviceTypes           = "device_types"
+)
+
+// DefaultCacheFetchTimeout limits a shared request, if config.HttpClientTimeout is not set
+const DefaultCacheFetchTimeout = 30 * time.Second
+
+// Cache wraps interfaces.Devices. successful results are cached for the ttl configured for their kind,
+// concurrent requests with the same arguments are coalesced to one request.
+// invalidations increase the generation of the key or kind; results of requests, that started before an invalidation, are not cached.
+type Cache struct {
+	devices      interfaces.Devices
+	ttl          map[string]time.Duration
+	fetchTimeout time.Duration
+	metrics      *metrics.Metrics
+	mux          sync.Mutex
+	entries      map[string]cacheEntry
+	group        singleflight.Group
+	//generation is the last value assigned to keyGenerations or kindGenerations
+	generation      uint64
+	keyGenerations  map[string]uint64
+	kindGenerations map[string]uint64
+	//running requests; the generations are only needed while requests run
+	fetching int
+}
+
+type cacheEntry struct {
+	value      interface{}
+	expiration time.Time
+}
+
+func NewCache(ctx context.Context, wg *sync.WaitGroup, conf config.Config, devices interfaces.Devices, m *metrics.Metrics) (result *Cache, err error) {
+	result = &Cache{
+		devices:         devices,
+		ttl:             map[string]time.Duration{},
+		fetchTimeout:    DefaultCacheFetchTimeout,
+		metrics:         m,
+		entries:         map[string]cacheEntry{},
+		keyGenerations:  map[string]uint64{},
+		kindGenerations: map[string]uint64{},
+	}
+	if conf.HttpClientTimeout != "" {
+		result.fetchTimeout, err = time.ParseDuration(conf.HttpClientTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for kind, ttl := range conf.DevicesCacheTtl {
+		result.ttl[kind], err = time.ParseDuration(ttl)
+		if err != nil {
+			return nil, err
+		}
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				result.removeExpired()
+			}
+		}
+	}()
+	return result, nil
+}
+
+// ListenForInvalidation consumes the device-repository topics and removes affected cache entries.
+// every instance has to receive every update, so the topics are read without consumer group.
+func (this *Cache) ListenForInvalidation(ctx context.Context, wg *sync.WaitGroup, conf config.Config, sourcing interfaces.SourcingFactory) error {
+	topics := map[string]func(id string){
+		conf.DeviceRepoDeviceTopic: func(id string) {
+			this.InvalidateKind(CacheKindDeviceInfos)
+			this.InvalidateKind(CacheKindGroupInfos)
+		},
+		conf.DeviceGroupTopic: func(id string) {
+			this.Invalidate(CacheKindGroupInfos, id)
+			this.InvalidateKind(CacheKindDeviceGroups)
+		},
+		conf.DeviceRepoDeviceTypeTopic: func(id string) {
+			this.InvalidateKind(CacheKindService)
+			this.InvalidateKind(CacheKindDeviceTypes)
+			this.InvalidateKind(CacheKindDeviceTypeSelectables)
+		},
+		conf.DeviceRepoFunctionTopic: func(id string) {
+			this.Invalidate(CacheKindFunction, id)
+			this.InvalidateKind(CacheKindDeviceTypeSelectables)
+		},
+		conf.DeviceRepoAspectTopic: func(id string) {
+			this.InvalidateKind(CacheKindDeviceTypeSelectables)
+		},
+		conf.DeviceRepoConceptTopic: func(id string) {
+			this.Invalidate(CacheKindConcept, id)
+		},
+	}
+	for topic, invalidate := range topics {
+		if topic == "" || topic == "-" {
+			continue
+		}
+		err := sourcing.NewBroadcastConsumer(ctx, wg, conf, topic, getInvalidationListener(invalidate))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type invalidationCommand struct {
+	Command string `json:"command"`
+	Id      string `json:"id"`
+}
+
+func getInvalidationListener(invalidate func(id string)) func(delivery []byte) error {
+	return func(delivery []byte) error {
+		cmd := invalidationCommand{}
+		err := json.Unmarshal(delivery, &cmd)
+		if err != nil {
+			log.Println("WARNING: unable to interpret device-repository message --> ignore", err)
+			return nil
+		}
+		invalidate(cmd.Id)
+		return nil
+	}
+}
+
+// InvalidateDeviceGroup removes the cached infos of the device-group of a device-group topic message.
+// the device-group update handler calls it before it resolves the group,
+// because the invalidation listener may receive the same message later.
+func (this *Cache) InvalidateDeviceGroup(delivery []byte) {
+	_ = getInvalidationListener(func(id string) {
+		this.Invalidate(CacheKindGroupInfos, id)
+		this.InvalidateKind(CacheKindDeviceGroups)
+	})(delivery)
+}
+
+func (this *Cache) Invalidate(kind string, id string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	key := kind + "." + id
+	this.generation++
+	this.keyGenerations[key] = this.generation
+	delete(this.entries, key)
+	//callers after the invalidation do not wait for a running request
+	this.group.Forget(key)
+}
+
+func (this *Cache) InvalidateKind(kind string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.generation++
+	this.kindGenerations[kind] = this.generation
+	for key := range this.entries {
+		if strings.HasPrefix(key, kind+".") {
+			delete(this.entries, key)
+		}
+	}
+}
+
+func (this *Cache) removeExpired() {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if this.fetching == 0 {
+		clear(this.keyGenerations)
+		clear(this.kindGenerations)
+	}
+	now := time.Now()
+	for key, entry := range this.entries {
+		if entry.expiration.Before(now) {
+			delete(this.entries, key)
+		}
+	}
+}
+
+func (this *Cache) get(key string) (value interface{}, ok bool) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	entry, ok := this.entries[key]
+	if !ok || entry.expiration.Before(time.Now()) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// startFetch returns the current generation of the key; finishFetch has to be called with it, when the request finished
+func (this *Cache) startFetch(kind string, key string) (generation uint64) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.fetching++
+	return max(this.keyGenerations[key], this.kindGenerations[kind])
+}
+
+// finishFetch caches the value, if the key has not been invalidated since startFetch
+func (this *Cache) finishFetch(kind string, key string, generation uint64, value interface{}, ttl time.Duration, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.fetching--
+	if err != nil || generation != max(this.keyGenerations[key], this.kindGenerations[kind]) {
+		return
+	}
+	this.entries[key] = cacheEntry{value: value, expiration: time.Now().Add(ttl)}
+}
+
+// use shares one call of get between concurrent callers of the same key.
+// the shared call is not canceled with the ctx of the first caller, to not fail the other callers; it is limited by http_client_timeout.
+// every caller stops waiting, when its own ctx is done, and receives its own copy of the cached value.
+func use[T any](ctx context.Context, this *Cache, kind string, id string, get func(ctx context.Context) (T, error)) (result T, err error) {
+	ttl := this.ttl[kind]
+	if ttl <= 0 {
+		return get(ctx)
+	}
+	key := kind + "." + id
+	if value, ok := this.get(key); ok {
+		this.metrics.DevicesCacheHits.WithLabelValues(kind).Inc()
+		return clone(value.(T))
+	}
+	this.metrics.DevicesCacheMisses.WithLabelValues(kind).Inc()
+	detached := context.WithoutCancel(ctx)
+	resultChan := this.group.DoChan(key, func() (interface{}, error) {
+		generation := this.startFetch(kind, key)
+		fetchCtx, cancel := context.WithTimeout(detached, this.fetchTimeout)
+		defer cancel()
+		value, err := get(fetchCtx)
+		this.finishFetch(kind, key, generation, value, ttl, err)
+		return value, err
+	})
+	select {
+	case <-ctx.Done():
+		return result, ctx.Err()
+	case temp := <-resultChan:
+		result, _ = temp.Val.(T)
+		if temp.Err != nil {
+			return result, temp.Err
+		}
+		return clone(result)
+	}
+}
+
+// clone returns a deep copy of a cached value, so that callers can not change the cache through returned slices and maps.
+// all cached values are results of json apis.
+func clone[T any](value T) (result T, err error) {
+	temp, err := json.Marshal(value)
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(temp, &result)
+	return result, err
+}
+
+type deviceInfos struct {
+	Devices       []model.Device
+	DeviceTypeIds []string
+}
+
+func (this *Cache) GetDeviceInfosOfGroup(ctx context.Context, groupId string) (devices []model.Device, deviceTypeIds []string, err error) {
+	infos, err := use(ctx, this, CacheKindGroupInfos, groupId, func(ctx context.Context) (deviceInfos, error) {
+		devices, deviceTypeIds, err := this.devices.GetDeviceInfosOfGroup(ctx, groupId)
+		return deviceInfos{Devices: devices, DeviceTypeIds: deviceTypeIds}, err
+	})
+	return infos.Devices, infos.DeviceTypeIds, err
+}
+
+func (this *Cache) GetDeviceInfosOfDevices(ctx context.Context, deviceIds []string) (devices []model.Device, deviceTypeIds []string, err error) {
+	infos, err := use(ctx, this, CacheKindDeviceInfos, strings.Join(deviceIds, ","), func(ctx context.Context) (deviceInfos, error) {
+		devices, deviceTypeIds, err := this.devices.GetDeviceInfosOfDevices(ctx, deviceIds)
+		return deviceInfos{Devices: devices, DeviceTypeIds: deviceTypeIds}, err
+	})
+	return infos.Devices, infos.DeviceTypeIds, err
+}
+
+// GetDeviceGroups caches the result by the list of ids; every device-group update invalidates all entries of the kind
+func (this *Cache) GetDeviceGroups(ctx context.Context, groupIds []string) (result []model.DeviceGroup, err error) {
+	return use(ctx, this, CacheKindDeviceGroups, strings.Join(groupIds, ","), func(ctx context.Context) ([]model.DeviceGroup, error) {
+		return this.devices.GetDeviceGroups(ctx, groupIds)
+	})
+}
+
+func (this *Cache) GetDeviceTypes(ctx context.Context, deviceTypeIds []string) (result []model.DeviceType, err error) {
+	return use(ctx, this, CacheKindDeviceTypes, strings.Join(deviceTypeIds, ","), func(ctx context.Context) ([]model.DeviceType, error) {
+		return this.devices.GetDeviceTypes(ctx, deviceTypeIds)
+	})
+}
+
+func (this *Cache) GetDeviceTypeSelectables(ctx context.Context, criteria []model.FilterCriteria) (result []model.DeviceTypeSelectable, err error) {
+	key, err := json.Marshal(criteria)
+	if err != nil {
+		return this.devices.GetDeviceTypeSelectables(ctx, criteria)
+	}
+	return use(ctx, this, CacheKindDeviceTypeSelectables, string(key), func(ctx context.Context) ([]model.DeviceTypeSelectable, error) {
+		return this.devices.GetDeviceTypeSelectables(ctx, criteria)
+	})
+}
+
+func (this *Cache) GetConcept(ctx context.Context, conceptId string) (result model.Concept, err error) {
+	return use(ctx, this, CacheKindConcept, conceptId, func(ctx context.Context) (model.Concept, error) {
+		return this.devices.GetConcept(ctx, conceptId)
+	})
+}
+
+func (this *Cache) GetFunction(ctx context.Context, functionId string) (result model.Function, err error) {
+	return use(ctx, this, CacheKindFunction, functionId, func(ctx context.Context) (model.Function, error) {
+		return this.devices.GetFunction(ctx, functionId)
+	})
+}
+
+func (this *Cache) GetService(ctx context.Context, serviceId string) (result models.Service, err error) {
+	return use(ctx, this, CacheKindService, serviceId, func(ctx context.Context) (models.Service, error) {
+		return this.devices.GetService(ctx, serviceId)
+	})
+}
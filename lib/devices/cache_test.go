@@ -0,0 +1,231 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package devices
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/event-deployment/lib/tests/mocks"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingDevices struct {
+	*mocks.DevicesMock
+	functionCalls atomic.Int64
+}
+
+func (this *countingDevices) GetFunction(ctx context.Context, functionId string) (result model.Function, err error) {
+	this.functionCalls.Add(1)
+	time.Sleep(50 * time.Millisecond)
+	return this.DevicesMock.GetFunction(ctx, functionId)
+}
+
+func TestCache(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inner := &countingDevices{DevicesMock: &mocks.DevicesMock{
+		Functions: map[string]model.Function{"f1": {Id: "f1", Name: "f1"}},
+	}}
+	conf := &config.ConfigStruct{DevicesCacheTtl: map[string]string{CacheKindFunction: "200ms"}}
+	cache, err := NewCache(ctx, wg, conf, inner, metrics.New())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("coalesce", func(t *testing.T) {
+		requests := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			requests.Add(1)
+			go func() {
+				defer requests.Done()
+				f, err := cache.GetFunction(ctx, "f1")
+				if err != nil || f.Id != "f1" {
+					t.Error(f, err)
+				}
+			}()
+		}
+		requests.Wait()
+		if calls := inner.functionCalls.Load(); calls != 1 {
+			t.Error(calls)
+		}
+	})
+
+	t.Run("hit", func(t *testing.T) {
+		_, err := cache.GetFunction(ctx, "f1")
+		if err != nil {
+			t.Error(err)
+		}
+		if calls := inner.functionCalls.Load(); calls != 1 {
+			t.Error(calls)
+		}
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		_, err := cache.GetFunction(ctx, "unknown")
+		if err == nil {
+			t.Error("expected error")
+		}
+		_, err = cache.GetFunction(ctx, "unknown")
+		if err == nil {
+			t.Error("expected error")
+		}
+		if calls := inner.functionCalls.Load(); calls != 3 {
+			t.Error(calls)
+		}
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		err = getInvalidationListener(func(id string) {
+			cache.Invalidate(CacheKindFunction, id)
+		})([]byte(`{"command":"PUT","id":"f1"}`))
+		if err != nil {
+			t.Error(err)
+		}
+		_, err := cache.GetFunction(ctx, "f1")
+		if err != nil {
+			t.Error(err)
+		}
+		if calls := inner.functionCalls.Load(); calls != 4 {
+			t.Error(calls)
+		}
+	})
+
+	t.Run("expire", func(t *testing.T) {
+		time.Sleep(250 * time.Millisecond)
+		_, err := cache.GetFunction(ctx, "f1")
+		if err != nil {
+			t.Error(err)
+		}
+		if calls := inner.functionCalls.Load(); calls != 5 {
+			t.Error(calls)
+		}
+	})
+}
+
+func TestCacheDeviceGroup(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inner := &mocks.DevicesMock{
+		GetDeviceInfosOfGroupValues: map[string][]model.Device{"g1": {{Id: "d1", DeviceTypeId: "dt1"}}},
+	}
+	conf := &config.ConfigStruct{DevicesCacheTtl: map[string]string{CacheKindGroupInfos: "1m"}}
+	cache, err := NewCache(ctx, wg, conf, inner, metrics.New())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("callers get copies", func(t *testing.T) {
+		devices, _, err := cache.GetDeviceInfosOfGroup(ctx, "g1")
+		if err != nil || len(devices) != 1 {
+			t.Error(devices, err)
+			return
+		}
+		devices[0].Id = "changed"
+		devices, _, err = cache.GetDeviceInfosOfGroup(ctx, "g1")
+		if err != nil || len(devices) != 1 || devices[0].Id != "d1" {
+			t.Error(devices, err)
+		}
+	})
+
+	t.Run("invalidate group", func(t *testing.T) {
+		inner.GetDeviceInfosOfGroupValues["g1"] = []model.Device{{Id: "d1", DeviceTypeId: "dt1"}, {Id: "d2", DeviceTypeId: "dt1"}}
+		devices, _, err := cache.GetDeviceInfosOfGroup(ctx, "g1")
+		if err != nil || len(devices) != 1 {
+			t.Error("expected cached value", devices, err)
+		}
+		cache.InvalidateDeviceGroup([]byte(`{"command":"PUT","id":"g1"}`))
+		devices, _, err = cache.GetDeviceInfosOfGroup(ctx, "g1")
+		if err != nil || len(devices) != 2 {
+			t.Error(devices, err)
+		}
+	})
+}
+
+func TestCacheConcurrentInvalidation(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inner := &countingDevices{DevicesMock: &mocks.DevicesMock{
+		Functions: map[string]model.Function{"f1": {Id: "f1", Name: "f1"}},
+	}}
+	conf := &config.ConfigStruct{DevicesCacheTtl: map[string]string{CacheKindFunction: "1m"}}
+	cache, err := NewCache(ctx, wg, conf, inner, metrics.New())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("invalidation while fetching", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := cache.GetFunction(ctx, "f1")
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cache.Invalidate(CacheKindFunction, "f1")
+		<-done
+		_, err := cache.GetFunction(ctx, "f1")
+		if err != nil {
+			t.Error(err)
+		}
+		if calls := inner.functionCalls.Load(); calls != 2 {
+			t.Error("the result of a request started before the invalidation should not be cached", calls)
+		}
+	})
+
+	t.Run("canceled caller", func(t *testing.T) {
+		cache.InvalidateKind(CacheKindFunction)
+		result := make(chan error, 1)
+		go func() {
+			_, err := cache.GetFunction(ctx, "f1")
+			result <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		callerCtx, callerCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer callerCancel()
+		_, err := cache.GetFunction(callerCtx, "f1")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Error(err)
+		}
+		err = <-result
+		if err != nil {
+			t.Error("the shared request should not be canceled by another caller", err)
+		}
+		if calls := inner.functionCalls.Load(); calls != 3 {
+			t.Error(calls)
+		}
+	})
+}
@@ -18,8 +18,10 @@ package devices
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"log"
 	"net/http"
@@ -27,22 +29,22 @@ import (
 	"runtime/debug"
 )
 
-func (this *Devices) GetConcept(conceptId string) (result model.Concept, err error, code int) {
+func (this *Devices) GetConcept(ctx context.Context, conceptId string) (result model.Concept, err error) {
 	token, err := this.auth.Ensure()
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
-	req, err := http.NewRequest("GET", this.config.DeviceRepositoryUrl+"/concepts/"+url.PathEscape(conceptId), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", this.config.DeviceRepositoryUrl+"/concepts/"+url.PathEscape(conceptId), nil)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 	token.UseInRequest(req)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, errs.FromRequestError(dependency, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
@@ -51,33 +53,33 @@ func (this *Devices) GetConcept(conceptId string) (result model.Concept, err err
 		err = errors.New(buf.String())
 		log.Println("ERROR: ", resp.StatusCode, err)
 		debug.PrintStack()
-		return result, err, resp.StatusCode
+		return result, errs.FromStatusCode(dependency, resp.StatusCode, err)
 	}
 	err = json.NewDecoder(resp.Body).Decode(&result)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 
-	return result, nil, http.StatusOK
+	return result, nil
 }
 
-func (this *Devices) GetFunction(functionId string) (result model.Function, err error, code int) {
+func (this *Devices) GetFunction(ctx context.Context, functionId string) (result model.Function, err error) {
 	token, err := this.auth.Ensure()
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
-	req, err := http.NewRequest("GET", this.config.DeviceRepositoryUrl+"/functions/"+url.PathEscape(functionId), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", this.config.DeviceRepositoryUrl+"/functions/"+url.PathEscape(functionId), nil)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 	token.UseInRequest(req)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, errs.FromRequestError(dependency, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
@@ -86,13 +88,13 @@ func (this *Devices) GetFunction(functionId string) (result model.Function, err
 		err = errors.New(buf.String())
 		log.Println("ERROR: ", resp.StatusCode, err)
 		debug.PrintStack()
-		return result, err, resp.StatusCode
+		return result, errs.FromStatusCode(dependency, resp.StatusCode, err)
 	}
 	err = json.NewDecoder(resp.Body).Decode(&result)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 
-	return result, nil, http.StatusOK
+	return result, nil
 }
@@ -18,11 +18,13 @@ package devices
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/SENERGY-Platform/device-repository/lib/client"
 	"github.com/SENERGY-Platform/event-deployment/lib/auth"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"github.com/SENERGY-Platform/models/go/models"
@@ -32,6 +34,9 @@ import (
 	"runtime/debug"
 )
 
+// dependency is the name of the device-repository in errors
+const dependency = "device-repository"
+
 type FactoryType struct{}
 
 func (this *FactoryType) New(config config.Config) (interfaces.Devices, error) {
@@ -78,29 +83,29 @@ func (this InternalAdminTokenAuth) Ensure() (token auth.AuthToken, err error) {
 	return client.InternalAdminToken, nil
 }
 
-func (this *Devices) GetDeviceInfosOfGroup(groupId string) (devices []model.Device, deviceTypeIds []string, err error, code int) {
+func (this *Devices) GetDeviceInfosOfGroup(ctx context.Context, groupId string) (devices []model.Device, deviceTypeIds []string, err error) {
 	token, err := this.auth.Ensure()
 	if err != nil {
-		return devices, nil, err, http.StatusInternalServerError
+		return devices, nil, err
+	}
+	group, err := this.GetDeviceGroup(ctx, token, groupId)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrForbidden) {
+		return nil, nil, nil
 	}
-	group, err, code := this.GetDeviceGroup(token, groupId)
 	if err != nil {
-		if code == http.StatusNotFound || code == http.StatusForbidden || code == http.StatusUnauthorized {
-			return nil, nil, nil, http.StatusOK
-		}
-		return devices, nil, err, code
+		return devices, nil, err
 	}
-	return this.GetDeviceInfosOfDevices(group.DeviceIds)
+	return this.GetDeviceInfosOfDevices(ctx, group.DeviceIds)
 }
 
-func (this *Devices) GetDeviceInfosOfDevices(deviceIds []string) (devices []model.Device, deviceTypeIds []string, err error, code int) {
+func (this *Devices) GetDeviceInfosOfDevices(ctx context.Context, deviceIds []string) (devices []model.Device, deviceTypeIds []string, err error) {
 	token, err := this.auth.Ensure()
 	if err != nil {
-		return devices, nil, err, http.StatusInternalServerError
+		return devices, nil, err
 	}
-	devices, err, code = this.GetDevicesWithIds(token, deviceIds)
+	devices, err = this.GetDevicesWithIds(ctx, token, deviceIds)
 	if err != nil {
-		return devices, nil, err, code
+		return devices, nil, err
 	}
 	deviceTypeIsUsed := map[string]bool{}
 	for _, d := range devices {
@@ -109,33 +114,83 @@ func (this *Devices) GetDeviceInfosOfDevices(deviceIds []string) (devices []mode
 			deviceTypeIds = append(deviceTypeIds, d.DeviceTypeId)
 		}
 	}
-	return devices, deviceTypeIds, nil, http.StatusOK
+	return devices, deviceTypeIds, nil
 }
 
-func (this *Devices) GetDeviceGroup(token auth.AuthToken, groupId string) (result model.DeviceGroup, err error, code int) {
-	return this.devicerepo.ReadDeviceGroup(groupId, string(token), false)
+// GetDeviceGroup checks ctx only before the request, because the device-repository client does not accept a context
+func (this *Devices) GetDeviceGroup(ctx context.Context, token auth.AuthToken, groupId string) (result model.DeviceGroup, err error) {
+	if err = ctx.Err(); err != nil {
+		return result, errs.Transient(dependency, err)
+	}
+	result, err, code := this.devicerepo.ReadDeviceGroup(groupId, string(token), false)
+	if err != nil {
+		return result, errs.FromStatusCode(dependency, code, err)
+	}
+	return result, nil
 }
 
-func (this *Devices) GetDevicesWithIds(token auth.AuthToken, ids []string) (result []model.Device, err error, code int) {
-	return this.devicerepo.ListDevices(string(token), client.DeviceListOptions{Ids: ids})
+// GetDevicesWithIds checks ctx only before the request, because the device-repository client does not accept a context
+func (this *Devices) GetDevicesWithIds(ctx context.Context, token auth.AuthToken, ids []string) (result []model.Device, err error) {
+	if err = ctx.Err(); err != nil {
+		return result, errs.Transient(dependency, err)
+	}
+	result, err, code := this.devicerepo.ListDevices(string(token), client.DeviceListOptions{Ids: ids})
+	if err != nil {
+		return result, errs.FromStatusCode(dependency, code, err)
+	}
+	return result, nil
+}
+
+// GetDeviceGroups returns the device-groups with the given ids; unknown or inaccessible groups are missing in the result.
+// ctx is only checked before the request, because the device-repository client does not accept a context
+func (this *Devices) GetDeviceGroups(ctx context.Context, groupIds []string) (result []model.DeviceGroup, err error) {
+	token, err := this.auth.Ensure()
+	if err != nil {
+		return result, err
+	}
+	if err = ctx.Err(); err != nil {
+		return result, errs.Transient(dependency, err)
+	}
+	result, _, err, code := this.devicerepo.ListDeviceGroups(string(token), client.DeviceGroupListOptions{Ids: groupIds, Limit: int64(len(groupIds))})
+	if err != nil {
+		return result, errs.FromStatusCode(dependency, code, err)
+	}
+	return result, nil
+}
+
+// GetDeviceTypes returns the device-types with the given ids; unknown device-types are missing in the result.
+// ctx is only checked before the request, because the device-repository client does not accept a context
+func (this *Devices) GetDeviceTypes(ctx context.Context, deviceTypeIds []string) (result []model.DeviceType, err error) {
+	token, err := this.auth.Ensure()
+	if err != nil {
+		return result, err
+	}
+	if err = ctx.Err(); err != nil {
+		return result, errs.Transient(dependency, err)
+	}
+	result, _, err, code := this.devicerepo.ListDeviceTypesV3(string(token), client.DeviceTypeListOptions{Ids: deviceTypeIds, Limit: int64(len(deviceTypeIds))})
+	if err != nil {
+		return result, errs.FromStatusCode(dependency, code, err)
+	}
+	return result, nil
 }
 
-func (this *Devices) GetService(serviceId string) (result models.Service, err error, code int) {
+func (this *Devices) GetService(ctx context.Context, serviceId string) (result models.Service, err error) {
 	token, err := this.auth.Ensure()
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
-	req, err := http.NewRequest("GET", this.config.DeviceRepositoryUrl+"/services/"+url.PathEscape(serviceId), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", this.config.DeviceRepositoryUrl+"/services/"+url.PathEscape(serviceId), nil)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 	token.UseInRequest(req)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, errs.FromRequestError(dependency, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
@@ -144,38 +199,38 @@ func (this *Devices) GetService(serviceId string) (result models.Service, err er
 		err = errors.New(buf.String())
 		log.Println("ERROR: ", resp.StatusCode, err)
 		debug.PrintStack()
-		return result, err, resp.StatusCode
+		return result, errs.FromStatusCode(dependency, resp.StatusCode, err)
 	}
 	err = json.NewDecoder(resp.Body).Decode(&result)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 
-	return result, nil, http.StatusOK
+	return result, nil
 }
 
-func (this *Devices) GetDeviceTypeSelectables(criteria []model.FilterCriteria) (result []model.DeviceTypeSelectable, err error, code int) {
+func (this *Devices) GetDeviceTypeSelectables(ctx context.Context, criteria []model.FilterCriteria) (result []model.DeviceTypeSelectable, err error) {
 	token, err := this.auth.Ensure()
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 	requestBody := new(bytes.Buffer)
 	err = json.NewEncoder(requestBody).Encode(criteria)
 	if err != nil {
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
-	req, err := http.NewRequest("POST", this.config.DeviceRepositoryUrl+"/query/device-type-selectables?interactions-filter=event&include_id_modified=true", requestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", this.config.DeviceRepositoryUrl+"/query/device-type-selectables?interactions-filter=event&include_id_modified=true", requestBody)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 	token.UseInRequest(req)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, errs.FromRequestError(dependency, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
@@ -184,13 +239,13 @@ func (this *Devices) GetDeviceTypeSelectables(criteria []model.FilterCriteria) (
 		err = errors.New(buf.String())
 		log.Println("ERROR: ", resp.StatusCode, err)
 		debug.PrintStack()
-		return result, err, resp.StatusCode
+		return result, errs.FromStatusCode(dependency, resp.StatusCode, err)
 	}
 	err = json.NewDecoder(resp.Body).Decode(&result)
 	if err != nil {
 		debug.PrintStack()
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 
-	return result, nil, http.StatusOK
+	return result, nil
 }
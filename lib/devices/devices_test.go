@@ -197,7 +197,7 @@ func TestDevices(t *testing.T) {
 
 func testCheckGetDeviceInfosOfGroupResult(repo *Devices, deviceGroupId string, expectedDevices []model.Device, expectedDeviceTypeIds []string) func(t *testing.T) {
 	return func(t *testing.T) {
-		actualDevices, actualDeviceTypeIds, err, _ := repo.GetDeviceInfosOfGroup(deviceGroupId)
+		actualDevices, actualDeviceTypeIds, err := repo.GetDeviceInfosOfGroup(context.Background(), deviceGroupId)
 		if err != nil {
 			t.Error(err)
 			return
@@ -0,0 +1,201 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package errs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// kinds of errors; match them with errors.Is
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrForbidden           = errors.New("forbidden")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrInvalid             = errors.New("invalid")
+	ErrNotImplemented      = errors.New("not implemented")
+	ErrConflict            = errors.New("conflict")
+	ErrTransient           = errors.New("transient")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+)
+
+// Error classifies Err by Kind and names the Dependency that caused it.
+// errors.Is matches both Kind and Err; errors.As gives access to the Dependency.
+type Error struct {
+	Kind       error
+	Dependency string
+	Err        error
+}
+
+func (this *Error) Error() string {
+	if this.Dependency == "" {
+		return this.Kind.Error() + ": " + this.Err.Error()
+	}
+	return this.Dependency + ": " + this.Kind.Error() + ": " + this.Err.Error()
+}
+
+func (this *Error) Unwrap() []error {
+	return []error{this.Kind, this.Err}
+}
+
+// New returns nil if err is nil. dependency may be empty for errors of this service.
+func New(kind error, dependency string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Dependency: dependency, Err: err}
+}
+
+func NotFound(dependency string, err error) error {
+	return New(ErrNotFound, dependency, err)
+}
+
+func Forbidden(dependency string, err error) error {
+	return New(ErrForbidden, dependency, err)
+}
+
+func Unauthorized(dependency string, err error) error {
+	return New(ErrUnauthorized, dependency, err)
+}
+
+func Invalid(dependency string, err error) error {
+	return New(ErrInvalid, dependency, err)
+}
+
+func NotImplemented(dependency string, err error) error {
+	return New(ErrNotImplemented, dependency, err)
+}
+
+func Conflict(dependency string, err error) error {
+	return New(ErrConflict, dependency, err)
+}
+
+func Transient(dependency string, err error) error {
+	return New(ErrTransient, dependency, err)
+}
+
+func UpstreamUnavailable(dependency string, err error) error {
+	return New(ErrUpstreamUnavailable, dependency, err)
+}
+
+// FromStatusCode classifies an error response of a dependency by its http status code
+func FromStatusCode(dependency string, code int, err error) error {
+	switch {
+	case code == http.StatusNotFound:
+		return NotFound(dependency, err)
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return Forbidden(dependency, err)
+	case code == http.StatusConflict:
+		return Conflict(dependency, err)
+	case code == http.StatusRequestTimeout || code == http.StatusTooManyRequests:
+		return Transient(dependency, err)
+	case code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout:
+		return UpstreamUnavailable(dependency, err)
+	case code >= 500:
+		return Transient(dependency, err)
+	case code >= 400:
+		return Invalid(dependency, err)
+	default:
+		return Transient(dependency, err)
+	}
+}
+
+// FromRequestError classifies an error of a request, that did not receive a response.
+// canceled contexts and exceeded deadlines are transient, every other error means the dependency is not reachable.
+func FromRequestError(dependency string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return Transient(dependency, err)
+	}
+	return UpstreamUnavailable(dependency, err)
+}
+
+// Kind returns the kind of the outermost classification of err; errors wrapping a kind directly are classified by it.
+// Kind returns nil for unclassified errors.
+func Kind(err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	for _, k := range kinds {
+		if errors.Is(err, k.kind) {
+			return k.kind
+		}
+	}
+	return nil
+}
+
+// IsPermanent returns true for errors, that will not be resolved by retrying the same operation.
+// unclassified errors are not permanent.
+func IsPermanent(err error) bool {
+	kind := Kind(err)
+	for _, k := range kinds {
+		if k.kind == kind {
+			return k.permanent
+		}
+	}
+	return false
+}
+
+// IsSkippable returns true for errors, that let consumers skip a message instead of retrying it: the message is invalid or refers to a missing resource.
+// other permanent errors are retried by consumers, because forbidden or unauthorized responses of dependencies may be caused by rotated
+// or misconfigured service credentials and conflicts by concurrent changes; skipping them would drop the message for good.
+func IsSkippable(err error) bool {
+	kind := Kind(err)
+	return kind == ErrNotFound || kind == ErrInvalid
+}
+
+// Dependency returns the dependency of the outermost classification of err
+func Dependency(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Dependency
+	}
+	return ""
+}
+
+// StatusCode returns the http status code for the kind of err; unclassified errors result in http.StatusInternalServerError
+func StatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	kind := Kind(err)
+	for _, k := range kinds {
+		if k.kind == kind {
+			return k.statusCode
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+var kinds = []struct {
+	kind       error
+	statusCode int
+	permanent  bool
+}{
+	{kind: ErrNotFound, statusCode: http.StatusNotFound, permanent: true},
+	{kind: ErrForbidden, statusCode: http.StatusForbidden, permanent: true},
+	{kind: ErrUnauthorized, statusCode: http.StatusUnauthorized, permanent: true},
+	{kind: ErrInvalid, statusCode: http.StatusBadRequest, permanent: true},
+	{kind: ErrNotImplemented, statusCode: http.StatusNotImplemented, permanent: true},
+	{kind: ErrConflict, statusCode: http.StatusConflict, permanent: true},
+	{kind: ErrTransient, statusCode: http.StatusServiceUnavailable, permanent: false},
+	{kind: ErrUpstreamUnavailable, statusCode: http.StatusBadGateway, permanent: false},
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	t.Run("classification", func(t *testing.T) {
+		cause := errors.New("cause")
+		err := fmt.Errorf("wrapped: %w", FromStatusCode("device-repository", http.StatusNotFound, cause))
+		if !errors.Is(err, ErrNotFound) || !errors.Is(err, cause) {
+			t.Error(err)
+		}
+		if Dependency(err) != "device-repository" {
+			t.Error(Dependency(err))
+		}
+		if !IsPermanent(err) || StatusCode(err) != http.StatusNotFound {
+			t.Error(IsPermanent(err), StatusCode(err))
+		}
+		if err.Error() != "wrapped: device-repository: not found: cause" {
+			t.Error(err.Error())
+		}
+	})
+
+	t.Run("status codes", func(t *testing.T) {
+		cases := map[int]error{
+			http.StatusBadRequest:          ErrInvalid,
+			http.StatusUnauthorized:        ErrForbidden,
+			http.StatusForbidden:           ErrForbidden,
+			http.StatusNotFound:            ErrNotFound,
+			http.StatusConflict:            ErrConflict,
+			http.StatusTooManyRequests:     ErrTransient,
+			http.StatusInternalServerError: ErrTransient,
+			http.StatusBadGateway:          ErrUpstreamUnavailable,
+			http.StatusServiceUnavailable:  ErrUpstreamUnavailable,
+		}
+		for code, kind := range cases {
+			if actual := Kind(FromStatusCode("dep", code, errors.New("test"))); actual != kind {
+				t.Error(code, actual, kind)
+			}
+		}
+	})
+
+	t.Run("request errors", func(t *testing.T) {
+		if Kind(FromRequestError("dep", context.DeadlineExceeded)) != ErrTransient {
+			t.Error("expected transient deadline")
+		}
+		if Kind(FromRequestError("dep", errors.New("connection refused"))) != ErrUpstreamUnavailable {
+			t.Error("expected upstream unavailable")
+		}
+		if FromRequestError("dep", nil) != nil {
+			t.Error("expected nil")
+		}
+	})
+
+	t.Run("outermost classification wins", func(t *testing.T) {
+		err := Unauthorized("", NotFound("auth", errors.New("user does not exist")))
+		if StatusCode(err) != http.StatusUnauthorized || Dependency(err) != "" {
+			t.Error(StatusCode(err), Dependency(err))
+		}
+		if !errors.Is(err, ErrNotFound) {
+			t.Error("expected inner kind to match")
+		}
+	})
+
+	t.Run("skippable", func(t *testing.T) {
+		skippable := map[int]bool{
+			http.StatusBadRequest:          true,
+			http.StatusNotFound:            true,
+			http.StatusUnauthorized:        false,
+			http.StatusForbidden:           false,
+			http.StatusConflict:            false,
+			http.StatusInternalServerError: false,
+		}
+		for code, expected := range skippable {
+			if actual := IsSkippable(FromStatusCode("dep", code, errors.New("test"))); actual != expected {
+				t.Error(code, actual, expected)
+			}
+		}
+		if IsSkippable(errors.New("unclassified")) {
+			t.Error("expected unclassified errors to be retried")
+		}
+	})
+
+	t.Run("unclassified", func(t *testing.T) {
+		err := errors.New("unclassified")
+		if IsPermanent(err) || StatusCode(err) != http.StatusInternalServerError || Kind(err) != nil {
+			t.Error(IsPermanent(err), StatusCode(err), Kind(err))
+		}
+		if IsPermanent(fmt.Errorf("%w: test", ErrInvalid)) != true {
+			t.Error("expected wrapped kind to be permanent")
+		}
+		if StatusCode(nil) != http.StatusOK {
+			t.Error(StatusCode(nil))
+		}
+	})
+}
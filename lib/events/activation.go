@@ -0,0 +1,141 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package events
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/deployments"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"log"
+	"sync"
+	"time"
+)
+
+type ScheduleRepository interface {
+	SetScheduledDeployment(ctx context.Context, element deployments.ScheduledDeployment) error
+	GetScheduledDeployment(ctx context.Context, id string) (result deployments.ScheduledDeployment, exists bool, err error)
+	GetDueScheduledDeployments(ctx context.Context, now time.Time) (result []deployments.ScheduledDeployment, err error)
+	UpdateScheduledDeploymentState(ctx context.Context, id string, revision string, active bool, nextCheck *time.Time) (updated bool, err error)
+	RemoveScheduledDeployment(ctx context.Context, id string) error
+}
+
+// max attempts to apply the activation of a deployment, that is replaced while the scheduler applies it
+const activationConflictRetries = 3
+
+func (this *Events) startActivationScheduler(ctx context.Context, wg *sync.WaitGroup, repo *deployments.Deployments) (err error) {
+	if repo == nil || this.config.ConditionalEventRepoMongoScheduleCollection == "" {
+		return nil
+	}
+	interval := time.Minute
+	if this.config.ActivationSchedulerInterval != "" {
+		interval, err = time.ParseDuration(this.config.ActivationSchedulerInterval)
+		if err != nil {
+			return err
+		}
+	}
+	this.schedule = repo
+	this.elector.RunAsLeader(ctx, wg, "activation-scheduler", interval, this.runActivationSchedule)
+	return nil
+}
+
+// newScheduledDeployment returns the schedule entry of the deployment, which is stored with every deployment; its events are only deployed if its activation is currently active.
+// an inactive deployment is removed from the handlers, to clean up events of a previous version.
+// without schedule collection, the result is nil and the deployment is always active.
+func (this *Events) newScheduledDeployment(owner string, deployment model.Deployment) (result *deployments.ScheduledDeployment, err error) {
+	if this.schedule == nil {
+		if deployment.Activation != nil {
+			log.Println("WARNING: no schedule collection configured --> ignore activation of deployment", deployment.Id)
+		}
+		return nil, nil
+	}
+	if deployment.Activation != nil {
+		err = deployment.Activation.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+	now := config.TimeNow()
+	result = &deployments.ScheduledDeployment{
+		Id:             deployment.Id,
+		Revision:       config.NewId(),
+		Owner:          owner,
+		Deployment:     deployment.Deployment,
+		FilterCriteria: deployment.FilterCriteria,
+		Activation:     deployment.Activation,
+	}
+	result.Active = result.IsActive(now)
+	result.NextCheck = result.NextChange(now)
+	return result, nil
+}
+
+func (this *Events) runActivationSchedule(ctx context.Context) error {
+	due, err := this.schedule.GetDueScheduledDeployments(ctx, config.TimeNow())
+	if err != nil {
+		return err
+	}
+	for _, entry := range due {
+		err = this.updateActivation(ctx, entry)
+		if err != nil {
+			//the entry stays due and is retried with the next run
+			log.Println("ERROR: unable to update activation of deployment", entry.Id, err)
+		}
+	}
+	return nil
+}
+
+func (this *Events) updateActivation(ctx context.Context, entry deployments.ScheduledDeployment) error {
+	for i := 0; i < activationConflictRetries; i++ {
+		now := config.TimeNow()
+		active := entry.IsActive(now)
+		changed := active != entry.Active
+		if changed {
+			log.Println("activation of deployment", entry.Id, "changed to active =", active)
+			entry.Active = active
+			err := this.applyActivation(ctx, entry)
+			if err != nil {
+				return err
+			}
+		}
+		updated, err := this.schedule.UpdateScheduledDeploymentState(ctx, entry.Id, entry.Revision, active, entry.NextChange(now))
+		if err != nil || updated || !changed {
+			return err
+		}
+		//the deployment has been replaced or removed while the activation was applied --> the new version has to be restored
+		current, exists, err := this.schedule.GetScheduledDeployment(ctx, entry.Id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return this.remove(ctx, entry.Owner, entry.Id)
+		}
+		entry = current
+		err = this.applyActivation(ctx, entry)
+		if err != nil {
+			return err
+		}
+	}
+	log.Println("WARNING: deployment", entry.Id, "changed repeatedly while its activation was applied --> retry with next run")
+	return nil
+}
+
+func (this *Events) applyActivation(ctx context.Context, entry deployments.ScheduledDeployment) error {
+	if entry.Active {
+		return this.deploy(ctx, entry.Owner, entry.GetDeployment())
+	}
+	return this.remove(ctx, entry.Owner, entry.Id)
+}
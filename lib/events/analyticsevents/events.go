@@ -21,7 +21,9 @@ import (
 	"errors"
 	"github.com/SENERGY-Platform/event-deployment/lib/auth"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/leader"
 	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"github.com/SENERGY-Platform/models/go/models"
@@ -29,6 +31,8 @@ import (
 	"net/http"
 	"runtime/debug"
 	"sort"
+	"sync"
+	"time"
 )
 
 type Events struct {
@@ -38,30 +42,55 @@ type Events struct {
 	imports   interfaces.Imports
 	metrics   *metrics.Metrics
 	auth      *auth.Auth
+	owners    OwnerRepository
 }
 
-func New(ctx context.Context, config config.Config, analytics interfaces.Analytics, devices interfaces.Devices, imports interfaces.Imports, m *metrics.Metrics) (result *Events, err error) {
+// OwnerRepository stores the owners of deployed event pipelines, to replace their pipeline tokens after a restart
+type OwnerRepository interface {
+	AddPipelineOwner(ctx context.Context, owner string) error
+	ListPipelineOwners(ctx context.Context) ([]string, error)
+}
+
+// New creates the handler; owners is optional
+func New(ctx context.Context, wg *sync.WaitGroup, config config.Config, analytics interfaces.Analytics, devices interfaces.Devices, imports interfaces.Imports, m *metrics.Metrics, elector *leader.Elector, owners OwnerRepository) (result *Events, err error) {
 	a, err := auth.NewAuth(config)
 	if err != nil {
 		return result, err
 	}
-	return &Events{config: config, analytics: analytics, devices: devices, imports: imports, metrics: m, auth: a}, err
+	a.Credentials().Start(ctx, wg)
+	result = &Events{config: config, analytics: analytics, devices: devices, imports: imports, metrics: m, auth: a, owners: owners}
+	if config.PipelineTokenRefreshInterval != "" && config.PipelineTokenRefreshInterval != "-" {
+		interval, err := time.ParseDuration(config.PipelineTokenRefreshInterval)
+		if err != nil {
+			return result, err
+		}
+		elector.RunAsLeader(ctx, wg, "pipeline user token refresh", interval, func(ctx context.Context) error {
+			return result.refreshPipelineUserTokens(ctx, interval)
+		})
+	}
+	return result, nil
 }
 
-func (this *Events) Deploy(owner string, deployment models.Deployment) error {
-	err := this.Remove(owner, deployment.Id)
+func (this *Events) Deploy(ctx context.Context, owner string, deployment model.Deployment) error {
+	err := this.Remove(ctx, owner, deployment.Id)
 	if err != nil {
 		if this.config.IgnoreAnalyticsEventErrors {
 			return nil
 		}
 		return err
 	}
-	token, err := this.auth.GetUserToken(owner)
+	if this.owners != nil {
+		err = this.owners.AddPipelineOwner(ctx, owner)
+		if err != nil {
+			return err
+		}
+	}
+	token, err := this.auth.GetUserToken(ctx, owner)
 	if err != nil {
 		return err
 	}
 	for _, element := range deployment.Elements {
-		err = this.deployElement(token, owner, deployment.Id, element)
+		err = this.deployElement(ctx, token, owner, deployment, element)
 		if err != nil {
 			if this.config.IgnoreAnalyticsEventErrors {
 				return nil
@@ -72,38 +101,40 @@ func (this *Events) Deploy(owner string, deployment models.Deployment) error {
 	return nil
 }
 
-func (this *Events) deployElement(token auth.AuthToken, owner string, deploymentId string, element models.Element) (err error) {
+func (this *Events) deployElement(ctx context.Context, token auth.AuthToken, owner string, deployment model.Deployment, element models.Element) (err error) {
 	event := element.MessageEvent
 	if event != nil && event.Selection.FilterCriteria.CharacteristicId != nil {
 		this.metrics.DeployedAnalyticsEvents.Inc()
 		label := element.Name + " (" + event.EventId + ")"
+		deploymentId := deployment.Id
+		additionalCriteria := deployment.GetFilterCriteria(event.EventId, event.Selection)[1:]
 		if event.Selection.SelectedDeviceGroupId != nil && *event.Selection.SelectedDeviceGroupId != "" {
-			return this.deployEventForDeviceGroup(token, label, owner, deploymentId, event)
+			return this.deployEventForDeviceGroup(ctx, token, label, owner, deploymentId, event, additionalCriteria)
 		}
 		if event.Selection.SelectedDeviceId != nil && event.Selection.SelectedServiceId != nil && *event.Selection.SelectedServiceId != "" {
-			return this.deployEventForDevice(token, label, owner, deploymentId, event)
+			return this.deployEventForDevice(ctx, token, label, owner, deploymentId, event)
 		}
 		if event.Selection.SelectedDeviceId != nil && !(event.Selection.SelectedServiceId != nil && *event.Selection.SelectedServiceId != "") {
-			return this.deployEventForDeviceWithoutService(token, label, owner, deploymentId, event)
+			return this.deployEventForDeviceWithoutService(ctx, token, label, owner, deploymentId, event, additionalCriteria)
 		}
 		if event.Selection.SelectedImportId != nil {
-			return this.deployEventForImport(token, label, owner, deploymentId, event)
+			return this.deployEventForImport(ctx, token, label, owner, deploymentId, event)
 		}
 		if event.Selection.SelectedGenericEventSource != nil {
-			return this.deployEventForGenericSource(token, label, owner, deploymentId, event)
+			return this.deployEventForGenericSource(ctx, token, label, owner, deploymentId, event)
 		}
 	}
 	return nil
 }
 
-func (this *Events) Remove(owner string, deploymentId string) error {
-	pipelineIds, err := this.analytics.GetPipelinesByDeploymentId(owner, deploymentId)
+func (this *Events) Remove(ctx context.Context, owner string, deploymentId string) error {
+	pipelineIds, err := this.analytics.GetPipelinesByDeploymentId(ctx, owner, deploymentId)
 	if err != nil {
 		return err
 	}
 	for _, id := range pipelineIds {
 		this.metrics.RemovedAnalyticsEvents.Inc()
-		err = this.analytics.Remove(owner, id)
+		err = this.analytics.Remove(ctx, owner, id)
 		if err != nil {
 			return err
 		}
@@ -111,14 +142,22 @@ func (this *Events) Remove(owner string, deploymentId string) error {
 	return nil
 }
 
-func (this *Events) CheckEvent(token string, id string) int {
+var ErrRebuildNotImplemented = errs.NotImplemented("", errors.New("rebuild is not supported for analytics events"))
+
+// RemoveDeploymentsExcept is not implemented, because pipelines are stored by the analytics service and can not be listed for all users;
+// a rebuild would leave pipelines of deployments without command in the deployment topic.
+func (this *Events) RemoveDeploymentsExcept(ctx context.Context, keep map[string]bool) error {
+	return ErrRebuildNotImplemented
+}
+
+func (this *Events) CheckEvent(ctx context.Context, token string, id string) int {
 	userId, err := GetUserId(token)
 	if err != nil {
 		log.Println("ERROR:", err)
 		debug.PrintStack()
 		return http.StatusBadRequest
 	}
-	_, exists, err := this.analytics.GetPipelineByEventId(userId, id)
+	_, exists, err := this.analytics.GetPipelineByEventId(ctx, userId, id)
 	if err != nil {
 		log.Println("ERROR:", err)
 		debug.PrintStack()
@@ -130,30 +169,47 @@ func (this *Events) CheckEvent(token string, id string) int {
 	return http.StatusOK
 }
 
-func (this *Events) GetEventStates(token string, ids []string) (states map[string]bool, err error, code int) {
+func (this *Events) GetEventStates(ctx context.Context, token string, ids []string) (states map[string]bool, err error) {
 	userId, err := GetUserId(token)
 	if err != nil {
 		log.Println("ERROR:", err)
 		debug.PrintStack()
-		return states, err, http.StatusBadRequest
+		return states, errs.Invalid("", err)
 	}
 	states = map[string]bool{}
 	if len(ids) == 0 {
-		return states, nil, http.StatusOK
+		return states, nil
+	}
+	states, err = this.analytics.GetEventStates(ctx, userId, ids)
+	if err != nil {
+		log.Println("ERROR:", err)
+		debug.PrintStack()
+		return states, err
+	}
+	return states, nil
+}
+
+func (this *Events) GetEventDetails(ctx context.Context, token string, id string) (details model.EventDetails, err error) {
+	details.EventId = id
+	userId, err := GetUserId(token)
+	if err != nil {
+		log.Println("ERROR:", err)
+		debug.PrintStack()
+		return details, errs.Invalid("", err)
 	}
-	states, err = this.analytics.GetEventStates(userId, ids)
+	details.Pipelines, err = this.analytics.GetEventPipelines(ctx, userId, id)
 	if err != nil {
 		log.Println("ERROR:", err)
 		debug.PrintStack()
-		return states, err, http.StatusInternalServerError
+		return details, err
 	}
-	return states, nil, http.StatusOK
+	return details, nil
 }
 
 var ErrMissingCharacteristicInEvent = errors.New("missing characteristic id in event")
 
 // expects event.Selection.SelectedDeviceId and event.Selection.SelectedServiceId to be set
-func (this *Events) deployEventForDevice(token auth.AuthToken, label string, owner string, deploymentId string, event *models.MessageEvent) error {
+func (this *Events) deployEventForDevice(ctx context.Context, token auth.AuthToken, label string, owner string, deploymentId string, event *models.MessageEvent) error {
 	if event == nil {
 		debug.PrintStack()
 		return errors.New("missing event element") //programming error -> dont ignore
@@ -177,16 +233,16 @@ func (this *Events) deployEventForDevice(token auth.AuthToken, label string, own
 
 		//find cast extensions
 		if event.Selection.FilterCriteria.FunctionId != nil {
-			function, err, code := this.devices.GetFunction(*event.Selection.FilterCriteria.FunctionId)
+			function, err := this.devices.GetFunction(ctx, *event.Selection.FilterCriteria.FunctionId)
 			if err != nil {
-				if code != http.StatusNotFound {
+				if !errors.Is(err, errs.ErrNotFound) {
 					//ignore not found errors to prevent unresolvable kafka consumption loop
 					return err
 				}
 			} else if function.ConceptId != "" {
-				concept, err, code := this.devices.GetConcept(function.ConceptId)
+				concept, err := this.devices.GetConcept(ctx, function.ConceptId)
 				if err != nil {
-					if code != http.StatusNotFound {
+					if !errors.Is(err, errs.ErrNotFound) {
 						//ignore not found errors to prevent unresolvable kafka consumption loop
 						return err
 					}
@@ -216,6 +272,7 @@ func (this *Events) deployEventForDevice(token auth.AuthToken, label string, own
 			serializedPath = event.Selection.SelectedPath.Path
 		}
 		pipelineId, err = this.analytics.DeployDeviceWithMarshaller(
+			ctx,
 			token,
 			label,
 			owner,
@@ -231,6 +288,7 @@ func (this *Events) deployEventForDevice(token auth.AuthToken, label string, own
 			*event.Selection.FilterCriteria.CharacteristicId)
 	} else {
 		pipelineId, err = this.analytics.DeployDevice(
+			ctx,
 			token,
 			label,
 			owner,
@@ -256,7 +314,7 @@ func (this *Events) deployEventForDevice(token auth.AuthToken, label string, own
 	return nil
 }
 
-func (this *Events) deployEventForDeviceGroup(token auth.AuthToken, label string, owner string, deploymentId string, event *models.MessageEvent) error {
+func (this *Events) deployEventForDeviceGroup(ctx context.Context, token auth.AuthToken, label string, owner string, deploymentId string, event *models.MessageEvent, additionalCriteria []model.FilterCriteria) error {
 	if !this.DeviceGroupsAndImportsEnabled() {
 		log.Println("WARNING: DeviceGroupsAndImportsEnabled() = false; configure AuthClientId, AuthClientSecret, AuthEndpoint, PermSearchUrl")
 		return nil
@@ -282,20 +340,21 @@ func (this *Events) deployEventForDeviceGroup(token auth.AuthToken, label string
 		characteristicId = *event.Selection.FilterCriteria.CharacteristicId
 	}
 
-	return this.deployEventForDeviceGroupWithDescription(token, label, owner, model.GroupEventDescription{
-		DeviceGroupId:    *event.Selection.SelectedDeviceGroupId,
-		EventId:          event.EventId,
-		DeploymentId:     deploymentId,
-		CharacteristicId: characteristicId,
-		FunctionId:       *event.Selection.FilterCriteria.FunctionId,
-		AspectId:         *event.Selection.FilterCriteria.AspectId,
-		FlowId:           event.FlowId,
-		OperatorValue:    event.Value,
-		UseMarshaller:    event.UseMarshaller,
+	return this.deployEventForDeviceGroupWithDescription(ctx, token, label, owner, model.GroupEventDescription{
+		DeviceGroupId:            *event.Selection.SelectedDeviceGroupId,
+		EventId:                  event.EventId,
+		DeploymentId:             deploymentId,
+		CharacteristicId:         characteristicId,
+		FunctionId:               *event.Selection.FilterCriteria.FunctionId,
+		AspectId:                 *event.Selection.FilterCriteria.AspectId,
+		FlowId:                   event.FlowId,
+		OperatorValue:            event.Value,
+		UseMarshaller:            event.UseMarshaller,
+		AdditionalFilterCriteria: additionalCriteria,
 	})
 }
 
-func (this *Events) deployEventForDeviceGroupWithDescription(token auth.AuthToken, label string, owner string, desc model.GroupEventDescription) error {
+func (this *Events) deployEventForDeviceGroupWithDescription(ctx context.Context, token auth.AuthToken, label string, owner string, desc model.GroupEventDescription) error {
 	if !this.DeviceGroupsAndImportsEnabled() {
 		log.Println("WARNING: DeviceGroupsAndImportsEnabled() = false; configure AuthClientId, AuthClientSecret, AuthEndpoint, PermSearchUrl")
 		return nil
@@ -316,10 +375,10 @@ func (this *Events) deployEventForDeviceGroupWithDescription(token auth.AuthToke
 		log.Println("WARNING: try to deploy group event without deployment id --> ignore", label, desc)
 		return nil
 	}
-	serviceIds, serviceToDevices, serviceToPath, serviceToPathAndCharacteristic, err, code := this.getServicesPathsAndDevicesForEvent(desc)
+	serviceIds, serviceToDevices, serviceToPath, serviceToPathAndCharacteristic, err := this.getServicesPathsAndDevicesForEvent(ctx, desc)
 	if err != nil {
-		log.Println("WARNING: getServicesPathsAndDevicesForEvent()", code, err)
-		if code == http.StatusNotFound {
+		log.Println("WARNING: getServicesPathsAndDevicesForEvent()", err)
+		if errors.Is(err, errs.ErrNotFound) {
 			return nil //ignore
 		}
 		return err
@@ -327,16 +386,16 @@ func (this *Events) deployEventForDeviceGroupWithDescription(token auth.AuthToke
 
 	//find cast extensions
 	castExtensions := []model.ConverterExtension{}
-	function, err, code := this.devices.GetFunction(desc.FunctionId)
+	function, err := this.devices.GetFunction(ctx, desc.FunctionId)
 	if err != nil {
-		if code != http.StatusNotFound {
+		if !errors.Is(err, errs.ErrNotFound) {
 			//ignore not found errors to prevent unresolvable kafka consumption loop
 			return err
 		}
 	} else if function.ConceptId != "" {
-		concept, err, code := this.devices.GetConcept(function.ConceptId)
+		concept, err := this.devices.GetConcept(ctx, function.ConceptId)
 		if err != nil {
-			if code != http.StatusNotFound {
+			if !errors.Is(err, errs.ErrNotFound) {
 				//ignore not found errors to prevent unresolvable kafka consumption loop
 				return err
 			}
@@ -346,6 +405,7 @@ func (this *Events) deployEventForDeviceGroupWithDescription(token auth.AuthToke
 	}
 
 	pipelineId, err := this.analytics.DeployGroup(
+		ctx,
 		token,
 		label,
 		owner,
@@ -366,7 +426,7 @@ func (this *Events) deployEventForDeviceGroupWithDescription(token auth.AuthToke
 	return nil
 }
 
-func (this *Events) updateEventPipelineForDeviceGroup(token auth.AuthToken, pipelineId string, label string, owner string, desc model.GroupEventDescription) error {
+func (this *Events) updateEventPipelineForDeviceGroup(ctx context.Context, token auth.AuthToken, pipelineId string, label string, owner string, desc model.GroupEventDescription) error {
 	if !this.DeviceGroupsAndImportsEnabled() {
 		return nil
 	}
@@ -387,10 +447,10 @@ func (this *Events) updateEventPipelineForDeviceGroup(token auth.AuthToken, pipe
 		return nil
 	}
 
-	serviceIds, serviceToDevices, serviceToPath, serviceToPathAndCharacteristic, err, code := this.getServicesPathsAndDevicesForEvent(desc)
+	serviceIds, serviceToDevices, serviceToPath, serviceToPathAndCharacteristic, err := this.getServicesPathsAndDevicesForEvent(ctx, desc)
 	if err != nil {
-		log.Println("WARNING: getServicesPathsAndDevicesForEvent()", code, err)
-		if code == http.StatusNotFound {
+		log.Println("WARNING: getServicesPathsAndDevicesForEvent()", err)
+		if errors.Is(err, errs.ErrNotFound) {
 			return nil //ignore
 		}
 		return err
@@ -398,16 +458,16 @@ func (this *Events) updateEventPipelineForDeviceGroup(token auth.AuthToken, pipe
 
 	//find cast extensions
 	castExtensions := []model.ConverterExtension{}
-	function, err, code := this.devices.GetFunction(desc.FunctionId)
+	function, err := this.devices.GetFunction(ctx, desc.FunctionId)
 	if err != nil {
-		if code != http.StatusNotFound {
+		if !errors.Is(err, errs.ErrNotFound) {
 			//ignore not found errors to prevent unresolvable kafka consumption loop
 			return err
 		}
 	} else if function.ConceptId != "" {
-		concept, err, code := this.devices.GetConcept(function.ConceptId)
+		concept, err := this.devices.GetConcept(ctx, function.ConceptId)
 		if err != nil {
-			if code != http.StatusNotFound {
+			if !errors.Is(err, errs.ErrNotFound) {
 				//ignore not found errors to prevent unresolvable kafka consumption loop
 				return err
 			}
@@ -417,6 +477,7 @@ func (this *Events) updateEventPipelineForDeviceGroup(token auth.AuthToken, pipe
 	}
 
 	err = this.analytics.UpdateGroupDeployment(
+		ctx,
 		token,
 		pipelineId,
 		label,
@@ -436,22 +497,22 @@ func (this *Events) updateEventPipelineForDeviceGroup(token auth.AuthToken, pipe
 
 const IdParameterSeperator = "$"
 
-func (this *Events) getServicesPathsAndDevicesForEvent(desc model.GroupEventDescription) (serviceIds []string, serviceToDevices map[string][]string, serviceToPath map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, err error, code int) {
+func (this *Events) getServicesPathsAndDevicesForEvent(ctx context.Context, desc model.GroupEventDescription) (serviceIds []string, serviceToDevices map[string][]string, serviceToPath map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, err error) {
 	serviceToPathAndCharacteristic = map[string][]model.PathAndCharacteristic{}
 	var devices []model.Device
 	var deviceTypeIds []string
 	if desc.DeviceIds != nil {
-		devices, deviceTypeIds, err, code = this.devices.GetDeviceInfosOfDevices(desc.DeviceIds)
+		devices, deviceTypeIds, err = this.devices.GetDeviceInfosOfDevices(ctx, desc.DeviceIds)
 	} else {
-		devices, deviceTypeIds, err, code = this.devices.GetDeviceInfosOfGroup(desc.DeviceGroupId)
+		devices, deviceTypeIds, err = this.devices.GetDeviceInfosOfGroup(ctx, desc.DeviceGroupId)
 	}
 	if err != nil {
-		return nil, nil, nil, serviceToPathAndCharacteristic, err, code
+		return nil, nil, nil, serviceToPathAndCharacteristic, err
 	}
-	options, err := this.getDeviceGroupPathOptions(desc, deviceTypeIds)
+	options, err := this.getDeviceGroupPathOptions(ctx, desc, deviceTypeIds)
 	if err != nil {
 		log.Println("ERROR: unable to find path options", err)
-		return nil, nil, nil, serviceToPathAndCharacteristic, err, http.StatusInternalServerError
+		return nil, nil, nil, serviceToPathAndCharacteristic, err
 	}
 	serviceIds = []string{}
 	serviceToDevices = map[string][]string{}
@@ -486,7 +547,7 @@ func (this *Events) getServicesPathsAndDevicesForEvent(desc model.GroupEventDesc
 		})
 	}
 	sort.Strings(serviceIds)
-	return serviceIds, serviceToDevices, serviceToPath, serviceToPathAndCharacteristic, nil, http.StatusOK
+	return serviceIds, serviceToDevices, serviceToPath, serviceToPathAndCharacteristic, nil
 }
 
 func (this *Events) DeviceGroupsAndImportsEnabled() bool {
@@ -502,7 +563,7 @@ func (this *Events) DeviceGroupsAndImportsEnabled() bool {
 	return true
 }
 
-func (this *Events) deployEventForImport(token auth.AuthToken, label string, owner string, deploymentId string, event *models.MessageEvent) error {
+func (this *Events) deployEventForImport(ctx context.Context, token auth.AuthToken, label string, owner string, deploymentId string, event *models.MessageEvent) error {
 	if !this.DeviceGroupsAndImportsEnabled() {
 		return nil
 	}
@@ -533,16 +594,16 @@ func (this *Events) deployEventForImport(token auth.AuthToken, label string, own
 
 		//find cast extensions
 		if event.Selection.FilterCriteria.FunctionId != nil {
-			function, err, code := this.devices.GetFunction(*event.Selection.FilterCriteria.FunctionId)
+			function, err := this.devices.GetFunction(ctx, *event.Selection.FilterCriteria.FunctionId)
 			if err != nil {
-				if code != http.StatusNotFound {
+				if !errors.Is(err, errs.ErrNotFound) {
 					//ignore not found errors to prevent unresolvable kafka consumption loop
 					return err
 				}
 			} else if function.ConceptId != "" {
-				concept, err, code := this.devices.GetConcept(function.ConceptId)
+				concept, err := this.devices.GetConcept(ctx, function.ConceptId)
 				if err != nil {
-					if code != http.StatusNotFound {
+					if !errors.Is(err, errs.ErrNotFound) {
 						//ignore not found errors to prevent unresolvable kafka consumption loop
 						return err
 					}
@@ -552,7 +613,7 @@ func (this *Events) deployEventForImport(token auth.AuthToken, label string, own
 			}
 		}
 	}
-	return this.deployEventForImportWithDescription(token, label, owner, model.GroupEventDescription{
+	return this.deployEventForImportWithDescription(ctx, token, label, owner, model.GroupEventDescription{
 		ImportId:      *event.Selection.SelectedImportId,
 		EventId:       event.EventId,
 		DeploymentId:  deploymentId,
@@ -564,7 +625,7 @@ func (this *Events) deployEventForImport(token auth.AuthToken, label string, own
 	}, castFrom, *event.Selection.FilterCriteria.CharacteristicId, castExtensions)
 }
 
-func (this *Events) deployEventForGenericSource(token auth.AuthToken, label string, owner string, deploymentId string, event *models.MessageEvent) error {
+func (this *Events) deployEventForGenericSource(ctx context.Context, token auth.AuthToken, label string, owner string, deploymentId string, event *models.MessageEvent) error {
 	if event == nil {
 		debug.PrintStack()
 		return errors.New("missing event element") //programming error -> dont ignore
@@ -599,16 +660,16 @@ func (this *Events) deployEventForGenericSource(token auth.AuthToken, label stri
 
 		//find cast extensions
 		if event.Selection.FilterCriteria.FunctionId != nil {
-			function, err, code := this.devices.GetFunction(*event.Selection.FilterCriteria.FunctionId)
+			function, err := this.devices.GetFunction(ctx, *event.Selection.FilterCriteria.FunctionId)
 			if err != nil {
-				if code != http.StatusNotFound {
+				if !errors.Is(err, errs.ErrNotFound) {
 					//ignore not found errors to prevent unresolvable kafka consumption loop
 					return err
 				}
 			} else if function.ConceptId != "" {
-				concept, err, code := this.devices.GetConcept(function.ConceptId)
+				concept, err := this.devices.GetConcept(ctx, function.ConceptId)
 				if err != nil {
-					if code != http.StatusNotFound {
+					if !errors.Is(err, errs.ErrNotFound) {
 						//ignore not found errors to prevent unresolvable kafka consumption loop
 						return err
 					}
@@ -618,7 +679,7 @@ func (this *Events) deployEventForGenericSource(token auth.AuthToken, label stri
 			}
 		}
 	}
-	return this.deployEventForGenericSourceWithDescription(token, label, owner, model.GroupEventDescription{
+	return this.deployEventForGenericSourceWithDescription(ctx, token, label, owner, model.GroupEventDescription{
 		GenericEventSource: event.Selection.SelectedGenericEventSource,
 		EventId:            event.EventId,
 		DeploymentId:       deploymentId,
@@ -630,7 +691,7 @@ func (this *Events) deployEventForGenericSource(token auth.AuthToken, label stri
 	}, castFrom, *event.Selection.FilterCriteria.CharacteristicId, castExtensions)
 }
 
-func (this *Events) deployEventForImportWithDescription(token auth.AuthToken, label string, owner string, desc model.GroupEventDescription, castFrom string, castTo string, castExtensions []model.ConverterExtension) error {
+func (this *Events) deployEventForImportWithDescription(ctx context.Context, token auth.AuthToken, label string, owner string, desc model.GroupEventDescription, castFrom string, castTo string, castExtensions []model.ConverterExtension) error {
 	if !this.DeviceGroupsAndImportsEnabled() {
 		return nil
 	}
@@ -645,11 +706,12 @@ func (this *Events) deployEventForImportWithDescription(token auth.AuthToken, la
 	if desc.Path == "" {
 		return errors.New("missing path") //programming error -> dont ignore
 	}
-	topic, err, _ := this.imports.GetTopic(owner, desc.ImportId)
+	topic, err := this.imports.GetTopic(ctx, owner, desc.ImportId)
 	if err != nil {
 		return err
 	}
 	pipelineId, err := this.analytics.DeployImport(
+		ctx,
 		token,
 		label,
 		owner,
@@ -669,7 +731,7 @@ func (this *Events) deployEventForImportWithDescription(token auth.AuthToken, la
 	return nil
 }
 
-func (this *Events) deployEventForGenericSourceWithDescription(token auth.AuthToken, label string, owner string, desc model.GroupEventDescription, castFrom string, castTo string, castExtensions []model.ConverterExtension) error {
+func (this *Events) deployEventForGenericSourceWithDescription(ctx context.Context, token auth.AuthToken, label string, owner string, desc model.GroupEventDescription, castFrom string, castTo string, castExtensions []model.ConverterExtension) error {
 	if !this.DeviceGroupsAndImportsEnabled() {
 		return nil
 	}
@@ -685,6 +747,7 @@ func (this *Events) deployEventForGenericSourceWithDescription(token auth.AuthTo
 		return errors.New("missing path") //programming error -> dont ignore
 	}
 	pipelineId, err := this.analytics.DeployGenericSource(
+		ctx,
 		token,
 		label,
 		owner,
@@ -703,7 +766,7 @@ func (this *Events) deployEventForGenericSourceWithDescription(token auth.AuthTo
 	return nil
 }
 
-func (this *Events) deployEventForDeviceWithoutService(token auth.AuthToken, label string, owner string, deploymentId string, event *models.MessageEvent) error {
+func (this *Events) deployEventForDeviceWithoutService(ctx context.Context, token auth.AuthToken, label string, owner string, deploymentId string, event *models.MessageEvent, additionalCriteria []model.FilterCriteria) error {
 	if !this.DeviceGroupsAndImportsEnabled() {
 		log.Println("WARNING: DeviceGroupsAndImportsEnabled() = false; configure AuthClientId, AuthClientSecret, AuthEndpoint, PermSearchUrl")
 		return nil
@@ -729,15 +792,16 @@ func (this *Events) deployEventForDeviceWithoutService(token auth.AuthToken, lab
 		characteristicId = *event.Selection.FilterCriteria.CharacteristicId
 	}
 
-	return this.deployEventForDeviceGroupWithDescription(token, label, owner, model.GroupEventDescription{
-		DeviceIds:        []string{*event.Selection.SelectedDeviceId},
-		EventId:          event.EventId,
-		DeploymentId:     deploymentId,
-		CharacteristicId: characteristicId,
-		FunctionId:       *event.Selection.FilterCriteria.FunctionId,
-		AspectId:         *event.Selection.FilterCriteria.AspectId,
-		FlowId:           event.FlowId,
-		OperatorValue:    event.Value,
-		UseMarshaller:    event.UseMarshaller,
+	return this.deployEventForDeviceGroupWithDescription(ctx, token, label, owner, model.GroupEventDescription{
+		DeviceIds:                []string{*event.Selection.SelectedDeviceId},
+		EventId:                  event.EventId,
+		DeploymentId:             deploymentId,
+		CharacteristicId:         characteristicId,
+		FunctionId:               *event.Selection.FilterCriteria.FunctionId,
+		AspectId:                 *event.Selection.FilterCriteria.AspectId,
+		FlowId:                   event.FlowId,
+		OperatorValue:            event.Value,
+		UseMarshaller:            event.UseMarshaller,
+		AdditionalFilterCriteria: additionalCriteria,
 	})
 }
@@ -16,7 +16,17 @@
 
 package analyticsevents
 
-func (this *Events) UpdateDeviceGroup(groupId string) error {
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+)
+
+func (this *Events) UpdateDeviceGroup(ctx context.Context, groupId string, group *model.DeviceGroup) error {
+	//legacy analytics events dont support device-group updates
+	return nil
+}
+
+func (this *Events) RemoveDeviceGroup(ctx context.Context, groupId string) error {
 	//legacy analytics events dont support device-group updates
 	return nil
 }
@@ -17,37 +17,53 @@
 package analyticsevents
 
 import (
+	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"log"
 )
 
-func (this *Events) getDeviceGroupPathOptions(desc model.GroupEventDescription, deviceTypeIds []string) (result map[string][]model.PathOptionsResultElement, err error) {
+// getDeviceGroupPathOptions returns the path options of all services matching desc.FunctionId and desc.AspectId
+// or one of desc.AdditionalFilterCriteria, grouped by device-type
+func (this *Events) getDeviceGroupPathOptions(ctx context.Context, desc model.GroupEventDescription, deviceTypeIds []string) (result map[string][]model.PathOptionsResultElement, err error) {
 	result = map[string][]model.PathOptionsResultElement{}
-	selectables, err, _ := this.devices.GetDeviceTypeSelectables([]model.FilterCriteria{{
+	criteriaList := append([]model.FilterCriteria{{
 		FunctionId: desc.FunctionId,
 		AspectId:   desc.AspectId,
-	}})
-	if err != nil {
-		return result, err
-	}
-	for _, dtId := range deviceTypeIds {
-		for _, selectable := range selectables {
-			if selectable.DeviceTypeId == dtId {
-				for sid, options := range selectable.ServicePathOptions {
-					temp := model.PathOptionsResultElement{
-						ServiceId:              sid,
-						JsonPath:               []string{},
-						PathToCharacteristicId: map[string]string{},
-					}
-					for _, option := range options {
-						if option.ServiceId == sid {
-							temp.JsonPath = append(temp.JsonPath, option.Path)
-							temp.PathToCharacteristicId[option.Path] = option.CharacteristicId
-						} else {
-							log.Println("WARNING: unexpected service id in ServicePathOptions")
+	}}, desc.AdditionalFilterCriteria...)
+	optionIndex := map[string]int{} //dtId + service id -> index in result[dtId]
+	for _, criteria := range criteriaList {
+		//criteria are requested one by one to get the union of the matching services
+		selectables, err := this.devices.GetDeviceTypeSelectables(ctx, []model.FilterCriteria{criteria})
+		if err != nil {
+			return result, err
+		}
+		for _, dtId := range deviceTypeIds {
+			for _, selectable := range selectables {
+				if selectable.DeviceTypeId == dtId {
+					for sid, options := range selectable.ServicePathOptions {
+						index, ok := optionIndex[dtId+"."+sid]
+						if !ok {
+							index = len(result[dtId])
+							optionIndex[dtId+"."+sid] = index
+							result[dtId] = append(result[dtId], model.PathOptionsResultElement{
+								ServiceId:              sid,
+								JsonPath:               []string{},
+								PathToCharacteristicId: map[string]string{},
+							})
+						}
+						temp := result[dtId][index]
+						for _, option := range options {
+							if option.ServiceId == sid {
+								if _, known := temp.PathToCharacteristicId[option.Path]; !known {
+									temp.JsonPath = append(temp.JsonPath, option.Path)
+								}
+								temp.PathToCharacteristicId[option.Path] = option.CharacteristicId
+							} else {
+								log.Println("WARNING: unexpected service id in ServicePathOptions")
+							}
 						}
+						result[dtId][index] = temp
 					}
-					result[dtId] = append(result[dtId], temp)
 				}
 			}
 		}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package analyticsevents
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"log"
+	"slices"
+	"sort"
+	"time"
+)
+
+// RefreshPipelineUserTokens replaces userToken configs of the owners event pipelines, if they expire within buffer
+func (this *Events) RefreshPipelineUserTokens(ctx context.Context, owner string, buffer time.Duration) (err error) {
+	pipelineIds, err := this.analytics.GetPipelinesWithExpiredUserToken(ctx, owner, buffer)
+	if err != nil {
+		return err
+	}
+	if len(pipelineIds) == 0 {
+		return nil
+	}
+	token, err := this.auth.GetUserToken(ctx, owner)
+	if errors.Is(err, auth.ErrUserDoesNotExist) {
+		log.Printf("WARNING: user %v does not exist -> pipeline tokens will not be refreshed\n", owner)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, pipelineId := range pipelineIds {
+		log.Println("refresh user token of pipeline", owner, pipelineId)
+		err = this.analytics.UpdatePipelineUserToken(ctx, token, owner, pipelineId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshPipelineUserTokens checks the pipelines of every owner in the OwnerRepository and of every user with a token requested since the start.
+// without OwnerRepository, pipelines of other users are refreshed on their next deployment.
+func (this *Events) refreshPipelineUserTokens(ctx context.Context, buffer time.Duration) error {
+	owners, err := this.getPipelineOwners(ctx)
+	if err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		err := this.RefreshPipelineUserTokens(ctx, owner, buffer)
+		if errors.Is(err, auth.ErrAuthUnavailable) {
+			return err
+		}
+		if err != nil {
+			log.Println("ERROR: unable to refresh pipeline user tokens", owner, err)
+		}
+	}
+	return nil
+}
+
+func (this *Events) getPipelineOwners(ctx context.Context) (result []string, err error) {
+	if this.owners != nil {
+		result, err = this.owners.ListPipelineOwners(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if credentials := this.auth.Credentials(); credentials != nil {
+		result = append(result, credentials.Users()...)
+	}
+	sort.Strings(result)
+	return slices.Compact(result), nil
+}
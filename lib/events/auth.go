@@ -17,52 +17,23 @@
 package events
 
 import (
-	"errors"
-	"github.com/golang-jwt/jwt"
-	"strings"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
 )
 
-type Claims struct {
-	Sub         string              `json:"sub,omitempty"`
-	RealmAccess map[string][]string `json:"realm_access,omitempty"`
-}
-
-func (this *Claims) Valid() error {
-	if this.Sub == "" {
-		return errors.New("missing subject")
-	}
-	return nil
-}
-
-func parse(token string) (claims Claims, err error) {
-	if len(token) > 7 && strings.ToLower(token[:7]) == "bearer " {
-		token = token[7:]
-	}
-	_, _, err = new(jwt.Parser).ParseUnverified(token, &claims)
-	return
-}
+// tokens are verified by the api; here only the claims are read
 
 func IsAdmin(token string) bool {
-	claims, err := parse(token)
+	claims, err := auth.ParseUnverified(token)
 	if err != nil {
-		panic(err)
+		return false
 	}
-	return contains(claims.RealmAccess["roles"], "admin")
+	return claims.IsAdmin()
 }
 
 func GetUserId(token string) (userId string, err error) {
-	claims, err := parse(token)
+	claims, err := auth.ParseUnverified(token)
 	if err != nil {
 		return userId, err
 	}
 	return claims.Sub, err
 }
-
-func contains(s []string, e string) bool {
-	for _, a := range s {
-		if a == e {
-			return true
-		}
-	}
-	return false
-}
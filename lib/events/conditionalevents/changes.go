@@ -0,0 +1,110 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conditionalevents
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// changePublisher publishes every change of the wrapped repository as model.EventDescChange.
+// changes are published after the repository write; a failed publish is returned, so that the command is retried.
+type changePublisher struct {
+	EventDescriptionRepository
+	producer interfaces.Producer
+}
+
+func newChangePublisher(repo EventDescriptionRepository, producer interfaces.Producer) EventDescriptionRepository {
+	return &changePublisher{EventDescriptionRepository: repo, producer: producer}
+}
+
+func (this *changePublisher) ReplaceEventDescriptions(ctx context.Context, deploymentId string, descriptions []model.EventDesc) (removed int64, err error) {
+	before, err := this.GetEventDescriptionsByDeploymentId(ctx, deploymentId)
+	if err != nil {
+		return 0, err
+	}
+	removed, err = this.EventDescriptionRepository.ReplaceEventDescriptions(ctx, deploymentId, descriptions)
+	if err != nil {
+		return removed, err
+	}
+	return removed, this.publish(ctx, getEventDescChanges(deploymentId, before, descriptions, config.TimeNow()))
+}
+
+func (this *changePublisher) RemoveEventDescriptionsByDeploymentId(ctx context.Context, deploymentId string) (count int64, err error) {
+	before, err := this.GetEventDescriptionsByDeploymentId(ctx, deploymentId)
+	if err != nil {
+		return 0, err
+	}
+	count, err = this.EventDescriptionRepository.RemoveEventDescriptionsByDeploymentId(ctx, deploymentId)
+	if err != nil {
+		return count, err
+	}
+	return count, this.publish(ctx, getEventDescChanges(deploymentId, before, nil, config.TimeNow()))
+}
+
+func (this *changePublisher) publish(ctx context.Context, changes []model.EventDescChange) error {
+	for _, change := range changes {
+		msg, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		err = this.producer.Produce(ctx, change.Key(), msg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getEventDescChanges compares the descriptions of a deployment before and after a change;
+// descriptions are identified by event, device, service, device-group, import, path, function and aspect,
+// because a service matching several filter criteria has a description per criteria. unchanged descriptions are omitted.
+func getEventDescChanges(deploymentId string, before []model.EventDesc, after []model.EventDesc, now time.Time) (result []model.EventDescChange) {
+	previous := map[string]model.EventDesc{}
+	for _, desc := range before {
+		previous[getEventDescIdentity(desc)] = desc
+	}
+	current := map[string]bool{}
+	for _, desc := range after {
+		current[getEventDescIdentity(desc)] = true
+	}
+	for _, desc := range before {
+		if !current[getEventDescIdentity(desc)] {
+			result = append(result, model.EventDescChange{Type: model.EventDescChangeDeleted, Time: now, DeploymentId: deploymentId, Description: &desc})
+		}
+	}
+	for _, desc := range after {
+		old, exists := previous[getEventDescIdentity(desc)]
+		switch {
+		case !exists:
+			result = append(result, model.EventDescChange{Type: model.EventDescChangeCreated, Time: now, DeploymentId: deploymentId, Description: &desc})
+		case !reflect.DeepEqual(old, desc):
+			result = append(result, model.EventDescChange{Type: model.EventDescChangeUpdated, Time: now, DeploymentId: deploymentId, Description: &desc})
+		}
+	}
+	return result
+}
+
+func getEventDescIdentity(desc model.EventDesc) string {
+	return strings.Join([]string{desc.EventId, desc.DeviceId, desc.ServiceId, desc.DeviceGroupId, desc.ImportId, desc.Path, desc.FunctionId, desc.AspectId}, "\n")
+}
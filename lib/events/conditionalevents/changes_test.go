@@ -0,0 +1,107 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conditionalevents
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/memory"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type producerMock struct {
+	mux      sync.Mutex
+	keys     []string
+	messages []model.EventDescChange
+}
+
+func (this *producerMock) Produce(ctx context.Context, key string, message []byte) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	change := model.EventDescChange{}
+	err := json.Unmarshal(message, &change)
+	if err != nil {
+		return err
+	}
+	this.keys = append(this.keys, key)
+	this.messages = append(this.messages, change)
+	return nil
+}
+
+func TestGetEventDescChanges(t *testing.T) {
+	now := time.Now()
+	before := []model.EventDesc{
+		{DeploymentId: "dep", EventId: "e1", DeviceId: "d1", ServiceId: "s1", Script: "x == 1"},
+		{DeploymentId: "dep", EventId: "e1", DeviceId: "d2", ServiceId: "s1", Script: "x == 1"},
+		{DeploymentId: "dep", EventId: "e2", ImportId: "i1", Path: "value", Script: "x == 2"},
+	}
+	after := []model.EventDesc{
+		{DeploymentId: "dep", EventId: "e1", DeviceId: "d1", ServiceId: "s1", Script: "x == 1"},
+		{DeploymentId: "dep", EventId: "e2", ImportId: "i1", Path: "value", Script: "x == 3"},
+		{DeploymentId: "dep", EventId: "e1", DeviceId: "d3", ServiceId: "s1", Script: "x == 1"},
+	}
+	actual := []string{}
+	for _, change := range getEventDescChanges("dep", before, after, now) {
+		if change.DeploymentId != "dep" || !change.Time.Equal(now) {
+			t.Error(change)
+		}
+		actual = append(actual, change.Type+" "+change.Key())
+	}
+	expected := []string{"deleted d2/s1", "updated i1/value", "created d3/s1"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error(actual, expected)
+	}
+}
+
+func TestChangePublisher(t *testing.T) {
+	ctx := context.Background()
+	producer := &producerMock{}
+	repo := newChangePublisher(memory.New(), producer)
+
+	_, err := repo.ReplaceEventDescriptions(ctx, "dep", []model.EventDesc{{DeploymentId: "dep", EventId: "e1", DeviceId: "d1", ServiceId: "s1"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = repo.ReplaceEventDescriptions(ctx, "dep", []model.EventDesc{{DeploymentId: "dep", EventId: "e1", DeviceId: "d1", ServiceId: "s1"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = repo.RemoveEventDescriptionsByDeploymentId(ctx, "dep")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	types := []string{}
+	for _, change := range producer.messages {
+		types = append(types, change.Type)
+	}
+	if !reflect.DeepEqual(types, []string{model.EventDescChangeCreated, model.EventDescChangeDeleted}) {
+		t.Error(types)
+	}
+	if !reflect.DeepEqual(producer.keys, []string{"d1/s1", "d1/s1"}) {
+		t.Error(producer.keys)
+	}
+	if producer.messages[1].Description == nil || producer.messages[1].Description.EventId != "e1" {
+		t.Error(producer.messages[1])
+	}
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package deployments
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"go.mongodb.org/mongo-driver/mongo"
+	"runtime/debug"
+)
+
+func init() {
+	CreateCollections = append(CreateCollections, func(db *Deployments) error {
+		if db.config.ConditionalEventRepoMongoAuditCollection == "" {
+			return nil
+		}
+		err := db.ensureCompoundIndex(db.auditCollection(), "audit_deployment_time_index", true, false, "deployment_id", "time")
+		if err != nil {
+			debug.PrintStack()
+			return err
+		}
+		return nil
+	})
+}
+
+func (this *Deployments) auditCollection() *mongo.Collection {
+	return this.client.Database(this.config.ConditionalEventRepoMongoTable).Collection(this.config.ConditionalEventRepoMongoAuditCollection)
+}
+
+// AddAuditEntry only inserts; audit entries are never updated or removed by this service
+func (this *Deployments) AddAuditEntry(ctx context.Context, entry model.AuditEntry) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.auditCollection().InsertOne(ctx, entry)
+	return err
+}
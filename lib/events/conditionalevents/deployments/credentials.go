@@ -0,0 +1,78 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deployments
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/credentials"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"runtime/debug"
+)
+
+func init() {
+	CreateCollections = append(CreateCollections, func(db *Deployments) error {
+		if db.config.ConditionalEventRepoMongoCredentialsCollection == "" {
+			return nil
+		}
+		collection := db.credentialsCollection()
+		err := db.ensureIndex(collection, "credential_key_hash_index", "key_hash", true, true)
+		if err != nil {
+			debug.PrintStack()
+			return err
+		}
+		//earlier versions allowed only one credential per event
+		err = db.dropIndex(collection, "credential_event_index")
+		if err != nil {
+			debug.PrintStack()
+			return err
+		}
+		return nil
+	})
+}
+
+func (this *Deployments) credentialsCollection() *mongo.Collection {
+	return this.client.Database(this.config.ConditionalEventRepoMongoTable).Collection(this.config.ConditionalEventRepoMongoCredentialsCollection)
+}
+
+func (this *Deployments) AddCredential(ctx context.Context, credential credentials.Credential) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.credentialsCollection().InsertOne(ctx, credential)
+	return err
+}
+
+func (this *Deployments) GetCredential(ctx context.Context, keyHash string) (credential credentials.Credential, exists bool, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	err = this.credentialsCollection().FindOne(ctx, bson.M{"key_hash": keyHash}).Decode(&credential)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return credential, false, nil
+	}
+	if err != nil {
+		return credential, false, err
+	}
+	return credential, true, nil
+}
+
+func (this *Deployments) RemoveCredential(ctx context.Context, id string) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.credentialsCollection().DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
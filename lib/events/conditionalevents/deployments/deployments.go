@@ -17,6 +17,7 @@
 package deployments
 
 import (
+	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"github.com/SENERGY-Platform/models/go/models"
 	"go.mongodb.org/mongo-driver/bson"
@@ -26,10 +27,15 @@ import (
 )
 
 type DeploymentIndex struct {
-	Deployment   models.Deployment `json:"deployment" bson:"deployment"`
-	UserId       string            `json:"user_id" bson:"user_id"`
-	DeviceGroups []string          `json:"device_groups" bson:"device_groups"`
-	Id           string            `json:"id" bson:"id"`
+	Deployment     models.Deployment                 `json:"deployment" bson:"deployment"`
+	FilterCriteria map[string][]model.FilterCriteria `json:"filter_criteria,omitempty" bson:"filter_criteria,omitempty"`
+	UserId         string                            `json:"user_id" bson:"user_id"`
+	DeviceGroups   []string                          `json:"device_groups" bson:"device_groups"`
+	Id             string                            `json:"id" bson:"id"`
+
+	//broken deployments reference a deleted device-group; their events are removed until the deployment is replaced
+	Broken       bool   `json:"broken,omitempty" bson:"broken,omitempty"`
+	BrokenReason string `json:"broken_reason,omitempty" bson:"broken_reason,omitempty"`
 }
 
 type Deployment = model.Deployment
@@ -56,12 +62,14 @@ func (this *Deployments) deploymentsCollection() *mongo.Collection {
 	return this.client.Database(this.config.ConditionalEventRepoMongoTable).Collection(this.config.ConditionalEventRepoMongoDeploymentsCollection)
 }
 
-func (this *Deployments) GetDeploymentByDeviceGroupId(deviceGroupId string) (result []Deployment, err error) {
+// GetDeploymentByDeviceGroupId ignores broken deployments
+func (this *Deployments) GetDeploymentByDeviceGroupId(ctx context.Context, deviceGroupId string) (result []Deployment, err error) {
 	if deviceGroupId == "" {
 		return []Deployment{}, nil
 	}
-	ctx, _ := this.getTimeoutContext()
-	cursor, err := this.deploymentsCollection().Find(ctx, bson.M{"device_groups": deviceGroupId})
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	cursor, err := this.deploymentsCollection().Find(ctx, bson.M{"device_groups": deviceGroupId, "broken": bson.M{"$ne": true}})
 	if err != nil {
 		return result, err
 	}
@@ -71,37 +79,83 @@ func (this *Deployments) GetDeploymentByDeviceGroupId(deviceGroupId string) (res
 	}
 	for _, e := range temp {
 		result = append(result, Deployment{
-			Deployment: e.Deployment,
-			UserId:     e.UserId,
+			Deployment:     e.Deployment,
+			UserId:         e.UserId,
+			FilterCriteria: e.FilterCriteria,
 		})
 	}
 	return result, err
 }
 
-func (this *Deployments) RemoveDeployment(deploymentId string) (err error) {
-	ctx, _ := this.getTimeoutContext()
+func (this *Deployments) MarkDeploymentBroken(ctx context.Context, deploymentId string, reason string) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.deploymentsCollection().UpdateOne(ctx, bson.M{"id": deploymentId}, bson.M{"$set": bson.M{"broken": true, "broken_reason": reason}})
+	return err
+}
+
+func (this *Deployments) RemoveDeployment(ctx context.Context, deploymentId string) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
 	_, err = this.deploymentsCollection().DeleteMany(ctx, bson.M{"id": deploymentId})
 	return err
 }
 
-func (this *Deployments) SetDeployment(element Deployment) (err error) {
-	ctx, _ := this.getTimeoutContext()
+func (this *Deployments) ListDeploymentIds(ctx context.Context) (result []string, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	return distinctStrings(ctx, this.deploymentsCollection(), "id")
+}
+
+// ListEventDescriptionDeploymentIds returns the ids of all deployments with descriptions in the event-worker repository
+func (this *Deployments) ListEventDescriptionDeploymentIds(ctx context.Context) (result []string, err error) {
+	deploymentIdField, err := getBsonFieldName(model.EventDesc{}, "DeploymentId")
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	return distinctStrings(ctx, this.descriptionsCollection(), deploymentIdField)
+}
+
+func distinctStrings(ctx context.Context, collection *mongo.Collection, field string) (result []string, err error) {
+	values, err := collection.Distinct(ctx, field, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	result = []string{}
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result, nil
+}
+
+func (this *Deployments) SetDeployment(ctx context.Context, element Deployment) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
 	_, err = this.deploymentsCollection().ReplaceOne(ctx, bson.M{"id": element.Id}, getDeploymentIndex(element), options.Replace().SetUpsert(true))
 	return err
 }
 
 func getDeploymentIndex(depl Deployment) (result DeploymentIndex) {
-	result = DeploymentIndex{
-		Id:           depl.Id,
-		UserId:       depl.UserId,
-		Deployment:   depl.Deployment,
-		DeviceGroups: nil,
+	return DeploymentIndex{
+		Id:             depl.Id,
+		UserId:         depl.UserId,
+		Deployment:     depl.Deployment,
+		FilterCriteria: depl.FilterCriteria,
+		DeviceGroups:   GetDeviceGroupIds(depl),
 	}
+}
+
+// GetDeviceGroupIds returns the device-groups selected by conditional events of the deployment
+func GetDeviceGroupIds(depl Deployment) (result []string) {
 	for _, element := range depl.Elements {
 		if element.ConditionalEvent != nil &&
 			element.ConditionalEvent.Selection.SelectedDeviceGroupId != nil &&
 			*element.ConditionalEvent.Selection.SelectedDeviceGroupId != "" {
-			result.DeviceGroups = append(result.DeviceGroups, *element.ConditionalEvent.Selection.SelectedDeviceGroupId)
+			result = append(result, *element.ConditionalEvent.Selection.SelectedDeviceGroupId)
 		}
 	}
 	return result
@@ -22,10 +22,12 @@ import (
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"github.com/SENERGY-Platform/event-deployment/lib/tests/docker"
 	"github.com/SENERGY-Platform/models/go/models"
+	"go.mongodb.org/mongo-driver/bson"
 	"reflect"
 	"sort"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestDeployments(t *testing.T) {
@@ -55,7 +57,7 @@ func TestDeployments(t *testing.T) {
 	}
 
 	deploy := func(d models.Deployment) error {
-		return deployments.SetDeployment(model.Deployment{
+		return deployments.SetDeployment(ctx, model.Deployment{
 			Deployment: d,
 			UserId:     "userid",
 		})
@@ -138,7 +140,7 @@ func TestDeployments(t *testing.T) {
 	})
 
 	t.Run("remove", func(t *testing.T) {
-		err = deployments.RemoveDeployment("deleted")
+		err = deployments.RemoveDeployment(ctx, "deleted")
 		if err != nil {
 			t.Error(err)
 			return
@@ -146,7 +148,7 @@ func TestDeployments(t *testing.T) {
 	})
 
 	t.Run("read g1", func(t *testing.T) {
-		result, err := deployments.GetDeploymentByDeviceGroupId("g1")
+		result, err := deployments.GetDeploymentByDeviceGroupId(ctx, "g1")
 		if err != nil {
 			t.Error(err)
 			return
@@ -165,7 +167,7 @@ func TestDeployments(t *testing.T) {
 	})
 
 	t.Run("read g2", func(t *testing.T) {
-		result, err := deployments.GetDeploymentByDeviceGroupId("g2")
+		result, err := deployments.GetDeploymentByDeviceGroupId(ctx, "g2")
 		if err != nil {
 			t.Error(err)
 			return
@@ -183,8 +185,296 @@ func TestDeployments(t *testing.T) {
 		}
 	})
 
+	t.Run("broken deployments are not updated", func(t *testing.T) {
+		err = deployments.MarkDeploymentBroken(ctx, "second", "device-group g2 deleted")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		result, err := deployments.GetDeploymentByDeviceGroupId(ctx, "g2")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(result) != 1 || result[0].Id != "duplicate" {
+			t.Error(result)
+		}
+	})
+}
+
+func TestDeploymentHistory(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := config.LoadConfig("../../../../config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, mongoIp, err := docker.Mongo(ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ConditionalEventRepoMongoUrl = "mongodb://" + mongoIp + ":27017"
+
+	deployments, err := New(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, name := range []string{"v1", "v2", "v3"} {
+		_, err = deployments.AddDeploymentVersion(ctx, model.DeploymentVersion{
+			DeploymentId: "dep",
+			Owner:        "owner",
+			Deployment:   models.Deployment{Id: "dep", Name: name},
+			ChangedBy:    "owner",
+		}, 2)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	err = deployments.SetLatestDeploymentVersionDescriptions(ctx, "dep", []model.EventDesc{{DeploymentId: "dep", EventId: "e1"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	versions, err := deployments.ListDeploymentVersions(ctx, "dep")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(versions) != 2 || versions[0].Version != 3 || versions[1].Version != 2 || versions[1].Deployment.Name != "v2" {
+		t.Errorf("%#v", versions)
+		return
+	}
+	if len(versions[0].Descriptions) != 1 || len(versions[1].Descriptions) != 0 {
+		t.Errorf("%#v", versions)
+		return
+	}
+	_, exists, err := deployments.GetDeploymentVersion(ctx, "dep", 1)
+	if err != nil || exists {
+		t.Error(exists, err)
+	}
+
+	t.Run("concurrent versions", func(t *testing.T) {
+		addWg := sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			addWg.Add(1)
+			go func() {
+				defer addWg.Done()
+				_, err := deployments.AddDeploymentVersion(ctx, model.DeploymentVersion{DeploymentId: "concurrent", Owner: "owner", ChangedBy: "owner"}, 0)
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		addWg.Wait()
+		versions, err := deployments.ListDeploymentVersions(ctx, "concurrent")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(versions) != 4 || versions[0].Version != 4 {
+			t.Errorf("%#v", versions)
+		}
+	})
 }
 
 func ptr(s string) *string {
 	return &s
 }
+
+func TestReplaceEventDescriptions(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := config.LoadConfig("../../../../config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, mongoIp, err := docker.Mongo(ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ConditionalEventRepoMongoUrl = "mongodb://" + mongoIp + ":27017"
+
+	deployments, err := New(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	list := func() (result []string) {
+		cursor, err := deployments.descriptionsCollection().Find(ctx, bson.M{})
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+		descriptions, err, _ := readCursorResult[model.EventDesc](ctx, cursor)
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+		for _, desc := range descriptions {
+			result = append(result, desc.DeploymentId+"."+desc.EventId)
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	removed, err := deployments.ReplaceEventDescriptions(ctx, "dep1", []model.EventDesc{{DeploymentId: "dep1", EventId: "e1"}, {DeploymentId: "dep1", EventId: "e2"}})
+	if err != nil || removed != 0 {
+		t.Error(removed, err)
+		return
+	}
+	_, err = deployments.ReplaceEventDescriptions(ctx, "dep2", []model.EventDesc{{DeploymentId: "dep2", EventId: "e1"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	removed, err = deployments.ReplaceEventDescriptions(ctx, "dep1", []model.EventDesc{{DeploymentId: "dep1", EventId: "e3"}})
+	if err != nil || removed != 2 {
+		t.Error(removed, err)
+		return
+	}
+	if actual := list(); !reflect.DeepEqual(actual, []string{"dep1.e3", "dep2.e1"}) {
+		t.Error(actual)
+	}
+	removed, err = deployments.ReplaceEventDescriptions(ctx, "dep2", nil)
+	if err != nil || removed != 1 {
+		t.Error(removed, err)
+		return
+	}
+	if actual := list(); !reflect.DeepEqual(actual, []string{"dep1.e3"}) {
+		t.Error(actual)
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := config.LoadConfig("../../../../config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, mongoIp, err := docker.Mongo(ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ConditionalEventRepoMongoUrl = "mongodb://" + mongoIp + ":27017"
+	config.ConditionalEventRepoMongoOutboxCollection = "outbox"
+
+	deployments, err := New(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	now := time.Now().Truncate(time.Millisecond)
+	for i, id := range []string{"b", "a", "c"} {
+		err = deployments.AddOutboxEntry(ctx, model.OutboxEntry{Id: id, Topic: "topic", Key: id, Message: []byte(id), Created: now.Add(time.Duration(i) * time.Second)})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	err = deployments.SetOutboxEntryError(ctx, "a", "test error", true)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = deployments.RemoveOutboxEntry(ctx, "b")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	entries, err := deployments.ListOutboxEntries(ctx, 10)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(entries) != 2 || entries[0].Id != "a" || entries[1].Id != "c" || string(entries[0].Message) != "a" {
+		t.Errorf("%#v", entries)
+		return
+	}
+	if entries[0].Attempts != 1 || entries[0].PermanentErrors != 1 || entries[0].LastAttempt.IsZero() || entries[0].LastError != "test error" {
+		t.Errorf("%#v", entries[0])
+	}
+	entries, err = deployments.ListOutboxEntries(ctx, 1)
+	if err != nil || len(entries) != 1 || entries[0].Id != "a" {
+		t.Error(err, entries)
+	}
+	err = deployments.ParkOutboxEntry(ctx, "a", "test error")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	entries, err = deployments.ListOutboxEntries(ctx, 10)
+	if err != nil || len(entries) != 1 || entries[0].Id != "c" {
+		t.Error("parked entries should not be listed", err, entries)
+	}
+	count, err := deployments.RequeueParkedOutboxEntries(ctx)
+	if err != nil || count != 1 {
+		t.Error(err, count)
+		return
+	}
+	entries, err = deployments.ListOutboxEntries(ctx, 10)
+	if err != nil || len(entries) != 2 || entries[0].Id != "a" || entries[0].Attempts != 0 || entries[0].Parked || entries[0].LastError != "test error" {
+		t.Error("requeued entries should be listed in their order with reset attempts", err, entries)
+	}
+}
+
+func TestPipelineOwners(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := config.LoadConfig("../../../../config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, mongoIp, err := docker.Mongo(ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ConditionalEventRepoMongoUrl = "mongodb://" + mongoIp + ":27017"
+
+	deployments, err := New(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, owner := range []string{"b", "a", "b"} {
+		err = deployments.AddPipelineOwner(ctx, owner)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	owners, err := deployments.ListPipelineOwners(ctx)
+	if err != nil || !reflect.DeepEqual(owners, []string{"a", "b"}) {
+		t.Error(err, owners)
+	}
+}
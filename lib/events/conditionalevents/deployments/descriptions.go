@@ -0,0 +1,136 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deployments
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+)
+
+// descriptionsCollection is the collection of the event-worker repository
+func (this *Deployments) descriptionsCollection() *mongo.Collection {
+	return this.client.Database(this.config.ConditionalEventRepoMongoTable).Collection(this.config.ConditionalEventRepoMongoDescCollection)
+}
+
+func (this *Deployments) GetEventDescriptionsByDeploymentId(ctx context.Context, deploymentId string) (result []model.EventDesc, err error) {
+	return this.findEventDescriptions(ctx, "DeploymentId", deploymentId)
+}
+
+func (this *Deployments) GetEventDescriptionsByEventId(ctx context.Context, eventId string) (result []model.EventDesc, err error) {
+	return this.findEventDescriptions(ctx, "EventId", eventId)
+}
+
+func (this *Deployments) GetEventDescriptionsByDeviceGroup(ctx context.Context, deviceGroupId string) (result []model.EventDesc, err error) {
+	return this.findEventDescriptions(ctx, "DeviceGroupId", deviceGroupId)
+}
+
+// findEventDescriptions returns the descriptions where the model.EventDesc field has the value
+func (this *Deployments) findEventDescriptions(ctx context.Context, field string, value string) (result []model.EventDesc, err error) {
+	bsonField, err := getBsonFieldName(model.EventDesc{}, field)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	cursor, err := this.descriptionsCollection().Find(ctx, bson.M{bsonField: value})
+	if err != nil {
+		return nil, err
+	}
+	result, err, _ = readCursorResult[model.EventDesc](ctx, cursor)
+	return result, err
+}
+
+func (this *Deployments) RemoveEventDescriptionsByDeploymentId(ctx context.Context, deploymentId string) (count int64, err error) {
+	deploymentIdField, err := getBsonFieldName(model.EventDesc{}, "DeploymentId")
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	result, err := this.descriptionsCollection().DeleteMany(ctx, bson.M{deploymentIdField: deploymentId})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// ReplaceEventDescriptions replaces all event descriptions of the deployment with one bulk insert.
+// with conditional_event_repo_mongo_transactions (requires a replica set) or in a transaction of Transaction, readers see either the old or the new descriptions.
+// otherwise the new descriptions are inserted before the old ones are removed: readers may briefly see both, but never none,
+// and if the insert fails, the old descriptions are kept.
+func (this *Deployments) ReplaceEventDescriptions(ctx context.Context, deploymentId string, descriptions []model.EventDesc) (removed int64, err error) {
+	deploymentIdField, err := getBsonFieldName(model.EventDesc{}, "DeploymentId")
+	if err != nil {
+		return 0, err
+	}
+	documents := make([]interface{}, 0, len(descriptions))
+	for _, desc := range descriptions {
+		documents = append(documents, desc)
+	}
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	if this.config.ConditionalEventRepoMongoTransactions || mongo.SessionFromContext(ctx) != nil {
+		return this.replaceEventDescriptionsInTransaction(ctx, deploymentIdField, deploymentId, documents)
+	}
+	filter := bson.M{deploymentIdField: deploymentId}
+	if len(documents) > 0 {
+		result, err := this.descriptionsCollection().InsertMany(ctx, documents)
+		if err != nil {
+			if result != nil && len(result.InsertedIDs) > 0 {
+				this.removeInsertedEventDescriptions(result.InsertedIDs)
+			}
+			return 0, err
+		}
+		filter["_id"] = bson.M{"$nin": result.InsertedIDs}
+	}
+	result, err := this.descriptionsCollection().DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (this *Deployments) replaceEventDescriptionsInTransaction(ctx context.Context, deploymentIdField string, deploymentId string, documents []interface{}) (removed int64, err error) {
+	err = this.Transaction(ctx, func(ctx context.Context) error {
+		result, err := this.descriptionsCollection().DeleteMany(ctx, bson.M{deploymentIdField: deploymentId})
+		if err != nil {
+			return err
+		}
+		removed = result.DeletedCount
+		if len(documents) > 0 {
+			_, err = this.descriptionsCollection().InsertMany(ctx, documents)
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// removeInsertedEventDescriptions cleans up after a failed insert; ids of documents that were not inserted are ignored by mongodb
+func (this *Deployments) removeInsertedEventDescriptions(ids []interface{}) {
+	ctx, cancel := this.getTimeoutContext()
+	defer cancel()
+	_, err := this.descriptionsCollection().DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		log.Println("ERROR: unable to remove partially inserted event descriptions", err)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deployments
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"runtime/debug"
+)
+
+func init() {
+	CreateCollections = append(CreateCollections, func(db *Deployments) error {
+		if db.config.ConditionalEventRepoMongoHistoryCollection == "" {
+			return nil
+		}
+		err := db.ensureCompoundIndex(db.historyCollection(), "history_deployment_version_index", true, true, "deployment_id", "version")
+		if err != nil {
+			debug.PrintStack()
+			return err
+		}
+		return nil
+	})
+}
+
+func (this *Deployments) historyCollection() *mongo.Collection {
+	return this.client.Database(this.config.ConditionalEventRepoMongoTable).Collection(this.config.ConditionalEventRepoMongoHistoryCollection)
+}
+
+// concurrent writers of the same deployment may read the same latest version; the loser of the insert retries with the next version
+const addDeploymentVersionAttempts = 5
+
+// AddDeploymentVersion stores element as next version of the deployment and removes versions exceeding limit (if limit > 0)
+func (this *Deployments) AddDeploymentVersion(ctx context.Context, element model.DeploymentVersion, limit int64) (result model.DeploymentVersion, err error) {
+	for i := 0; i < addDeploymentVersionAttempts; i++ {
+		result, err = this.addDeploymentVersion(ctx, element, limit)
+		//a failed insert aborts a transaction, which is retried as a whole by Transaction
+		if !mongo.IsDuplicateKeyError(err) || mongo.SessionFromContext(ctx) != nil {
+			return result, err
+		}
+	}
+	return result, err
+}
+
+func (this *Deployments) addDeploymentVersion(ctx context.Context, element model.DeploymentVersion, limit int64) (result model.DeploymentVersion, err error) {
+	latest, exists, err := this.GetLatestDeploymentVersion(ctx, element.DeploymentId)
+	if err != nil {
+		return result, err
+	}
+	element.Version = 1
+	if exists {
+		element.Version = latest.Version + 1
+	}
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.historyCollection().InsertOne(ctx, element)
+	if err != nil {
+		return result, err
+	}
+	if limit > 0 && element.Version > limit {
+		_, err = this.historyCollection().DeleteMany(ctx, bson.M{"deployment_id": element.DeploymentId, "version": bson.M{"$lte": element.Version - limit}})
+		if err != nil {
+			return result, err
+		}
+	}
+	return element, nil
+}
+
+func (this *Deployments) GetLatestDeploymentVersion(ctx context.Context, deploymentId string) (result model.DeploymentVersion, exists bool, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	err = this.historyCollection().FindOne(ctx, bson.M{"deployment_id": deploymentId}, options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return result, false, nil
+	}
+	if err != nil {
+		return result, false, err
+	}
+	return result, true, nil
+}
+
+func (this *Deployments) GetDeploymentVersion(ctx context.Context, deploymentId string, version int64) (result model.DeploymentVersion, exists bool, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	err = this.historyCollection().FindOne(ctx, bson.M{"deployment_id": deploymentId, "version": version}).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return result, false, nil
+	}
+	if err != nil {
+		return result, false, err
+	}
+	return result, true, nil
+}
+
+// ListDeploymentVersions returns the versions of the deployment, newest first
+func (this *Deployments) ListDeploymentVersions(ctx context.Context, deploymentId string) (result []model.DeploymentVersion, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	cursor, err := this.historyCollection().Find(ctx, bson.M{"deployment_id": deploymentId}, options.Find().SetSort(bson.D{{Key: "version", Value: -1}}))
+	if err != nil {
+		return result, err
+	}
+	result, err, _ = readCursorResult[model.DeploymentVersion](ctx, cursor)
+	return result, err
+}
+
+// SetLatestDeploymentVersionDescriptions replaces the descriptions of the latest version, if it is not a removal
+func (this *Deployments) SetLatestDeploymentVersionDescriptions(ctx context.Context, deploymentId string, descriptions []model.EventDesc) (err error) {
+	latest, exists, err := this.GetLatestDeploymentVersion(ctx, deploymentId)
+	if err != nil || !exists || latest.Removed {
+		return err
+	}
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.historyCollection().UpdateOne(ctx, bson.M{"deployment_id": deploymentId, "version": latest.Version}, bson.M{"$set": bson.M{"descriptions": descriptions}})
+	return err
+}
@@ -140,3 +140,14 @@ func (this *Deployments) ensureTextIndex(collection *mongo.Collection, indexname
 	})
 	return err
 }
+
+// dropIndex removes an index of earlier versions; a missing index or collection is ignored
+func (this *Deployments) dropIndex(collection *mongo.Collection, indexname string) error {
+	ctx, _ := this.getTimeoutContext()
+	_, err := collection.Indexes().DropOne(ctx, indexname)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound") {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deployments
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"runtime/debug"
+	"time"
+)
+
+type Lease struct {
+	Name      string    `json:"name" bson:"_id"`
+	Holder    string    `json:"holder" bson:"holder"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+func init() {
+	CreateCollections = append(CreateCollections, func(db *Deployments) error {
+		if db.config.ConditionalEventRepoMongoLeaseCollection == "" {
+			return nil
+		}
+		ctx, _ := db.getTimeoutContext()
+		//expired leases are removed by mongodb; the lease logic itself does not depend on the removal
+		_, err := db.leaseCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("lease_expiration_index").SetExpireAfterSeconds(0),
+		})
+		if err != nil {
+			debug.PrintStack()
+			return err
+		}
+		return nil
+	})
+}
+
+func (this *Deployments) leaseCollection() *mongo.Collection {
+	return this.client.Database(this.config.ConditionalEventRepoMongoTable).Collection(this.config.ConditionalEventRepoMongoLeaseCollection)
+}
+
+// TryAcquireLease acquires or renews the lease if it is unclaimed, expired or already held by holder.
+func (this *Deployments) TryAcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (acquired bool, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	now := config.TimeNow()
+	_, err = this.leaseCollection().UpdateOne(ctx, bson.M{
+		"_id": name,
+		"$or": []bson.M{
+			{"holder": holder},
+			{"expires_at": bson.M{"$lt": now}},
+		},
+	}, bson.M{
+		"$set": bson.M{
+			"holder":     holder,
+			"expires_at": now.Add(ttl),
+		},
+	}, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		//filter did not match an existing lease -> the upsert collides with the lease of another holder
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (this *Deployments) ReleaseLease(ctx context.Context, name string, holder string) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.leaseCollection().DeleteOne(ctx, bson.M{"_id": name, "holder": holder})
+	return err
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deployments
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"runtime/debug"
+)
+
+func init() {
+	CreateCollections = append(CreateCollections, func(db *Deployments) error {
+		if db.config.ConditionalEventRepoMongoOutboxCollection == "" {
+			return nil
+		}
+		err := db.ensureCompoundIndex(db.outboxCollection(), "outbox_created_index", true, false, "created", "_id")
+		if err != nil {
+			debug.PrintStack()
+			return err
+		}
+		return nil
+	})
+}
+
+func (this *Deployments) outboxCollection() *mongo.Collection {
+	return this.client.Database(this.config.ConditionalEventRepoMongoTable).Collection(this.config.ConditionalEventRepoMongoOutboxCollection)
+}
+
+func (this *Deployments) AddOutboxEntry(ctx context.Context, entry model.OutboxEntry) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.outboxCollection().InsertOne(ctx, entry)
+	return err
+}
+
+// ListOutboxEntries returns the oldest entries first; parked entries are not listed
+func (this *Deployments) ListOutboxEntries(ctx context.Context, limit int64) (result []model.OutboxEntry, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	cursor, err := this.outboxCollection().Find(ctx, bson.M{"parked": bson.M{"$ne": true}}, options.Find().SetSort(bson.D{{Key: "created", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	result, err, _ = readCursorResult[model.OutboxEntry](ctx, cursor)
+	return result, err
+}
+
+func (this *Deployments) RemoveOutboxEntry(ctx context.Context, id string) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.outboxCollection().DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// SetOutboxEntryError counts a failed publish attempt of the entry; permanent errors are counted separately for the attempt limit
+func (this *Deployments) SetOutboxEntryError(ctx context.Context, id string, lastError string, permanent bool) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	inc := bson.M{"attempts": 1}
+	if permanent {
+		inc["permanent_errors"] = 1
+	}
+	_, err = this.outboxCollection().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": inc, "$set": bson.M{"last_error": lastError, "last_attempt": config.TimeNow()}})
+	return err
+}
+
+// ParkOutboxEntry counts a failed publish attempt and excludes the entry from ListOutboxEntries
+func (this *Deployments) ParkOutboxEntry(ctx context.Context, id string, lastError string) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.outboxCollection().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"attempts": 1, "permanent_errors": 1}, "$set": bson.M{"last_error": lastError, "last_attempt": config.TimeNow(), "parked": true}})
+	return err
+}
+
+// RequeueParkedOutboxEntries lets the relay publish the parked entries again, in the order of their creation.
+// the attempt counters are reset; the last error is kept for inspection.
+func (this *Deployments) RequeueParkedOutboxEntries(ctx context.Context) (count int64, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	result, err := this.outboxCollection().UpdateMany(ctx, bson.M{"parked": true}, bson.M{"$unset": bson.M{"parked": "", "attempts": "", "permanent_errors": "", "last_attempt": ""}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// Transaction runs f in a mongo transaction; repository calls with the context passed to f are part of the transaction.
+// f is called again, if the transaction is retried after a transient error. transactions need a replica set or sharded cluster.
+// if ctx already belongs to a transaction, f is part of that transaction.
+func (this *Deployments) Transaction(ctx context.Context, f func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return f(ctx)
+	}
+	session, err := this.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.WithoutCancel(ctx))
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+		return nil, f(ctx)
+	})
+	return err
+}
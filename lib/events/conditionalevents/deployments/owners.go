@@ -0,0 +1,57 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deployments
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type pipelineOwner struct {
+	Owner string `bson:"_id"`
+}
+
+func (this *Deployments) pipelineOwnerCollection() *mongo.Collection {
+	return this.client.Database(this.config.ConditionalEventRepoMongoTable).Collection(this.config.ConditionalEventRepoMongoPipelineOwnerCollection)
+}
+
+// AddPipelineOwner remembers a user with deployed event pipelines; owners are never removed
+func (this *Deployments) AddPipelineOwner(ctx context.Context, owner string) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.pipelineOwnerCollection().ReplaceOne(ctx, bson.M{"_id": owner}, pipelineOwner{Owner: owner}, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (this *Deployments) ListPipelineOwners(ctx context.Context) (result []string, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	cursor, err := this.pipelineOwnerCollection().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	owners, err, _ := readCursorResult[pipelineOwner](ctx, cursor)
+	if err != nil {
+		return nil, err
+	}
+	for _, owner := range owners {
+		result = append(result, owner.Owner)
+	}
+	return result, nil
+}
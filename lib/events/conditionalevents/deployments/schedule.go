@@ -0,0 +1,139 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deployments
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"runtime/debug"
+	"time"
+)
+
+// ScheduledDeployment is stored for every deployment, to deploy or remove its events when its activation changes.
+// deployments without activation are always active. Revision changes with every new version of the deployment.
+type ScheduledDeployment struct {
+	Id             string                            `json:"id" bson:"_id"`
+	Revision       string                            `json:"revision" bson:"revision"`
+	Owner          string                            `json:"owner" bson:"owner"`
+	Deployment     models.Deployment                 `json:"deployment" bson:"deployment"`
+	FilterCriteria map[string][]model.FilterCriteria `json:"filter_criteria,omitempty" bson:"filter_criteria,omitempty"`
+	Activation     *model.Activation                 `json:"activation,omitempty" bson:"activation,omitempty"`
+	Active         bool                              `json:"active" bson:"active"`
+	NextCheck      *time.Time                        `json:"next_check,omitempty" bson:"next_check,omitempty"` //nil if the activation never changes again
+}
+
+func (this ScheduledDeployment) IsActive(now time.Time) bool {
+	return this.Activation == nil || this.Activation.IsActive(now)
+}
+
+// NextChange returns nil if the activation never changes again
+func (this ScheduledDeployment) NextChange(now time.Time) *time.Time {
+	if this.Activation == nil {
+		return nil
+	}
+	next, ok := this.Activation.NextChange(now)
+	if !ok {
+		return nil
+	}
+	return &next
+}
+
+func (this ScheduledDeployment) GetDeployment() model.Deployment {
+	return model.Deployment{
+		Deployment:     this.Deployment,
+		UserId:         this.Owner,
+		FilterCriteria: this.FilterCriteria,
+		Activation:     this.Activation,
+	}
+}
+
+func init() {
+	CreateCollections = append(CreateCollections, func(db *Deployments) error {
+		if db.config.ConditionalEventRepoMongoScheduleCollection == "" {
+			return nil
+		}
+		err := db.ensureIndex(db.scheduleCollection(), "schedule_next_check_index", "next_check", true, false)
+		if err != nil {
+			debug.PrintStack()
+			return err
+		}
+		return nil
+	})
+}
+
+func (this *Deployments) scheduleCollection() *mongo.Collection {
+	return this.client.Database(this.config.ConditionalEventRepoMongoTable).Collection(this.config.ConditionalEventRepoMongoScheduleCollection)
+}
+
+func (this *Deployments) SetScheduledDeployment(ctx context.Context, element ScheduledDeployment) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.scheduleCollection().ReplaceOne(ctx, bson.M{"_id": element.Id}, element, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (this *Deployments) GetScheduledDeployment(ctx context.Context, id string) (result ScheduledDeployment, exists bool, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	err = this.scheduleCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return result, false, nil
+	}
+	if err != nil {
+		return result, false, err
+	}
+	return result, true, nil
+}
+
+// GetDueScheduledDeployments returns all scheduled deployments with a next_check not after now
+func (this *Deployments) GetDueScheduledDeployments(ctx context.Context, now time.Time) (result []ScheduledDeployment, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	cursor, err := this.scheduleCollection().Find(ctx, bson.M{"next_check": bson.M{"$lte": now}}, options.Find().SetSort(bson.D{{Key: "next_check", Value: 1}}))
+	if err != nil {
+		return result, err
+	}
+	result, err, _ = readCursorResult[ScheduledDeployment](ctx, cursor)
+	return result, err
+}
+
+// UpdateScheduledDeploymentState sets active and nextCheck, if the stored entry still has the given revision
+func (this *Deployments) UpdateScheduledDeploymentState(ctx context.Context, id string, revision string, active bool, nextCheck *time.Time) (updated bool, err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	update := bson.M{"$set": bson.M{"active": active, "next_check": nextCheck}}
+	if nextCheck == nil {
+		update = bson.M{"$set": bson.M{"active": active}, "$unset": bson.M{"next_check": ""}}
+	}
+	result, err := this.scheduleCollection().UpdateOne(ctx, bson.M{"_id": id, "revision": revision}, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (this *Deployments) RemoveScheduledDeployment(ctx context.Context, id string) (err error) {
+	ctx, cancel := getTimeoutContext(ctx)
+	defer cancel()
+	_, err = this.scheduleCollection().DeleteMany(ctx, bson.M{"_id": id})
+	return err
+}
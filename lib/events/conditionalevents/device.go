@@ -20,12 +20,9 @@ import (
 	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/idmodifier"
 	"github.com/SENERGY-Platform/event-worker/pkg/model"
 	"github.com/SENERGY-Platform/models/go/models"
-	"log"
-	"net/http"
-	"runtime/debug"
 )
 
-func (this *Transformer) transformEventForDevice(owner string, deployentId string, event *models.ConditionalEvent) (result []model.EventDesc, err error) {
+func (this *Transformer) transformEventForDevice(owner string, deployentId string, event *models.ConditionalEvent, resolved resolved) (result []model.EventDesc) {
 	desc := model.EventDesc{
 		UserId:        owner,
 		DeploymentId:  deployentId,
@@ -54,17 +51,11 @@ func (this *Transformer) transformEventForDevice(owner string, deployentId strin
 	desc.DeviceId, _ = idmodifier.SplitModifier(desc.DeviceId)
 	desc.ServiceId, _ = idmodifier.SplitModifier(desc.ServiceId)
 
-	service, err, code := this.devices.GetService(desc.ServiceId)
-	if err != nil {
-		if code == http.StatusInternalServerError {
-			return []model.EventDesc{}, err
-		} else {
-			log.Println("ERROR:", code, err)
-			debug.PrintStack()
-			return []model.EventDesc{}, nil //ignore bad request errors
-		}
+	service, ok := resolved.services[desc.ServiceId]
+	if !ok {
+		return []model.EventDesc{} //service lookup failed with ignored error
 	}
 	desc.ServiceForMarshaller = service
 
-	return []model.EventDesc{desc}, nil
+	return []model.EventDesc{desc}
 }
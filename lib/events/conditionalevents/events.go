@@ -19,16 +19,16 @@ package conditionalevents
 import (
 	"context"
 	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/deployments"
 	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/idmodifier"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
 	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
-	"github.com/SENERGY-Platform/event-worker/pkg/configuration"
-	"github.com/SENERGY-Platform/event-worker/pkg/eventrepo/cloud/mongo"
+	"github.com/SENERGY-Platform/event-deployment/lib/permissions"
 	workermodel "github.com/SENERGY-Platform/event-worker/pkg/model"
-	"github.com/SENERGY-Platform/models/go/models"
 	"log"
 	"net/http"
 	"runtime/debug"
@@ -37,51 +37,93 @@ import (
 
 type Events struct {
 	config      config.Config
-	db          *mongo.Mongo
+	db          EventDescriptionRepository
 	transformer *Transformer
-	deployments *deployments.Deployments
+	deployments DeploymentRepository
 	mux         sync.Mutex
 	metrics     *metrics.Metrics
+	permissions *permissions.Permissions
+	//counts device group updates; guarded by mux
+	groupUpdates int64
 }
 
-func New(ctx context.Context, config config.Config, devices interfaces.Devices, imports interfaces.Imports, m *metrics.Metrics) (result *Events, err error) {
-	result = &Events{config: config, transformer: NewTransformer(devices, imports), metrics: m}
-	result.deployments, err = deployments.New(ctx, &sync.WaitGroup{}, config)
+// ErrOutdatedPreparation is returned by the store function of PrepareDeploy, if a device group changed after the events were resolved
+var ErrOutdatedPreparation = errs.Conflict("", errors.New("device groups changed while the deployment was prepared"))
+
+// New creates the handler; if descChangeProducer is not nil, every change of the event descriptions is published with it
+func New(config config.Config, devices interfaces.Devices, imports interfaces.Imports, m *metrics.Metrics, repo *deployments.Deployments, descChangeProducer interfaces.Producer) (result *Events, err error) {
+	descriptions, depl, err := NewRepositories(config, repo)
 	if err != nil {
-		return result, err
+		return nil, err
 	}
-	result.db, err = mongo.New(ctx, &sync.WaitGroup{}, configuration.Config{
-		CloudEventRepoMongoUrl:            config.ConditionalEventRepoMongoUrl,
-		CloudEventRepoMongoTable:          config.ConditionalEventRepoMongoTable,
-		CloudEventRepoMongoDescCollection: config.ConditionalEventRepoMongoDescCollection,
-	})
-	return result, err
+	if descChangeProducer != nil {
+		descriptions = newChangePublisher(descriptions, descChangeProducer)
+	}
+	return NewWithRepositories(config, devices, imports, m, descriptions, depl), nil
 }
 
-func (this *Events) Deploy(owner string, deployment models.Deployment) error {
-	this.mux.Lock()
-	defer this.mux.Unlock()
-	err := this.deployments.SetDeployment(model.Deployment{
-		Deployment: deployment,
-		UserId:     owner,
-	})
+func NewWithRepositories(config config.Config, devices interfaces.Devices, imports interfaces.Imports, m *metrics.Metrics, descriptions EventDescriptionRepository, depl DeploymentRepository) *Events {
+	return &Events{config: config, transformer: NewTransformer(devices, imports), metrics: m, permissions: permissions.New(config), db: descriptions, deployments: depl}
+}
+
+func (this *Events) Deploy(ctx context.Context, owner string, deployment model.Deployment) error {
+	store, err := this.PrepareDeploy(ctx, owner, deployment)
 	if err != nil {
 		return err
 	}
-	return this.deployEvents(owner, deployment)
+	return store(ctx)
+}
+
+// PrepareDeploy resolves the event descriptions of the deployment, without writing them.
+// the returned function only writes the deployment and its descriptions to the repositories, so it can run in a transaction and may be called again, if the transaction is retried.
+// it returns ErrOutdatedPreparation, if a device group has been updated since the preparation; the deployment has to be prepared again in this case.
+func (this *Events) PrepareDeploy(ctx context.Context, owner string, deployment model.Deployment) (store func(ctx context.Context) error, err error) {
+	this.mux.Lock()
+	groupUpdates := this.groupUpdates
+	this.mux.Unlock()
+	deployment.UserId = owner
+	descriptions, err := this.transformer.Transform(ctx, owner, deployment, nil)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		this.mux.Lock()
+		defer this.mux.Unlock()
+		if this.groupUpdates != groupUpdates {
+			return ErrOutdatedPreparation
+		}
+		err := this.deployments.SetDeployment(ctx, deployment)
+		if err != nil {
+			return err
+		}
+		return this.storeEvents(ctx, deployment.Id, descriptions)
+	}, nil
 }
 
-func (this *Events) deployEvents(owner string, deployment models.Deployment) error {
-	err := this.removeEvents(deployment.Id)
+// deployEvents replaces the event descriptions of the deployment; the old descriptions stay active until the new ones are written
+func (this *Events) deployEvents(ctx context.Context, owner string, deployment model.Deployment, knownGroups map[string]model.DeviceGroup) error {
+	descriptions, err := this.transformer.Transform(ctx, owner, deployment, knownGroups)
 	if err != nil {
 		return err
 	}
-	descriptions, err := this.transformer.Transform(owner, deployment)
+	return this.storeEvents(ctx, deployment.Id, descriptions)
+}
+
+func (this *Events) storeEvents(ctx context.Context, deploymentId string, descriptions []workermodel.EventDesc) error {
+	stored := make([]workermodel.EventDesc, 0, len(descriptions))
+	for _, desc := range descriptions {
+		desc.DeviceId, _ = idmodifier.SplitModifier(desc.DeviceId)
+		desc.ServiceId, _ = idmodifier.SplitModifier(desc.ServiceId)
+		stored = append(stored, desc)
+	}
+	count, err := this.db.ReplaceEventDescriptions(ctx, deploymentId, stored)
 	if err != nil {
 		return err
 	}
-	for _, element := range descriptions {
-		err = this.deployDescription(element)
+	this.metrics.RemovedConditionalEvents.Add(float64(count))
+	this.metrics.DeployedConditionalEvents.Add(float64(len(stored)))
+	if this.config.ConditionalEventRepoMongoHistoryCollection != "" {
+		err = this.deployments.SetLatestDeploymentVersionDescriptions(ctx, deploymentId, descriptions)
 		if err != nil {
 			return err
 		}
@@ -89,40 +131,129 @@ func (this *Events) deployEvents(owner string, deployment models.Deployment) err
 	return nil
 }
 
-func (this *Events) Remove(owner string, deploymentId string) error {
+func (this *Events) Remove(ctx context.Context, owner string, deploymentId string) error {
 	this.mux.Lock()
 	defer this.mux.Unlock()
-	err := this.deployments.RemoveDeployment(deploymentId)
+	err := this.deployments.RemoveDeployment(ctx, deploymentId)
+	if err != nil {
+		return err
+	}
+	return this.removeEvents(ctx, deploymentId)
+}
+
+// RemoveDeploymentsExcept removes the stored deployments and event descriptions of all deployments, which are not in keep.
+// it is used after a rebuild replay, to remove deployments without command in the deployment topic; the other events stay active during the rebuild.
+func (this *Events) RemoveDeploymentsExcept(ctx context.Context, keep map[string]bool) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	deploymentIds, err := this.deployments.ListDeploymentIds(ctx)
+	if err != nil {
+		return err
+	}
+	descDeploymentIds, err := this.db.ListEventDescriptionDeploymentIds(ctx)
 	if err != nil {
 		return err
 	}
-	return this.removeEvents(deploymentId)
+	for _, deploymentId := range deploymentIds {
+		if !keep[deploymentId] {
+			log.Println("remove deployment without command in the deployment topic", deploymentId)
+			err = this.deployments.RemoveDeployment(ctx, deploymentId)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, deploymentId := range descDeploymentIds {
+		if !keep[deploymentId] {
+			err = this.removeEvents(ctx, deploymentId)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
-func (this *Events) removeEvents(deploymentId string) error {
-	count, err := this.db.RemoveEventDescriptionsByDeploymentId(deploymentId)
+func (this *Events) removeEvents(ctx context.Context, deploymentId string) error {
+	count, err := this.db.RemoveEventDescriptionsByDeploymentId(ctx, deploymentId)
 	this.metrics.RemovedConditionalEvents.Add(float64(count))
 	return err
 }
 
-func (this *Events) CheckEvent(token string, id string) int {
-	desc, err := this.db.GetEventDescriptionsByEventId(id)
+// CheckEvent returns http.StatusNotFound for events of other users, if they are not shared with the token owner
+func (this *Events) CheckEvent(ctx context.Context, token string, id string) int {
+	claims, err := auth.ParseUnverified(token)
+	if err != nil {
+		log.Println("ERROR:", err)
+		return http.StatusBadRequest
+	}
+	return this.checkEvent(ctx, token, claims, id)
+}
+
+func (this *Events) checkEvent(ctx context.Context, token string, claims auth.Claims, id string) int {
+	descriptions, err := this.getAccessibleDescriptions(ctx, token, claims, id)
 	if err != nil {
 		log.Println("ERROR:", err)
 		debug.PrintStack()
 		return http.StatusInternalServerError
 	}
-	if len(desc) == 0 {
+	if len(descriptions) == 0 {
 		return http.StatusNotFound
 	}
 	return http.StatusOK
 }
 
-func (this *Events) GetEventStates(token string, ids []string) (states map[string]bool, err error, code int) {
+func (this *Events) GetEventDetails(ctx context.Context, token string, id string) (details model.EventDetails, err error) {
+	details.EventId = id
+	claims, err := auth.ParseUnverified(token)
+	if err != nil {
+		return details, errs.Invalid("", err)
+	}
+	details.ConditionalEvents, err = this.getAccessibleDescriptions(ctx, token, claims, id)
+	if err != nil {
+		log.Println("ERROR:", err)
+		debug.PrintStack()
+		return details, err
+	}
+	return details, nil
+}
+
+// getAccessibleDescriptions returns the descriptions of the event owned by the token owner or shared with them; admins get all descriptions
+func (this *Events) getAccessibleDescriptions(ctx context.Context, token string, claims auth.Claims, id string) (result []workermodel.EventDesc, err error) {
+	descriptions, err := this.db.GetEventDescriptionsByEventId(ctx, id)
+	if err != nil {
+		return result, err
+	}
+	access := map[string]bool{}
+	for _, desc := range descriptions {
+		allowed, checked := access[desc.DeploymentId]
+		if !checked {
+			allowed = claims.IsAdmin() || desc.UserId == claims.Sub
+			if !allowed && this.permissions != nil {
+				allowed, err = this.permissions.CanReadDeployment(ctx, token, desc.DeploymentId)
+				if err != nil {
+					return result, err
+				}
+			}
+			access[desc.DeploymentId] = allowed
+		}
+		if allowed {
+			result = append(result, desc)
+		}
+	}
+	return result, nil
+}
+
+func (this *Events) GetEventStates(ctx context.Context, token string, ids []string) (states map[string]bool, err error) {
+	states = map[string]bool{}
+	claims, err := auth.ParseUnverified(token)
+	if err != nil {
+		return states, errs.Invalid("", err)
+	}
 	for _, id := range ids {
-		state := this.CheckEvent(token, id)
+		state := this.checkEvent(ctx, token, claims, id)
 		if state == http.StatusInternalServerError {
-			return states, errors.New("unable to get event state"), state
+			return states, errors.New("unable to get event state")
 		}
 		if state == http.StatusNotFound {
 			states[id] = false
@@ -131,12 +262,5 @@ func (this *Events) GetEventStates(token string, ids []string) (states map[strin
 			states[id] = true
 		}
 	}
-	return states, nil, http.StatusOK
-}
-
-func (this *Events) deployDescription(desc workermodel.EventDesc) error {
-	this.metrics.DeployedConditionalEvents.Inc()
-	desc.DeviceId, _ = idmodifier.SplitModifier(desc.DeviceId)
-	desc.ServiceId, _ = idmodifier.SplitModifier(desc.ServiceId)
-	return this.db.SetEventDescription(desc)
+	return states, nil
 }
@@ -0,0 +1,220 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conditionalevents
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/memory"
+	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/event-deployment/lib/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/golang-jwt/jwt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEventsWithMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	devices := &mocks.DevicesMock{
+		GetDeviceInfosOfGroupValues: map[string][]model.Device{"g1": {
+			{Id: "d1", DeviceTypeId: "dt1"},
+			{Id: "d2", DeviceTypeId: "dt1"},
+		}},
+		GetDeviceTypeSelectablesValues: map[string]map[string][]model.DeviceTypeSelectable{
+			"f1": {"": {{DeviceTypeId: "dt1", Services: []models.Service{{Id: "s1"}}}}},
+		},
+	}
+	repo := memory.New()
+	events := NewWithRepositories(&config.ConfigStruct{}, devices, nil, metrics.New(), repo, repo)
+
+	characteristicId := "c1"
+	functionId := "f1"
+	groupId := "g1"
+	deployment := model.Deployment{Deployment: models.Deployment{Id: "dep1", Elements: []models.Element{{ConditionalEvent: &models.ConditionalEvent{
+		EventId: "e_group",
+		Selection: models.Selection{
+			FilterCriteria:        models.FilterCriteria{CharacteristicId: &characteristicId, FunctionId: &functionId},
+			SelectedDeviceGroupId: &groupId,
+		},
+	}}}}}
+
+	checkDescriptions := func(t *testing.T, expected int) {
+		t.Helper()
+		descriptions, err := repo.GetEventDescriptionsByEventId(ctx, "e_group")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(descriptions) != expected {
+			t.Error(len(descriptions), expected, descriptions)
+		}
+	}
+
+	t.Run("deploy", func(t *testing.T) {
+		err := events.Deploy(ctx, "owner", deployment)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		checkDescriptions(t, 2)
+	})
+
+	t.Run("update device-group", func(t *testing.T) {
+		err := events.UpdateDeviceGroup(ctx, "g1", &model.DeviceGroup{Id: "g1", DeviceIds: []string{"d1"}})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		checkDescriptions(t, 1)
+	})
+
+	t.Run("remove device-group", func(t *testing.T) {
+		err := events.RemoveDeviceGroup(ctx, "g1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		checkDescriptions(t, 0)
+		list, err := repo.GetDeploymentByDeviceGroupId(ctx, "g1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(list) != 0 {
+			t.Error("broken deployment should be ignored", list)
+		}
+	})
+
+	t.Run("redeploy and remove", func(t *testing.T) {
+		err := events.Deploy(ctx, "owner", deployment)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		checkDescriptions(t, 2)
+		err = events.Remove(ctx, "owner", "dep1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		checkDescriptions(t, 0)
+		list, err := repo.GetDeploymentByDeviceGroupId(ctx, "g1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(list) != 0 {
+			t.Error(list)
+		}
+	})
+}
+
+func TestEventAccess(t *testing.T) {
+	ctx := context.Background()
+	permissionsServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		claims, err := auth.ParseUnverified(request.Header.Get("Authorization"))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		shared := claims.Sub == "friend" && request.URL.Path == "/check/deployments/dep_owner"
+		json.NewEncoder(writer).Encode(shared)
+	}))
+	defer permissionsServer.Close()
+
+	repo := memory.New()
+	_, err := repo.ReplaceEventDescriptions(ctx, "dep_owner", []model.EventDesc{{UserId: "owner", DeploymentId: "dep_owner", EventId: "e1", DeviceId: "d1"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = repo.ReplaceEventDescriptions(ctx, "dep_other", []model.EventDesc{{UserId: "other", DeploymentId: "dep_other", EventId: "e2", DeviceId: "d2"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	events := NewWithRepositories(&config.ConfigStruct{PermissionsV2Url: permissionsServer.URL, PermissionsV2DeploymentTopic: "deployments"}, nil, nil, metrics.New(), repo, repo)
+
+	userToken := func(t *testing.T, user string) string {
+		token, err := auth.GenerateInternalUserToken(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	adminToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.Claims{
+		Sub:         "admin",
+		ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		RealmAccess: map[string][]string{"roles": {"admin"}},
+	}).SignedString([]byte("test"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	adminToken = "Bearer " + adminToken
+
+	cases := []struct {
+		name     string
+		token    string
+		expected map[string]bool
+	}{
+		{name: "owner", token: userToken(t, "owner"), expected: map[string]bool{"e1": true, "e2": false, "unknown": false}},
+		{name: "non-owner", token: userToken(t, "stranger"), expected: map[string]bool{"e1": false, "e2": false, "unknown": false}},
+		{name: "shared", token: userToken(t, "friend"), expected: map[string]bool{"e1": true, "e2": false, "unknown": false}},
+		{name: "admin", token: adminToken, expected: map[string]bool{"e1": true, "e2": true, "unknown": false}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for id, expected := range c.expected {
+				code := events.CheckEvent(ctx, c.token, id)
+				if (expected && code != http.StatusOK) || (!expected && code != http.StatusNotFound) {
+					t.Error(id, code)
+				}
+			}
+			ids := []string{}
+			for id := range c.expected {
+				ids = append(ids, id)
+			}
+			states, err := events.GetEventStates(ctx, c.token, ids)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !reflect.DeepEqual(states, c.expected) {
+				t.Error(states, c.expected)
+			}
+		})
+	}
+
+	t.Run("details of foreign event", func(t *testing.T) {
+		details, err := events.GetEventDetails(ctx, userToken(t, "stranger"), "e1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(details.ConditionalEvents) != 0 {
+			t.Error(details)
+		}
+	})
+}
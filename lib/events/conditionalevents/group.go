@@ -21,11 +21,10 @@ import (
 	"github.com/SENERGY-Platform/event-worker/pkg/model"
 	"github.com/SENERGY-Platform/models/go/models"
 	"log"
-	"net/http"
 	"runtime/debug"
 )
 
-func (this *Transformer) transformEventForDeviceGroup(owner string, deployentId string, event *models.ConditionalEvent) (result []model.EventDesc, err error) {
+func (this *Transformer) transformEventForDeviceGroup(owner string, deployentId string, event *models.ConditionalEvent, criteria []eventmodel.FilterCriteria, resolved resolved) (result []model.EventDesc) {
 	desc := model.EventDesc{
 		UserId:        owner,
 		DeploymentId:  deployentId,
@@ -50,51 +49,14 @@ func (this *Transformer) transformEventForDeviceGroup(owner string, deployentId
 		desc.Path = event.Selection.SelectedPath.Path
 	}
 
-	devices, _, err, code := this.devices.GetDeviceInfosOfGroup(desc.DeviceGroupId)
-	if err != nil {
-		if code == http.StatusInternalServerError {
-			return []model.EventDesc{}, err
-		} else {
-			log.Println("ERROR:", code, err)
-			debug.PrintStack()
-			return []model.EventDesc{}, nil //ignore bad request errors
-		}
-	}
-
-	deviceCache := map[string]models.Device{}
-
-	for _, device := range devices {
-		deviceCache[device.Id] = device
+	devices, ok := resolved.groupDevices[desc.DeviceGroupId]
+	if !ok {
+		return []model.EventDesc{} //group lookup failed with ignored error
 	}
-
-	dtSelectables, err, code := this.devices.GetDeviceTypeSelectables([]eventmodel.FilterCriteria{{
-		FunctionId: desc.FunctionId,
-		AspectId:   desc.AspectId,
-	}})
-	if err != nil {
-		if code == http.StatusInternalServerError {
-			return []model.EventDesc{}, err
-		} else {
-			log.Println("ERROR:", code, err)
-			debug.PrintStack()
-			return []model.EventDesc{}, nil //ignore bad request errors
-		}
-	}
-
-	result = []model.EventDesc{}
-	for _, device := range devices {
-		partial := desc
-		partial.DeviceId = device.Id
-		temp, err := this.transformPartialDescription(partial, dtSelectables, &deviceCache)
-		if err != nil {
-			return []model.EventDesc{}, err
-		}
-		result = append(result, temp...)
-	}
-	return result, nil
+	return this.transformDevices(desc, devices, criteria, resolved)
 }
 
-func (this *Transformer) transformEventForDeviceWithoutService(owner string, deployentId string, event *models.ConditionalEvent) (result []model.EventDesc, err error) {
+func (this *Transformer) transformEventForDeviceWithoutService(owner string, deployentId string, event *models.ConditionalEvent, criteria []eventmodel.FilterCriteria, resolved resolved) (result []model.EventDesc) {
 	desc := model.EventDesc{
 		UserId:        owner,
 		DeploymentId:  deployentId,
@@ -119,83 +81,68 @@ func (this *Transformer) transformEventForDeviceWithoutService(owner string, dep
 		desc.Path = event.Selection.SelectedPath.Path
 	}
 
-	devices, _, err, code := this.devices.GetDeviceInfosOfDevices([]string{desc.DeviceId})
-	if err != nil {
-		if code == http.StatusInternalServerError {
-			return []model.EventDesc{}, err
-		} else {
-			log.Println("ERROR:", code, err)
-			debug.PrintStack()
-			return []model.EventDesc{}, nil //ignore bad request errors
-		}
-	}
-
-	deviceCache := map[string]models.Device{}
-
-	for _, device := range devices {
-		deviceCache[device.Id] = device
-	}
-
-	dtSelectables, err, code := this.devices.GetDeviceTypeSelectables([]eventmodel.FilterCriteria{{
-		FunctionId: desc.FunctionId,
-		AspectId:   desc.AspectId,
-	}})
-	if err != nil {
-		if code == http.StatusInternalServerError {
-			return []model.EventDesc{}, err
-		} else {
-			log.Println("ERROR:", code, err)
-			debug.PrintStack()
-			return []model.EventDesc{}, nil //ignore bad request errors
-		}
+	device, ok := resolved.devices[desc.DeviceId]
+	if !ok {
+		log.Println("WARNING: unknown device", desc.DeviceId)
+		return []model.EventDesc{}
 	}
+	return this.transformDevices(desc, []models.Device{device}, criteria, resolved)
+}
 
+// transformDevices creates a description for every service of the devices, which matches one of the criteria.
+// a service matching several criteria gets only one description per device and path, so that a message triggers the event once;
+// the description records the function and aspect of the first matching criteria.
+func (this *Transformer) transformDevices(desc model.EventDesc, devices []models.Device, criteria []eventmodel.FilterCriteria, resolved resolved) (result []model.EventDesc) {
 	result = []model.EventDesc{}
+	used := map[string]bool{}
 	for _, device := range devices {
 		partial := desc
 		partial.DeviceId = device.Id
-		temp, err := this.transformPartialDescription(partial, dtSelectables, &deviceCache)
-		if err != nil {
-			return []model.EventDesc{}, err
+		for _, temp := range this.transformPartialDescription(partial, criteria, resolved) {
+			key := temp.DeviceId + "." + temp.ServiceId + "." + temp.Path
+			if !used[key] {
+				used[key] = true
+				result = append(result, temp)
+			}
 		}
-		result = append(result, temp...)
 	}
-	return result, nil
+	return result
 }
 
-func (this *Transformer) transformPartialDescription(partialDesc model.EventDesc, selectables []eventmodel.DeviceTypeSelectable, deviceCache *map[string]models.Device) (result []model.EventDesc, err error) {
-	dtId := (*deviceCache)[partialDesc.DeviceId].DeviceTypeId
+func (this *Transformer) transformPartialDescription(partialDesc model.EventDesc, criteria []eventmodel.FilterCriteria, resolved resolved) (result []model.EventDesc) {
+	dtId := resolved.devices[partialDesc.DeviceId].DeviceTypeId
 	if dtId == "" {
-		devices, _, err, code := this.devices.GetDeviceInfosOfDevices([]string{partialDesc.DeviceId})
-		if err != nil {
-			if code == http.StatusInternalServerError {
-				return []model.EventDesc{}, err
-			} else {
-				log.Println("ERROR:", code, err)
-				debug.PrintStack()
-				return []model.EventDesc{}, nil //ignore bad request errors
-			}
-		}
-		if len(devices) == 0 {
-			log.Println("ERROR: unexpected GetDeviceInfosOfDevices() result", devices)
-			debug.PrintStack()
-			return []model.EventDesc{}, nil
-		}
-		device := devices[0]
-		(*deviceCache)[device.Id] = device
-		dtId = device.DeviceTypeId
+		log.Println("ERROR: missing device-type of device", partialDesc.DeviceId)
+		debug.PrintStack()
+		return []model.EventDesc{}
 	}
 
 	result = []model.EventDesc{}
-	for _, selectable := range selectables {
-		if selectable.DeviceTypeId == dtId {
-			for _, service := range selectable.Services {
-				temp := partialDesc
-				temp.ServiceId = service.Id
-				temp.ServiceForMarshaller = service
-				result = append(result, temp)
+	for _, c := range criteria {
+		selectables, ok := resolved.selectables[c]
+		if !ok {
+			continue //selectables lookup failed with ignored error
+		}
+		for _, selectable := range selectables {
+			if selectable.DeviceTypeId == dtId {
+				for _, service := range selectable.Services {
+					if !supportsInteraction(service, c.Interaction) {
+						continue
+					}
+					temp := partialDesc
+					temp.FunctionId = c.FunctionId
+					temp.AspectId = c.AspectId
+					temp.ServiceId = service.Id
+					temp.ServiceForMarshaller = service
+					result = append(result, temp)
+				}
 			}
 		}
 	}
-	return result, nil
+	return result
+}
+
+// supportsInteraction checks the interaction of a criteria; services with event+request support both interactions
+func supportsInteraction(service models.Service, interaction models.Interaction) bool {
+	return interaction == "" || service.Interaction == interaction || service.Interaction == models.EVENT_AND_REQUEST
 }
@@ -17,30 +17,59 @@
 package conditionalevents
 
 import (
+	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/deployments"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	eventworkermodel "github.com/SENERGY-Platform/event-worker/pkg/model"
+	"log"
 )
 
-func (this *Events) UpdateDeviceGroup(groupId string) error {
+// UpdateDeviceGroup redeploys the events of all deployments using the group.
+// if group is nil, it is requested from the device-repository.
+func (this *Events) UpdateDeviceGroup(ctx context.Context, groupId string, group *model.DeviceGroup) error {
 	this.mux.Lock()
 	defer this.mux.Unlock()
-	deploymentList, err := this.deployments.GetDeploymentByDeviceGroupId(groupId)
+	this.groupUpdates++
+	deploymentList, err := this.deployments.GetDeploymentByDeviceGroupId(ctx, groupId)
 	if err != nil {
 		return err
 	}
-	descr, err := this.db.GetEventDescriptionsByDeviceGroup(groupId)
+	descr, err := this.db.GetEventDescriptionsByDeviceGroup(ctx, groupId)
 	if err != nil {
 		return err
 	}
+	var knownGroups map[string]model.DeviceGroup
+	if group != nil {
+		knownGroups = map[string]model.DeviceGroup{groupId: *group}
+	}
 	for _, depl := range deploymentList {
-		err = this.removeEvents(depl.Id)
+		if depl.UserId == "" {
+			depl.UserId = getFallbackUser(descr, depl)
+		}
+		err = this.deployEvents(ctx, depl.UserId, depl, knownGroups)
 		if err != nil {
 			return err
 		}
-		if depl.UserId == "" {
-			depl.UserId = getFallbackUser(descr, depl)
+	}
+	return nil
+}
+
+// RemoveDeviceGroup marks all deployments using the group as broken and removes their events
+func (this *Events) RemoveDeviceGroup(ctx context.Context, groupId string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.groupUpdates++
+	deploymentList, err := this.deployments.GetDeploymentByDeviceGroupId(ctx, groupId)
+	if err != nil {
+		return err
+	}
+	for _, depl := range deploymentList {
+		log.Println("WARNING: device-group", groupId, "deleted --> mark deployment", depl.Id, "as broken")
+		err = this.deployments.MarkDeploymentBroken(ctx, depl.Id, "device-group "+groupId+" deleted")
+		if err != nil {
+			return err
 		}
-		err = this.deployEvents(depl.UserId, depl.Deployment)
+		err = this.removeEvents(ctx, depl.Id)
 		if err != nil {
 			return err
 		}
@@ -17,14 +17,12 @@
 package conditionalevents
 
 import (
+	"context"
 	"github.com/SENERGY-Platform/event-worker/pkg/model"
 	"github.com/SENERGY-Platform/models/go/models"
-	"log"
-	"net/http"
-	"runtime/debug"
 )
 
-func (this *Transformer) transformEventForImport(owner string, deployentId string, event *models.ConditionalEvent) (result []model.EventDesc, err error) {
+func (this *Transformer) transformEventForImport(ctx context.Context, owner string, deployentId string, event *models.ConditionalEvent) (result []model.EventDesc, err error) {
 	desc := model.EventDesc{
 		UserId:        owner,
 		DeploymentId:  deployentId,
@@ -49,25 +47,13 @@ func (this *Transformer) transformEventForImport(owner string, deployentId strin
 		desc.Path = event.Selection.SelectedPath.Path
 	}
 
-	importInstance, err, code := this.imports.GetImportInstance(owner, desc.ImportId)
+	importInstance, err := this.imports.GetImportInstance(ctx, owner, desc.ImportId)
 	if err != nil {
-		if code == http.StatusInternalServerError {
-			return []model.EventDesc{}, err
-		} else {
-			log.Println("ERROR:", code, err)
-			debug.PrintStack()
-			return []model.EventDesc{}, nil //ignore bad request errors
-		}
+		return []model.EventDesc{}, handleResolveError(err)
 	}
-	importType, err, code := this.imports.GetImportType(owner, importInstance.ImportTypeId)
+	importType, err := this.imports.GetImportType(ctx, owner, importInstance.ImportTypeId)
 	if err != nil {
-		if code == http.StatusInternalServerError {
-			return []model.EventDesc{}, err
-		} else {
-			log.Println("ERROR:", code, err)
-			debug.PrintStack()
-			return []model.EventDesc{}, nil //ignore bad request errors
-		}
+		return []model.EventDesc{}, handleResolveError(err)
 	}
 	outputs := importVariablesToContents(importType.Output.SubContentVariables)
 
@@ -17,13 +17,36 @@
 package conditionalevents
 
 import (
+	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"github.com/SENERGY-Platform/models/go/models"
 )
 
 type Devices interface {
-	GetDeviceInfosOfGroup(groupId string) (devices []model.Device, deviceTypeIds []string, err error, code int)
-	GetDeviceInfosOfDevices(deviceIds []string) (devices []model.Device, deviceTypeIds []string, err error, code int)
-	GetDeviceTypeSelectables(criteria []model.FilterCriteria) (result []model.DeviceTypeSelectable, err error, code int)
-	GetService(serviceId string) (result models.Service, err error, code int)
+	GetDeviceInfosOfDevices(ctx context.Context, deviceIds []string) (devices []model.Device, deviceTypeIds []string, err error)
+	GetDeviceGroups(ctx context.Context, groupIds []string) (result []model.DeviceGroup, err error)
+	GetDeviceTypes(ctx context.Context, deviceTypeIds []string) (result []model.DeviceType, err error)
+	GetDeviceTypeSelectables(ctx context.Context, criteria []model.FilterCriteria) (result []model.DeviceTypeSelectable, err error)
+	GetService(ctx context.Context, serviceId string) (result models.Service, err error)
+}
+
+// EventDescriptionRepository stores the event descriptions, which are evaluated by the event-worker
+type EventDescriptionRepository interface {
+	//ReplaceEventDescriptions replaces all descriptions of the deployment, without a moment in which the deployment has no or partial descriptions
+	ReplaceEventDescriptions(ctx context.Context, deploymentId string, descriptions []model.EventDesc) (removed int64, err error)
+	RemoveEventDescriptionsByDeploymentId(ctx context.Context, deploymentId string) (count int64, err error)
+	ListEventDescriptionDeploymentIds(ctx context.Context) (deploymentIds []string, err error)
+	GetEventDescriptionsByEventId(ctx context.Context, eventId string) (result []model.EventDesc, err error)
+	GetEventDescriptionsByDeploymentId(ctx context.Context, deploymentId string) (result []model.EventDesc, err error)
+	GetEventDescriptionsByDeviceGroup(ctx context.Context, deviceGroupId string) (result []model.EventDesc, err error)
+}
+
+// DeploymentRepository stores the deployments with conditional events, to redeploy them if a used device-group changes
+type DeploymentRepository interface {
+	SetDeployment(ctx context.Context, deployment model.Deployment) error
+	RemoveDeployment(ctx context.Context, deploymentId string) error
+	ListDeploymentIds(ctx context.Context) (deploymentIds []string, err error)
+	GetDeploymentByDeviceGroupId(ctx context.Context, deviceGroupId string) (result []model.Deployment, err error)
+	MarkDeploymentBroken(ctx context.Context, deploymentId string, reason string) error
+	SetLatestDeploymentVersionDescriptions(ctx context.Context, deploymentId string, descriptions []model.EventDesc) error
 }
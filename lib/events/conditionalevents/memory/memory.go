@@ -0,0 +1,201 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/deployments"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Repository keeps event descriptions and deployments in memory.
+// it implements conditionalevents.EventDescriptionRepository and conditionalevents.DeploymentRepository
+// for tests and development setups without mongodb; the content is lost on restart.
+type Repository struct {
+	mux          sync.RWMutex
+	descriptions []model.EventDesc
+	deployments  map[string]deployment
+}
+
+type deployment struct {
+	deployment   model.Deployment
+	deviceGroups []string
+	broken       bool
+}
+
+func New() *Repository {
+	return &Repository{deployments: map[string]deployment{}}
+}
+
+func (this *Repository) ReplaceEventDescriptions(ctx context.Context, deploymentId string, descriptions []model.EventDesc) (removed int64, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	before := len(this.descriptions)
+	this.descriptions = slices.DeleteFunc(this.descriptions, func(desc model.EventDesc) bool {
+		return desc.DeploymentId == deploymentId
+	})
+	removed = int64(before - len(this.descriptions))
+	this.descriptions = append(this.descriptions, descriptions...)
+	return removed, nil
+}
+
+func (this *Repository) RemoveEventDescriptionsByDeploymentId(ctx context.Context, deploymentId string) (count int64, err error) {
+	return this.removeEventDescriptions(ctx, func(desc model.EventDesc) bool {
+		return desc.DeploymentId == deploymentId
+	})
+}
+
+func (this *Repository) ListEventDescriptionDeploymentIds(ctx context.Context) (result []string, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	result = []string{}
+	for _, desc := range this.descriptions {
+		if !slices.Contains(result, desc.DeploymentId) {
+			result = append(result, desc.DeploymentId)
+		}
+	}
+	return result, nil
+}
+
+func (this *Repository) removeEventDescriptions(ctx context.Context, match func(desc model.EventDesc) bool) (count int64, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	before := len(this.descriptions)
+	this.descriptions = slices.DeleteFunc(this.descriptions, match)
+	return int64(before - len(this.descriptions)), nil
+}
+
+func (this *Repository) GetEventDescriptionsByEventId(ctx context.Context, eventId string) (result []model.EventDesc, err error) {
+	return this.getEventDescriptions(ctx, func(desc model.EventDesc) bool {
+		return desc.EventId == eventId
+	})
+}
+
+func (this *Repository) GetEventDescriptionsByDeploymentId(ctx context.Context, deploymentId string) (result []model.EventDesc, err error) {
+	return this.getEventDescriptions(ctx, func(desc model.EventDesc) bool {
+		return desc.DeploymentId == deploymentId
+	})
+}
+
+func (this *Repository) GetEventDescriptionsByDeviceGroup(ctx context.Context, deviceGroupId string) (result []model.EventDesc, err error) {
+	return this.getEventDescriptions(ctx, func(desc model.EventDesc) bool {
+		return desc.DeviceGroupId == deviceGroupId
+	})
+}
+
+func (this *Repository) getEventDescriptions(ctx context.Context, match func(desc model.EventDesc) bool) (result []model.EventDesc, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	result = []model.EventDesc{}
+	for _, desc := range this.descriptions {
+		if match(desc) {
+			result = append(result, desc)
+		}
+	}
+	return result, nil
+}
+
+func (this *Repository) SetDeployment(ctx context.Context, element model.Deployment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.deployments[element.Id] = deployment{deployment: element, deviceGroups: deployments.GetDeviceGroupIds(element)}
+	return nil
+}
+
+func (this *Repository) RemoveDeployment(ctx context.Context, deploymentId string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.deployments, deploymentId)
+	return nil
+}
+
+func (this *Repository) ListDeploymentIds(ctx context.Context) (result []string, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	result = []string{}
+	for id := range this.deployments {
+		result = append(result, id)
+	}
+	slices.Sort(result)
+	return result, nil
+}
+
+// GetDeploymentByDeviceGroupId ignores broken deployments
+func (this *Repository) GetDeploymentByDeviceGroupId(ctx context.Context, deviceGroupId string) (result []model.Deployment, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	result = []model.Deployment{}
+	if deviceGroupId == "" {
+		return result, nil
+	}
+	for _, element := range this.deployments {
+		if !element.broken && slices.Contains(element.deviceGroups, deviceGroupId) {
+			result = append(result, element.deployment)
+		}
+	}
+	slices.SortFunc(result, func(a, b model.Deployment) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+	return result, nil
+}
+
+func (this *Repository) MarkDeploymentBroken(ctx context.Context, deploymentId string, reason string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	element, ok := this.deployments[deploymentId]
+	if !ok {
+		return nil
+	}
+	element.broken = true
+	this.deployments[deploymentId] = element
+	return nil
+}
+
+// SetLatestDeploymentVersionDescriptions does nothing, because the deployment history is only available with mongodb
+func (this *Repository) SetLatestDeploymentVersionDescriptions(ctx context.Context, deploymentId string, descriptions []model.EventDesc) error {
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"reflect"
+	"testing"
+)
+
+func TestEventDescriptions(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+	removed, err := repo.ReplaceEventDescriptions(ctx, "dep1", []model.EventDesc{
+		{DeploymentId: "dep1", EventId: "e0", DeviceId: "d0"},
+	})
+	if err != nil || removed != 0 {
+		t.Fatal(err, removed)
+	}
+	removed, err = repo.ReplaceEventDescriptions(ctx, "dep1", []model.EventDesc{
+		{DeploymentId: "dep1", EventId: "e1", DeviceId: "d1"},
+		{DeploymentId: "dep1", EventId: "e2", DeviceGroupId: "g1"},
+	})
+	if err != nil || removed != 1 {
+		t.Fatal(err, removed)
+	}
+	_, err = repo.ReplaceEventDescriptions(ctx, "dep2", []model.EventDesc{
+		{DeploymentId: "dep2", EventId: "e3", DeviceGroupId: "g1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := repo.GetEventDescriptionsByEventId(ctx, "e0")
+	if err != nil || len(list) != 0 {
+		t.Error(err, list)
+	}
+	list, err = repo.GetEventDescriptionsByDeviceGroup(ctx, "g1")
+	if err != nil || len(list) != 2 {
+		t.Error(err, list)
+	}
+	list, err = repo.GetEventDescriptionsByEventId(ctx, "e1")
+	if err != nil || len(list) != 1 || list[0].DeviceId != "d1" {
+		t.Error(err, list)
+	}
+	count, err := repo.RemoveEventDescriptionsByDeploymentId(ctx, "dep1")
+	if err != nil || count != 2 {
+		t.Error(err, count)
+	}
+	ids, err := repo.ListEventDescriptionDeploymentIds(ctx)
+	if err != nil || !reflect.DeepEqual(ids, []string{"dep2"}) {
+		t.Error(err, ids)
+	}
+}
+
+func TestDeployments(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+	group := "g1"
+	withGroup := func(id string) model.Deployment {
+		return model.Deployment{Deployment: models.Deployment{Id: id, Elements: []models.Element{{ConditionalEvent: &models.ConditionalEvent{
+			Selection: models.Selection{SelectedDeviceGroupId: &group},
+		}}}}}
+	}
+	for _, depl := range []model.Deployment{withGroup("dep2"), withGroup("dep1"), {Deployment: models.Deployment{Id: "dep3"}}} {
+		err := repo.SetDeployment(ctx, depl)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := repo.GetDeploymentByDeviceGroupId(ctx, "g1")
+	if err != nil || len(list) != 2 || list[0].Id != "dep1" || list[1].Id != "dep2" {
+		t.Error(err, list)
+	}
+	err = repo.MarkDeploymentBroken(ctx, "dep1", "test")
+	if err != nil {
+		t.Error(err)
+	}
+	list, err = repo.GetDeploymentByDeviceGroupId(ctx, "g1")
+	if err != nil || len(list) != 1 || list[0].Id != "dep2" {
+		t.Error(err, list)
+	}
+	err = repo.SetDeployment(ctx, withGroup("dep1"))
+	if err != nil {
+		t.Error(err)
+	}
+	err = repo.RemoveDeployment(ctx, "dep2")
+	if err != nil {
+		t.Error(err)
+	}
+	list, err = repo.GetDeploymentByDeviceGroupId(ctx, "g1")
+	if err != nil || len(list) != 1 || list[0].Id != "dep1" {
+		t.Error(err, list)
+	}
+	ids, err := repo.ListDeploymentIds(ctx)
+	if err != nil || !reflect.DeepEqual(ids, []string{"dep1", "dep3"}) {
+		t.Error(err, ids)
+	}
+}
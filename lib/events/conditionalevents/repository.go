@@ -0,0 +1,54 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conditionalevents
+
+import (
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/deployments"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/memory"
+)
+
+const (
+	RepositoryMongo  = "mongo"
+	RepositoryMemory = "memory"
+)
+
+// Enabled returns true if a repository for conditional events is configured
+func Enabled(config config.Config) bool {
+	if config.ConditionalEventRepoType == RepositoryMemory {
+		return true
+	}
+	return config.ConditionalEventRepoMongoUrl != "" && config.ConditionalEventRepoMongoUrl != "-"
+}
+
+// NewRepositories selects the repository implementation by config.ConditionalEventRepoType; mongo is used if not set.
+// the mongo implementation uses repo, which shares its client with the other repositories of the process.
+func NewRepositories(config config.Config, repo *deployments.Deployments) (EventDescriptionRepository, DeploymentRepository, error) {
+	switch config.ConditionalEventRepoType {
+	case "", RepositoryMongo:
+		if repo == nil {
+			return nil, nil, errors.New("conditional event repository " + RepositoryMongo + " needs conditional_event_repo_mongo_url")
+		}
+		return repo, repo, nil
+	case RepositoryMemory:
+		memoryRepo := memory.New()
+		return memoryRepo, memoryRepo, nil
+	default:
+		return nil, nil, errors.New("unknown conditional event repository " + config.ConditionalEventRepoType)
+	}
+}
@@ -0,0 +1,310 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conditionalevents
+
+import (
+	"context"
+	"fmt"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/idmodifier"
+	eventmodel "github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"log"
+	"runtime/debug"
+	"slices"
+)
+
+const (
+	selectionDeviceGroup          = "device_group"
+	selectionDevice               = "device"
+	selectionDeviceWithoutService = "device_without_service"
+	selectionImport               = "import"
+	selectionGenericSource        = "generic_source"
+)
+
+func getSelectionKind(event *models.ConditionalEvent) string {
+	if event == nil || event.Selection.FilterCriteria.CharacteristicId == nil {
+		return ""
+	}
+	if event.Selection.SelectedDeviceGroupId != nil && *event.Selection.SelectedDeviceGroupId != "" {
+		return selectionDeviceGroup
+	}
+	if event.Selection.SelectedDeviceId != nil && event.Selection.SelectedServiceId != nil && *event.Selection.SelectedServiceId != "" {
+		return selectionDevice
+	}
+	if event.Selection.SelectedDeviceId != nil {
+		return selectionDeviceWithoutService
+	}
+	if event.Selection.SelectedImportId != nil {
+		return selectionImport
+	}
+	if event.Selection.SelectedGenericEventSource != nil {
+		return selectionGenericSource
+	}
+	return ""
+}
+
+// resolved holds the device-repository information needed to transform a list of elements.
+// missing entries are the result of ignored (non internal) request errors.
+type resolved struct {
+	groupDevices map[string][]models.Device
+	devices      map[string]models.Device
+	selectables  map[eventmodel.FilterCriteria][]eventmodel.DeviceTypeSelectable
+	services     map[string]models.Service
+}
+
+// resolve collects all device, group, service and criteria ids referenced by the deployment
+// and requests every kind with one batched call: the groups, the devices of the groups and selections,
+// the device-types of the devices with selected services and the device-type-selectables of all criteria.
+// if the device batch fails with a permanent error, the devices are requested one by one.
+// services, that are not found in the device-type of their selected device, are requested one by one.
+// groups in knownGroups (e.g. from a device-group update message) are not requested.
+func (this *Transformer) resolve(ctx context.Context, deployment eventmodel.Deployment, knownGroups map[string]eventmodel.DeviceGroup) (result resolved, err error) {
+	result = resolved{
+		groupDevices: map[string][]models.Device{},
+		devices:      map[string]models.Device{},
+		selectables:  map[eventmodel.FilterCriteria][]eventmodel.DeviceTypeSelectable{},
+		services:     map[string]models.Service{},
+	}
+	groupIds := []string{}
+	deviceIds := []string{}
+	serviceIds := []string{}
+	serviceDeviceIds := []string{} //devices of selected services; their device-types contain the services
+	criteriaList := []eventmodel.FilterCriteria{}
+	seen := map[string]bool{}
+	seenCriteria := map[eventmodel.FilterCriteria]bool{}
+	addCriteria := func(criteria eventmodel.FilterCriteria) {
+		if !seenCriteria[criteria] {
+			seenCriteria[criteria] = true
+			criteriaList = append(criteriaList, criteria)
+		}
+	}
+	addId := func(list *[]string, prefix string, id string) {
+		if !seen[prefix+id] {
+			seen[prefix+id] = true
+			*list = append(*list, id)
+		}
+	}
+
+	for _, element := range deployment.Elements {
+		event := element.ConditionalEvent
+		switch getSelectionKind(event) {
+		case selectionDeviceGroup:
+			addId(&groupIds, "group:", *event.Selection.SelectedDeviceGroupId)
+			for _, criteria := range deployment.GetFilterCriteria(event.EventId, event.Selection) {
+				addCriteria(criteria)
+			}
+		case selectionDevice:
+			deviceId, _ := idmodifier.SplitModifier(*event.Selection.SelectedDeviceId)
+			serviceId, _ := idmodifier.SplitModifier(*event.Selection.SelectedServiceId)
+			addId(&deviceIds, "device:", deviceId)
+			addId(&serviceDeviceIds, "service_device:", deviceId)
+			addId(&serviceIds, "service:", serviceId)
+		case selectionDeviceWithoutService:
+			addId(&deviceIds, "device:", *event.Selection.SelectedDeviceId)
+			for _, criteria := range deployment.GetFilterCriteria(event.EventId, event.Selection) {
+				addCriteria(criteria)
+			}
+		}
+	}
+
+	groups := map[string]eventmodel.DeviceGroup{}
+	unknownGroupIds := []string{}
+	for _, groupId := range groupIds {
+		if group, ok := knownGroups[groupId]; ok {
+			groups[groupId] = group
+		} else {
+			unknownGroupIds = append(unknownGroupIds, groupId)
+		}
+	}
+	if len(unknownGroupIds) > 0 {
+		list, err := this.devices.GetDeviceGroups(ctx, unknownGroupIds)
+		if err != nil {
+			if err = handleResolveError(err); err != nil {
+				return result, err
+			}
+		}
+		for _, group := range list {
+			groups[group.Id] = group
+		}
+	}
+	for _, groupId := range groupIds {
+		for _, deviceId := range groups[groupId].DeviceIds {
+			addId(&deviceIds, "device:", deviceId)
+		}
+	}
+
+	if len(deviceIds) > 0 {
+		devices, _, err := this.devices.GetDeviceInfosOfDevices(ctx, deviceIds)
+		if err != nil && errs.IsPermanent(err) && len(deviceIds) > 1 {
+			//a single unknown or inaccessible device fails the batch; only the events of this device may be dropped
+			log.Println("WARNING: unable to resolve devices in batch --> resolve one by one", err)
+			devices, err = this.getDeviceInfosOneByOne(ctx, deviceIds)
+		}
+		if err != nil {
+			if err = handleResolveError(err); err != nil {
+				return result, err
+			}
+		}
+		for _, device := range devices {
+			result.devices[device.Id] = device
+		}
+	}
+	for _, groupId := range groupIds {
+		group, ok := groups[groupId]
+		if !ok {
+			continue //group lookup failed with ignored error
+		}
+		devices := []models.Device{}
+		for _, deviceId := range group.DeviceIds {
+			if device, ok := result.devices[deviceId]; ok {
+				devices = append(devices, device)
+			}
+		}
+		result.groupDevices[groupId] = devices
+	}
+
+	err = this.resolveServices(ctx, serviceIds, serviceDeviceIds, &result)
+	if err != nil {
+		return result, err
+	}
+
+	if len(criteriaList) > 0 {
+		selectables, err := this.devices.GetDeviceTypeSelectables(ctx, criteriaList)
+		if err != nil {
+			if err = handleResolveError(err); err != nil {
+				return result, err
+			}
+			return result, nil
+		}
+		for _, criteria := range criteriaList {
+			if len(criteriaList) == 1 {
+				result.selectables[criteria] = selectables
+			} else {
+				result.selectables[criteria] = getSelectablesOfCriteria(selectables, criteria)
+			}
+		}
+	}
+	return result, nil
+}
+
+// resolveServices takes the services from the device-types of the resolved devices;
+// services of unresolved devices or of other device-types are requested one by one
+func (this *Transformer) resolveServices(ctx context.Context, serviceIds []string, deviceIds []string, result *resolved) error {
+	if len(serviceIds) == 0 {
+		return nil
+	}
+	wanted := map[string]bool{}
+	for _, serviceId := range serviceIds {
+		wanted[serviceId] = true
+	}
+	deviceTypeIds := []string{}
+	seen := map[string]bool{}
+	for _, deviceId := range deviceIds {
+		dtId := result.devices[deviceId].DeviceTypeId
+		if dtId != "" && !seen[dtId] {
+			seen[dtId] = true
+			deviceTypeIds = append(deviceTypeIds, dtId)
+		}
+	}
+	if len(deviceTypeIds) > 0 {
+		deviceTypes, err := this.devices.GetDeviceTypes(ctx, deviceTypeIds)
+		if err != nil {
+			if err = handleResolveError(err); err != nil {
+				return err
+			}
+		}
+		for _, deviceType := range deviceTypes {
+			for _, service := range deviceType.Services {
+				if wanted[service.Id] {
+					result.services[service.Id] = service
+				}
+			}
+		}
+	}
+	for _, serviceId := range serviceIds {
+		if _, ok := result.services[serviceId]; ok {
+			continue
+		}
+		service, err := this.devices.GetService(ctx, serviceId)
+		if err != nil {
+			if err = handleResolveError(err); err != nil {
+				return err
+			}
+			continue
+		}
+		result.services[serviceId] = service
+	}
+	return nil
+}
+
+// getSelectablesOfCriteria returns the services of the selectables, which match the criteria by the function and aspect of one of their path options.
+// the device class of the criteria is applied by the device-repository, the interaction by transformPartialDescription.
+func getSelectablesOfCriteria(selectables []eventmodel.DeviceTypeSelectable, criteria eventmodel.FilterCriteria) (result []eventmodel.DeviceTypeSelectable) {
+	result = []eventmodel.DeviceTypeSelectable{}
+	for _, selectable := range selectables {
+		services := []models.Service{}
+		for _, service := range selectable.Services {
+			if slices.ContainsFunc(selectable.ServicePathOptions[service.Id], func(option eventmodel.ServicePathOption) bool {
+				return pathOptionMatchesCriteria(option, criteria)
+			}) {
+				services = append(services, service)
+			}
+		}
+		if len(services) > 0 {
+			result = append(result, eventmodel.DeviceTypeSelectable{
+				DeviceTypeId:       selectable.DeviceTypeId,
+				Services:           services,
+				ServicePathOptions: selectable.ServicePathOptions,
+			})
+		}
+	}
+	return result
+}
+
+// pathOptionMatchesCriteria checks the function and the aspect of the option; the aspect of the criteria may be an ancestor of the option aspect
+func pathOptionMatchesCriteria(option eventmodel.ServicePathOption, criteria eventmodel.FilterCriteria) bool {
+	if option.FunctionId != criteria.FunctionId {
+		return false
+	}
+	return criteria.AspectId == "" || option.AspectNode.Id == criteria.AspectId || slices.Contains(option.AspectNode.AncestorIds, criteria.AspectId)
+}
+
+// getDeviceInfosOneByOne requests every device on its own and skips devices with permanent errors
+func (this *Transformer) getDeviceInfosOneByOne(ctx context.Context, deviceIds []string) (result []models.Device, err error) {
+	for _, deviceId := range deviceIds {
+		devices, _, err := this.devices.GetDeviceInfosOfDevices(ctx, []string{deviceId})
+		if err != nil {
+			if err = handleResolveError(fmt.Errorf("device %v: %w", deviceId, err)); err != nil {
+				return result, err
+			}
+			continue
+		}
+		result = append(result, devices...)
+	}
+	return result, nil
+}
+
+// handleResolveError ignores permanent errors like unknown or inaccessible resources, which would block the consumption of the deployment
+func handleResolveError(err error) error {
+	if !errs.IsPermanent(err) {
+		return err
+	}
+	log.Println("ERROR:", err)
+	debug.PrintStack()
+	return nil
+}
@@ -17,7 +17,9 @@
 package conditionalevents
 
 import (
+	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	eventmodel "github.com/SENERGY-Platform/event-deployment/lib/model"
 	"github.com/SENERGY-Platform/event-worker/pkg/model"
 	"github.com/SENERGY-Platform/models/go/models"
 	"log"
@@ -35,9 +37,14 @@ type Transformer struct {
 	imports interfaces.Imports
 }
 
-func (this *Transformer) Transform(owner string, deployment models.Deployment) (result []model.EventDesc, err error) {
+// Transform uses the groups of knownGroups instead of requesting them; knownGroups may be nil
+func (this *Transformer) Transform(ctx context.Context, owner string, deployment eventmodel.Deployment, knownGroups map[string]eventmodel.DeviceGroup) (result []model.EventDesc, err error) {
+	resolved, err := this.resolve(ctx, deployment, knownGroups)
+	if err != nil {
+		return result, err
+	}
 	for _, element := range deployment.Elements {
-		temp, err := this.TransformElement(owner, deployment.Id, element)
+		temp, err := this.transformElement(ctx, owner, deployment, element, resolved)
 		if err != nil {
 			return result, err
 		}
@@ -46,25 +53,20 @@ func (this *Transformer) Transform(owner string, deployment models.Deployment) (
 	return result, nil
 }
 
-func (this *Transformer) TransformElement(owner string, deploymentId string, element models.Element) (result []model.EventDesc, err error) {
+func (this *Transformer) transformElement(ctx context.Context, owner string, deployment eventmodel.Deployment, element models.Element, resolved resolved) (result []model.EventDesc, err error) {
 	event := element.ConditionalEvent
-	if event != nil && event.Selection.FilterCriteria.CharacteristicId != nil {
-		if event.Selection.SelectedDeviceGroupId != nil && *event.Selection.SelectedDeviceGroupId != "" {
-			return this.transformEventForDeviceGroup(owner, deploymentId, event)
-		}
-		if event.Selection.SelectedDeviceId != nil && event.Selection.SelectedServiceId != nil && *event.Selection.SelectedServiceId != "" {
-			return this.transformEventForDevice(owner, deploymentId, event)
-		}
-		if event.Selection.SelectedDeviceId != nil && !(event.Selection.SelectedServiceId != nil && *event.Selection.SelectedServiceId != "") {
-			return this.transformEventForDeviceWithoutService(owner, deploymentId, event)
-		}
-		if event.Selection.SelectedImportId != nil {
-			return this.transformEventForImport(owner, deploymentId, event)
-		}
-		if event.Selection.SelectedGenericEventSource != nil {
-			log.Println("WARNING: generic event sources not supported for conditional events")
-			return []model.EventDesc{}, nil
-		}
+	switch getSelectionKind(event) {
+	case selectionDeviceGroup:
+		return this.transformEventForDeviceGroup(owner, deployment.Id, event, deployment.GetFilterCriteria(event.EventId, event.Selection), resolved), nil
+	case selectionDevice:
+		return this.transformEventForDevice(owner, deployment.Id, event, resolved), nil
+	case selectionDeviceWithoutService:
+		return this.transformEventForDeviceWithoutService(owner, deployment.Id, event, deployment.GetFilterCriteria(event.EventId, event.Selection), resolved), nil
+	case selectionImport:
+		return this.transformEventForImport(ctx, owner, deployment.Id, event)
+	case selectionGenericSource:
+		log.Println("WARNING: generic event sources not supported for conditional events")
+		return []model.EventDesc{}, nil
 	}
 	return []model.EventDesc{}, nil
 }
@@ -0,0 +1,423 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conditionalevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/event-deployment/lib/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type countingDevices struct {
+	mocks.DevicesMock
+	calls     map[string]int
+	forbidden map[string]bool        //device ids, that fail every GetDeviceInfosOfDevices request with errs.ErrForbidden
+	criteria  []model.FilterCriteria //criteria of the last GetDeviceTypeSelectables request
+}
+
+func (this *countingDevices) GetDeviceGroups(ctx context.Context, groupIds []string) (result []model.DeviceGroup, err error) {
+	this.calls["GetDeviceGroups"]++
+	return this.DevicesMock.GetDeviceGroups(ctx, groupIds)
+}
+
+func (this *countingDevices) GetDeviceTypes(ctx context.Context, deviceTypeIds []string) (result []model.DeviceType, err error) {
+	this.calls["GetDeviceTypes"]++
+	return this.DevicesMock.GetDeviceTypes(ctx, deviceTypeIds)
+}
+
+func (this *countingDevices) GetDeviceInfosOfDevices(ctx context.Context, deviceIds []string) (devices []model.Device, deviceTypeIds []string, err error) {
+	this.calls["GetDeviceInfosOfDevices"]++
+	for _, id := range deviceIds {
+		if this.forbidden[id] {
+			return nil, nil, errs.Forbidden("device-repository", errors.New("access denied to "+id))
+		}
+	}
+	return this.DevicesMock.GetDeviceInfosOfDevices(ctx, deviceIds)
+}
+
+func (this *countingDevices) GetDeviceTypeSelectables(ctx context.Context, criteria []model.FilterCriteria) (result []model.DeviceTypeSelectable, err error) {
+	this.calls["GetDeviceTypeSelectables"]++
+	this.criteria = criteria
+	return this.DevicesMock.GetDeviceTypeSelectables(ctx, criteria)
+}
+
+func (this *countingDevices) GetService(ctx context.Context, serviceId string) (result models.Service, err error) {
+	this.calls["GetService"]++
+	return this.DevicesMock.GetService(ctx, serviceId)
+}
+
+func TestTransformBatchesRequests(t *testing.T) {
+	devices := []model.Device{}
+	for i := 0; i < 50; i++ {
+		devices = append(devices, model.Device{Id: "d" + strconv.Itoa(i), DeviceTypeId: "dt1"})
+	}
+	repo := &countingDevices{
+		DevicesMock: mocks.DevicesMock{
+			GetDeviceInfosOfGroupValues: map[string][]model.Device{"g1": devices},
+			GetDeviceTypeSelectablesValues: map[string]map[string][]model.DeviceTypeSelectable{
+				"f1": {"": {{DeviceTypeId: "dt1", Services: []models.Service{{Id: "s1"}}}}},
+			},
+		},
+		calls: map[string]int{},
+	}
+
+	characteristicId := "c1"
+	functionId := "f1"
+	groupId := "g1"
+	deployment := model.Deployment{Deployment: models.Deployment{Id: "dep1"}}
+	for _, device := range devices {
+		deviceId := device.Id
+		deployment.Elements = append(deployment.Elements, models.Element{ConditionalEvent: &models.ConditionalEvent{
+			EventId: "e_" + deviceId,
+			Selection: models.Selection{
+				FilterCriteria:   models.FilterCriteria{CharacteristicId: &characteristicId, FunctionId: &functionId},
+				SelectedDeviceId: &deviceId,
+			},
+		}})
+	}
+	deployment.Elements = append(deployment.Elements, models.Element{ConditionalEvent: &models.ConditionalEvent{
+		EventId: "e_group",
+		Selection: models.Selection{
+			FilterCriteria:        models.FilterCriteria{CharacteristicId: &characteristicId, FunctionId: &functionId},
+			SelectedDeviceGroupId: &groupId,
+		},
+	}})
+
+	result, err := NewTransformer(repo, nil).Transform(context.Background(), "owner", deployment, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(result) != 100 {
+		t.Error(len(result))
+	}
+	expected := map[string]int{
+		"GetDeviceGroups":          1,
+		"GetDeviceInfosOfDevices":  1, //devices of the group and of the selections
+		"GetDeviceTypeSelectables": 1,
+		"GetService":               0,
+	}
+	for method, count := range expected {
+		if repo.calls[method] != count {
+			t.Error(method, repo.calls[method], count)
+		}
+	}
+	repo.calls = map[string]int{}
+	deployment.Elements = deployment.Elements[:len(devices)]
+	result, err = NewTransformer(repo, nil).Transform(context.Background(), "owner", deployment, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(result) != 50 {
+		t.Error(len(result))
+	}
+	if repo.calls["GetDeviceInfosOfDevices"] != 1 || repo.calls["GetDeviceTypeSelectables"] != 1 {
+		t.Error(repo.calls)
+	}
+}
+
+func TestTransformMultipleFilterCriteria(t *testing.T) {
+	repo := &countingDevices{
+		DevicesMock: mocks.DevicesMock{
+			GetDeviceInfosOfGroupValues: map[string][]model.Device{"g1": {
+				{Id: "d1", DeviceTypeId: "temperature_sensor"},
+				{Id: "d2", DeviceTypeId: "humidity_sensor"},
+				{Id: "d3", DeviceTypeId: "climate_sensor"},
+			}},
+			GetDeviceTypeSelectablesValues: map[string]map[string][]model.DeviceTypeSelectable{
+				"temperature": {"air": {
+					{DeviceTypeId: "temperature_sensor", Services: []models.Service{{Id: "s_temp"}}, ServicePathOptions: pathOptions("s_temp", "temperature", "air")},
+					{DeviceTypeId: "climate_sensor", Services: []models.Service{{Id: "s_climate"}}, ServicePathOptions: pathOptions("s_climate", "temperature", "air")},
+				}},
+				"humidity": {"air": {
+					{DeviceTypeId: "humidity_sensor", Services: []models.Service{{Id: "s_hum"}}, ServicePathOptions: pathOptions("s_hum", "humidity", "air")},
+					{DeviceTypeId: "climate_sensor", Services: []models.Service{{Id: "s_climate"}}, ServicePathOptions: pathOptions("s_climate", "humidity", "inside_air")},
+				}},
+			},
+		},
+		calls: map[string]int{},
+	}
+
+	deployment := model.Deployment{}
+	err := json.Unmarshal([]byte(`{
+		"id": "dep1",
+		"elements": [{
+			"conditional_event": {
+				"event_id": "e1",
+				"selection": {
+					"filter_criteria": {"characteristic_id": "c1", "function_id": "temperature", "aspect_id": "air"},
+					"selected_device_group_id": "g1"
+				}
+			}
+		}],
+		"filter_criteria": {"e1": [{"function_id": "humidity", "aspect_id": "air"}]}
+	}`), &deployment)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result, err := NewTransformer(repo, nil).Transform(context.Background(), "owner", deployment, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	actual := map[string]bool{}
+	for _, desc := range result {
+		actual[desc.DeviceId+"."+desc.ServiceId+"."+desc.FunctionId+"."+desc.AspectId] = true
+	}
+	expected := map[string]bool{
+		"d1.s_temp.temperature.air":    true,
+		"d2.s_hum.humidity.air":        true,
+		"d3.s_climate.temperature.air": true, //only one description for the first matching criteria
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("\n%#v\n%#v", actual, expected)
+	}
+	if repo.calls["GetDeviceTypeSelectables"] != 1 {
+		t.Error(repo.calls)
+	}
+	if len(result) != 3 {
+		t.Error(len(result))
+	}
+}
+
+func TestTransformCriteriaDeviceClassAndInteraction(t *testing.T) {
+	repo := &countingDevices{
+		DevicesMock: mocks.DevicesMock{
+			GetDeviceInfosOfGroupValues: map[string][]model.Device{"g1": {{Id: "d1", DeviceTypeId: "dt1"}}},
+			GetDeviceTypeSelectablesValues: map[string]map[string][]model.DeviceTypeSelectable{
+				"f1": {"air": {{DeviceTypeId: "dt1", Services: []models.Service{{Id: "s_event", Interaction: models.EVENT}}, ServicePathOptions: pathOptions("s_event", "f1", "air")}}},
+				"f2": {"": {{
+					DeviceTypeId: "dt1",
+					Services:     []models.Service{{Id: "s_event2", Interaction: models.EVENT}, {Id: "s_request", Interaction: models.REQUEST}, {Id: "s_both", Interaction: models.EVENT_AND_REQUEST}},
+					ServicePathOptions: mergeOptions(
+						pathOptions("s_event2", "f2", "air"),
+						pathOptions("s_request", "f2", "air"),
+						pathOptions("s_both", "f2", "air")),
+				}}},
+			},
+		},
+		calls: map[string]int{},
+	}
+
+	deployment := model.Deployment{}
+	err := json.Unmarshal([]byte(`{
+		"id": "dep1",
+		"elements": [{
+			"conditional_event": {
+				"event_id": "e1",
+				"selection": {
+					"filter_criteria": {"characteristic_id": "c1", "function_id": "f1", "aspect_id": "air", "device_class_id": "dc1"},
+					"selected_device_group_id": "g1"
+				}
+			}
+		}],
+		"filter_criteria": {"e1": [{"function_id": "f2", "interaction": "request"}]}
+	}`), &deployment)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result, err := NewTransformer(repo, nil).Transform(context.Background(), "owner", deployment, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	actual := []string{}
+	for _, desc := range result {
+		actual = append(actual, desc.ServiceId+"."+desc.FunctionId)
+	}
+	if !reflect.DeepEqual(actual, []string{"s_event.f1", "s_request.f2", "s_both.f2"}) {
+		t.Error(actual)
+	}
+	expectedCriteria := []model.FilterCriteria{
+		{FunctionId: "f1", AspectId: "air", DeviceClassId: "dc1"},
+		{FunctionId: "f2", Interaction: models.REQUEST},
+	}
+	if !reflect.DeepEqual(repo.criteria, expectedCriteria) {
+		t.Error(repo.criteria)
+	}
+}
+
+func mergeOptions(list ...map[string][]model.ServicePathOption) (result map[string][]model.ServicePathOption) {
+	result = map[string][]model.ServicePathOption{}
+	for _, options := range list {
+		for serviceId, option := range options {
+			result[serviceId] = append(result[serviceId], option...)
+		}
+	}
+	return result
+}
+
+// pathOptions returns the path options of a service with one path; the aspect is a descendant of "air"
+func pathOptions(serviceId string, functionId string, aspectId string) map[string][]model.ServicePathOption {
+	return map[string][]model.ServicePathOption{serviceId: {{
+		ServiceId:  serviceId,
+		Path:       "value." + functionId,
+		FunctionId: functionId,
+		AspectNode: model.AspectNode{Id: aspectId, AncestorIds: []string{"air"}},
+	}}}
+}
+
+func TestTransformKnownGroup(t *testing.T) {
+	repo := &countingDevices{
+		DevicesMock: mocks.DevicesMock{
+			GetDeviceInfosOfGroupValues: map[string][]model.Device{"g1": {
+				{Id: "d1", DeviceTypeId: "dt1"},
+				{Id: "d2", DeviceTypeId: "dt1"},
+			}},
+			GetDeviceTypeSelectablesValues: map[string]map[string][]model.DeviceTypeSelectable{
+				"f1": {"": {{DeviceTypeId: "dt1", Services: []models.Service{{Id: "s1"}}}}},
+			},
+		},
+		calls: map[string]int{},
+	}
+
+	characteristicId := "c1"
+	functionId := "f1"
+	groupId := "g1"
+	deployment := model.Deployment{Deployment: models.Deployment{Id: "dep1", Elements: []models.Element{{ConditionalEvent: &models.ConditionalEvent{
+		EventId: "e_group",
+		Selection: models.Selection{
+			FilterCriteria:        models.FilterCriteria{CharacteristicId: &characteristicId, FunctionId: &functionId},
+			SelectedDeviceGroupId: &groupId,
+		},
+	}}}}}
+
+	//the update message already removed d2 from the group, while the device-repository may still return the old state
+	result, err := NewTransformer(repo, nil).Transform(context.Background(), "owner", deployment, map[string]model.DeviceGroup{
+		"g1": {Id: "g1", DeviceIds: []string{"d1"}},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(result) != 1 || result[0].DeviceId != "d1" {
+		t.Error(result)
+	}
+	if repo.calls["GetDeviceGroups"] != 0 || repo.calls["GetDeviceInfosOfDevices"] != 1 {
+		t.Error(repo.calls)
+	}
+}
+
+func TestTransformFailedDeviceBatch(t *testing.T) {
+	devices := []model.Device{{Id: "d1", DeviceTypeId: "dt1"}, {Id: "d2", DeviceTypeId: "dt1"}, {Id: "d3", DeviceTypeId: "dt1"}}
+	repo := &countingDevices{
+		DevicesMock: mocks.DevicesMock{
+			GetDeviceInfosOfGroupValues: map[string][]model.Device{"g1": devices},
+			GetDeviceTypeSelectablesValues: map[string]map[string][]model.DeviceTypeSelectable{
+				"f1": {"": {{DeviceTypeId: "dt1", Services: []models.Service{{Id: "s1"}}}}},
+			},
+		},
+		calls:     map[string]int{},
+		forbidden: map[string]bool{"d2": true},
+	}
+
+	characteristicId := "c1"
+	functionId := "f1"
+	deployment := model.Deployment{Deployment: models.Deployment{Id: "dep1"}}
+	for _, device := range devices {
+		deviceId := device.Id
+		deployment.Elements = append(deployment.Elements, models.Element{ConditionalEvent: &models.ConditionalEvent{
+			EventId: "e_" + deviceId,
+			Selection: models.Selection{
+				FilterCriteria:   models.FilterCriteria{CharacteristicId: &characteristicId, FunctionId: &functionId},
+				SelectedDeviceId: &deviceId,
+			},
+		}})
+	}
+
+	result, err := NewTransformer(repo, nil).Transform(context.Background(), "owner", deployment, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	actual := []string{}
+	for _, desc := range result {
+		actual = append(actual, desc.DeviceId)
+	}
+	if !reflect.DeepEqual(actual, []string{"d1", "d3"}) {
+		t.Error(actual)
+	}
+	//one failed batch and one request per device
+	if repo.calls["GetDeviceInfosOfDevices"] != 4 {
+		t.Error(repo.calls)
+	}
+}
+
+func TestTransformServicesOfDeviceTypes(t *testing.T) {
+	repo := &countingDevices{
+		DevicesMock: mocks.DevicesMock{
+			GetDeviceInfosOfGroupValues: map[string][]model.Device{"g1": {
+				{Id: "d1", DeviceTypeId: "dt1"},
+				{Id: "d2", DeviceTypeId: "dt1"},
+			}},
+			DeviceTypes: map[string]model.DeviceType{
+				"dt1": {Id: "dt1", Services: []models.Service{{Id: "s1", LocalId: "local_s1"}, {Id: "s2"}}},
+			},
+		},
+		calls: map[string]int{},
+	}
+
+	characteristicId := "c1"
+	deployment := model.Deployment{Deployment: models.Deployment{Id: "dep1"}}
+	for _, selection := range [][2]string{{"d1", "s1"}, {"d2", "s1"}, {"d1", "s3"}} {
+		deviceId, serviceId := selection[0], selection[1]
+		deployment.Elements = append(deployment.Elements, models.Element{ConditionalEvent: &models.ConditionalEvent{
+			EventId: "e_" + deviceId + "_" + serviceId,
+			Selection: models.Selection{
+				FilterCriteria:    models.FilterCriteria{CharacteristicId: &characteristicId},
+				SelectedDeviceId:  &deviceId,
+				SelectedServiceId: &serviceId,
+			},
+		}})
+	}
+
+	result, err := NewTransformer(repo, nil).Transform(context.Background(), "owner", deployment, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(result) != 3 {
+		t.Error(len(result))
+		return
+	}
+	if result[0].ServiceForMarshaller.LocalId != "local_s1" || result[1].ServiceForMarshaller.LocalId != "local_s1" {
+		t.Error(result[0].ServiceForMarshaller, result[1].ServiceForMarshaller)
+	}
+	//s3 is not part of dt1 and is requested on its own
+	expected := map[string]int{
+		"GetDeviceInfosOfDevices": 1,
+		"GetDeviceTypes":          1,
+		"GetService":              1,
+	}
+	for method, count := range expected {
+		if repo.calls[method] != count {
+			t.Error(method, repo.calls[method], count)
+		}
+	}
+}
@@ -22,14 +22,20 @@ import (
 	"errors"
 	"github.com/SENERGY-Platform/event-deployment/lib/auth"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/events/analyticsevents"
 	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/deployments"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/leader"
 	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"github.com/SENERGY-Platform/models/go/models"
 	"log"
 	"net/http"
 	"runtime/debug"
+	"sync"
+	"time"
 )
 
 type EventsFactory struct{}
@@ -38,36 +44,91 @@ var Factory = &EventsFactory{}
 
 type Events struct {
 	config       config.Config
+	analytics    interfaces.Analytics
 	handlers     []Handler
 	doneProducer interfaces.Producer
 	metrics      *metrics.Metrics
+	elector      *leader.Elector
+	schedule     ScheduleRepository
+	history      HistoryRepository
+	auditLog     interfaces.AuditLog
+	replay       *replay
+	//publishes rollbacks to the deployment topic; if nil, rollbacks are only applied locally
+	deploymentProducer interfaces.Producer
+	//held for reading while commands and api mutations are handled and for writing while a rebuild replay runs
+	rebuild sync.RWMutex
+	//if set, deployments, removals and their outbox entries are written in one transaction
+	transactions TransactionRepository
+	outbox       OutboxRepository
 }
 
 type Handler interface {
-	GetEventStates(token string, ids []string) (states map[string]bool, err error, code int)
-	CheckEvent(token string, id string) int
-	Remove(owner string, deploymentId string) error
-	Deploy(owner string, deployment models.Deployment) error
-	UpdateDeviceGroup(groupId string) error
+	GetEventStates(ctx context.Context, token string, ids []string) (states map[string]bool, err error)
+	CheckEvent(ctx context.Context, token string, id string) int
+	GetEventDetails(ctx context.Context, token string, id string) (details model.EventDetails, err error)
+	Remove(ctx context.Context, owner string, deploymentId string) error
+	Deploy(ctx context.Context, owner string, deployment model.Deployment) error
+	UpdateDeviceGroup(ctx context.Context, groupId string, group *model.DeviceGroup) error
+	RemoveDeviceGroup(ctx context.Context, groupId string) error
+	//RemoveDeploymentsExcept removes all stored deployments, which are not in keep; it finishes a rebuild replay
+	RemoveDeploymentsExcept(ctx context.Context, keep map[string]bool) error
 }
 
-func (this *EventsFactory) New(ctx context.Context, config config.Config, analytics interfaces.Analytics, devices interfaces.Devices, imports interfaces.Imports, doneProducer interfaces.Producer, m *metrics.Metrics) (result interfaces.Events, err error) {
+// TransactionalHandler is implemented by handlers, which only write to the repository of the transaction, when a deployment is stored or removed.
+// PrepareDeploy makes the requests to other services, before the transaction starts; the returned function writes the prepared events in the transaction.
+// the other handlers are called before the transaction.
+type TransactionalHandler interface {
+	PrepareDeploy(ctx context.Context, owner string, deployment model.Deployment) (store func(ctx context.Context) error, err error)
+}
+
+// max attempts to prepare a deployment, if device groups change while it is prepared
+const preparationConflictRetries = 3
+
+func (this *EventsFactory) New(ctx context.Context, wg *sync.WaitGroup, config config.Config, analytics interfaces.Analytics, devices interfaces.Devices, imports interfaces.Imports, doneProducer interfaces.Producer, m *metrics.Metrics, elector *leader.Elector, auditLog interfaces.AuditLog, replaySource interfaces.ReplaySource, descChangeProducer interfaces.Producer, deploymentProducer interfaces.Producer, repo *deployments.Deployments) (result interfaces.Events, err error) {
+	//repo is shared with the other components of the process; it is only created here, if the caller did not
+	if repo == nil && config.ConditionalEventRepoMongoUrl != "" && config.ConditionalEventRepoMongoUrl != "-" {
+		repo, err = deployments.New(ctx, wg, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if elector == nil {
+		var leaseRepo leader.LeaseRepository
+		if repo != nil && config.ConditionalEventRepoMongoLeaseCollection != "" {
+			leaseRepo = repo
+		}
+		elector, err = leader.New(ctx, wg, config, leaseRepo, m)
+		if err != nil {
+			return nil, err
+		}
+	}
 	handlers := []Handler{}
 	if config.EnableAnalyticsEvents {
-		analyticsEvents, err := analyticsevents.New(ctx, config, analytics, devices, imports, m)
+		var pipelineOwners analyticsevents.OwnerRepository
+		if repo != nil && config.ConditionalEventRepoMongoPipelineOwnerCollection != "" {
+			pipelineOwners = repo
+		}
+		analyticsEvents, err := analyticsevents.New(ctx, wg, config, analytics, devices, imports, m, elector, pipelineOwners)
 		if err != nil {
 			return nil, err
 		}
 		handlers = append(handlers, analyticsEvents)
 	}
-	if config.ConditionalEventRepoMongoUrl != "" && config.ConditionalEventRepoMongoUrl != "-" {
-		conditionalEvents, err := conditionalevents.New(ctx, config, devices, imports, m)
+	if conditionalevents.Enabled(config) {
+		conditionalEvents, err := conditionalevents.New(config, devices, imports, m, repo, descChangeProducer)
 		if err != nil {
 			return nil, err
 		}
 		handlers = append(handlers, conditionalEvents)
 	}
-	return &Events{config: config, handlers: handlers, doneProducer: doneProducer, metrics: m}, err
+	events := &Events{config: config, analytics: analytics, handlers: handlers, doneProducer: doneProducer, deploymentProducer: deploymentProducer, metrics: m, elector: elector, auditLog: auditLog, replay: newReplay(ctx, wg, replaySource)}
+	err = events.startActivationScheduler(ctx, wg, repo)
+	if err != nil {
+		return nil, err
+	}
+	events.useHistory(repo)
+	events.useOutbox(repo)
+	return events, nil
 }
 
 type VersionWrapper struct {
@@ -78,19 +139,53 @@ type VersionWrapper struct {
 }
 
 type DeploymentCommand struct {
-	Command    string             `json:"command"`
-	Id         string             `json:"id"`
-	Owner      string             `json:"owner"`
-	Deployment *models.Deployment `json:"deployment"`
-	Source     string             `json:"source,omitempty"`
-	Version    int64              `json:"version"`
+	Command    string            `json:"command"`
+	Id         string            `json:"id"`
+	Owner      string            `json:"owner"`
+	Deployment *model.Deployment `json:"deployment"`
+	Source     string            `json:"source,omitempty"`
+	Version    int64             `json:"version"`
+	//set by rollbacks of this service, to be stored in the deployment history; the owner is used if not set
+	ChangedBy string `json:"changed_by,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+}
+
+func (this *Events) HandleCommand(ctx context.Context, msg []byte) error {
+	return this.HandleCommandMessage(ctx, interfaces.Message{Topic: this.config.DeploymentTopic, Value: msg})
+}
+
+// HandleCommandMessage handles the command and writes an audit entry with the topic position of the message.
+// while a rebuild replay runs, the command waits until the rebuild finished.
+func (this *Events) HandleCommandMessage(ctx context.Context, msg interfaces.Message) (err error) {
+	defer this.waitForRebuild()()
+	start := time.Now()
+	entry := model.AuditEntry{
+		Time: config.TimeNow(),
+		Source: model.AuditSource{
+			Kind:      model.AuditSourceKafka,
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+		},
+	}
+	err = this.handleCommand(ctx, msg.Value, &entry, false)
+	entry.Finish(time.Since(start), err)
+	auditErr := this.Audit(entry)
+	if auditErr != nil {
+		//the message is retried until it is audited, even if the command was handled or is skipped as permanent error
+		return auditErr
+	}
+	return err
 }
 
-func (this *Events) HandleCommand(msg []byte) error {
+// handleCommand sets who, what and which deployment of the audit entry; ignored commands set the result to ignored.
+// replayed commands do not add history versions and do not send done notifications.
+func (this *Events) handleCommand(ctx context.Context, msg []byte, entry *model.AuditEntry, replayed bool) error {
 	if this.config.Debug {
 		log.Println("DEBUG: receive deployment command:", string(msg))
 	}
 
+	entry.Result = model.AuditResultIgnored
 	version := VersionWrapper{}
 	err := json.Unmarshal(msg, &version)
 	if err != nil {
@@ -98,11 +193,15 @@ func (this *Events) HandleCommand(msg []byte) error {
 		debug.PrintStack()
 		return nil
 	}
+	entry.Who = version.Owner
+	entry.Action = version.Command
+	entry.DeploymentId = version.Id
 	if version.Version != models.CurrentDeploymentModelVersion {
 		log.Println("ERROR: consumed unexpected deployment version", version.Version)
 		if version.Command == "DELETE" {
 			log.Println("handle legacy delete")
-			return this.Remove(version.Owner, version.Id)
+			entry.Result = ""
+			return this.removeDeployment(ctx, version.Owner, version.Id, replayed)
 		}
 		return nil
 	}
@@ -126,10 +225,24 @@ func (this *Events) HandleCommand(msg []byte) error {
 			log.Printf("ERROR: missing owner --> ignore deployment command %#v\n", cmd)
 			return nil
 		}
+		if cmd.Deployment != nil && cmd.Deployment.Activation != nil {
+			err = cmd.Deployment.Activation.Validate()
+			if err != nil {
+				log.Printf("ERROR: invalid activation --> ignore deployment command %v %v\n", cmd.Id, err)
+				return nil
+			}
+		}
 		if cmd.Deployment != nil {
-			err = this.Deploy(cmd.Owner, *cmd.Deployment)
+			entry.Result = ""
+			changedBy := cmd.Owner
+			if cmd.ChangedBy != "" {
+				changedBy = cmd.ChangedBy
+				entry.Who = changedBy
+			}
+			err = this.deployVersion(ctx, cmd.Owner, *cmd.Deployment, changedBy, cmd.Comment, replayed)
 		}
 		if errors.Is(err, auth.ErrUserDoesNotExist) {
+			entry.Result = model.AuditResultIgnored
 			log.Printf("WARNING: user %v does not exist -> DEPLOYMENT WILL BE IGNORED\n", cmd.Owner)
 			return nil
 		}
@@ -139,8 +252,10 @@ func (this *Events) HandleCommand(msg []byte) error {
 			log.Printf("ERROR: missing owner --> ignore deployment delete command %#v\n", cmd)
 			return nil
 		}
-		err = this.Remove(cmd.Owner, cmd.Id)
+		entry.Result = ""
+		err = this.removeDeployment(ctx, cmd.Owner, cmd.Id, replayed)
 		if errors.Is(err, auth.ErrUserDoesNotExist) {
+			entry.Result = model.AuditResultIgnored
 			log.Printf("WARNING: user %v does not exist -> DEPLOYMENT WILL BE IGNORED\n", cmd.Owner)
 			return nil
 		}
@@ -151,21 +266,156 @@ func (this *Events) HandleCommand(msg []byte) error {
 	return nil
 }
 
-func (this *Events) Deploy(owner string, deployment models.Deployment) (err error) {
-	for _, h := range this.handlers {
-		err = h.Deploy(owner, deployment)
+func (this *Events) Deploy(ctx context.Context, owner string, deployment model.Deployment) (err error) {
+	done, err := this.rejectDuringRebuild()
+	if err != nil {
+		return err
+	}
+	defer done()
+	return this.deployVersion(ctx, owner, deployment, owner, "", false)
+}
+
+// deployVersion deploys the deployment and stores it in the history as changed by changedBy.
+// requests to other services are made before the transaction; it only contains the writes of the deployment, its events, its history and its outbox entries.
+func (this *Events) deployVersion(ctx context.Context, owner string, deployment model.Deployment, changedBy string, comment string, replayed bool) (err error) {
+	entry, err := this.newScheduledDeployment(owner, deployment)
+	if err != nil {
+		return err
+	}
+	active := entry == nil || entry.Active
+	err = this.applyExternal(ctx, owner, deployment, active)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < preparationConflictRetries; i++ {
+		var stores []func(ctx context.Context) error
+		stores, err = this.prepareStores(ctx, owner, deployment, active)
+		if err != nil {
+			return err
+		}
+		err = this.inTransaction(ctx, func(ctx context.Context) error {
+			return this.deployVersionInTransaction(ctx, owner, deployment, changedBy, comment, replayed, entry, stores)
+		})
+		if !errors.Is(err, conditionalevents.ErrOutdatedPreparation) {
+			return err
+		}
+		log.Println("WARNING: device groups changed while deployment", deployment.Id, "was prepared --> prepare again")
+	}
+	return err
+}
+
+func (this *Events) deployVersionInTransaction(ctx context.Context, owner string, deployment model.Deployment, changedBy string, comment string, replayed bool, entry *deployments.ScheduledDeployment, stores []func(ctx context.Context) error) (err error) {
+	if !replayed {
+		err = this.addVersion(ctx, owner, deployment, changedBy, comment)
+		if err != nil {
+			return err
+		}
+	}
+	if entry != nil {
+		err = this.schedule.SetScheduledDeployment(ctx, *entry)
+		if err != nil {
+			return err
+		}
+	}
+	for _, store := range stores {
+		err = store(ctx)
 		if err != nil {
 			return err
 		}
 	}
 	this.metrics.DeployedProcesses.Inc()
-	this.notifyProcessDeploymentDone(deployment.Id)
+	if replayed {
+		return nil
+	}
+	return this.notifyProcessDeploymentDone(ctx, deployment.Id)
+}
+
+// applyExternal deploys the events of handlers, which are not written in the transaction of the deployment, or removes them if the deployment is not active
+func (this *Events) applyExternal(ctx context.Context, owner string, deployment model.Deployment, active bool) (err error) {
+	for _, h := range this.handlers {
+		if _, ok := h.(TransactionalHandler); ok {
+			continue
+		}
+		if active {
+			err = h.Deploy(ctx, owner, deployment)
+		} else {
+			err = h.Remove(ctx, owner, deployment.Id)
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (this *Events) Remove(owner string, deploymentId string) (err error) {
+// prepareStores returns the writes of the transactional handlers; inactive deployments are removed from them
+func (this *Events) prepareStores(ctx context.Context, owner string, deployment model.Deployment, active bool) (stores []func(ctx context.Context) error, err error) {
+	for _, h := range this.handlers {
+		transactional, ok := h.(TransactionalHandler)
+		if !ok {
+			continue
+		}
+		if !active {
+			handler := h
+			stores = append(stores, func(ctx context.Context) error {
+				return handler.Remove(ctx, owner, deployment.Id)
+			})
+			continue
+		}
+		store, err := transactional.PrepareDeploy(ctx, owner, deployment)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, store)
+	}
+	return stores, nil
+}
+
+func (this *Events) Remove(ctx context.Context, owner string, deploymentId string) (err error) {
+	done, err := this.rejectDuringRebuild()
+	if err != nil {
+		return err
+	}
+	defer done()
+	return this.removeDeployment(ctx, owner, deploymentId, false)
+}
+
+// removeDeployment removes the deployment and stores the removal in the history, if it is not replayed.
+// handlers, which are not written in the transaction, are called before it.
+func (this *Events) removeDeployment(ctx context.Context, owner string, deploymentId string, replayed bool) (err error) {
+	//the schedule entry is removed first, to prevent a concurrent activation by the scheduler
+	if this.schedule != nil {
+		err = this.schedule.RemoveScheduledDeployment(ctx, deploymentId)
+		if err != nil {
+			return err
+		}
+	}
+	for _, h := range this.handlers {
+		if _, ok := h.(TransactionalHandler); ok {
+			continue
+		}
+		err = h.Remove(ctx, owner, deploymentId)
+		if err != nil {
+			return err
+		}
+	}
+	return this.inTransaction(ctx, func(ctx context.Context) error {
+		return this.removeDeploymentInTransaction(ctx, owner, deploymentId, replayed)
+	})
+}
+
+func (this *Events) removeDeploymentInTransaction(ctx context.Context, owner string, deploymentId string, replayed bool) (err error) {
 	for _, h := range this.handlers {
-		err = h.Remove(owner, deploymentId)
+		if _, ok := h.(TransactionalHandler); !ok {
+			continue
+		}
+		err = h.Remove(ctx, owner, deploymentId)
+		if err != nil {
+			return err
+		}
+	}
+	if !replayed {
+		err = this.addRemovedVersion(ctx, owner, deploymentId)
 		if err != nil {
 			return err
 		}
@@ -174,9 +424,41 @@ func (this *Events) Remove(owner string, deploymentId string) (err error) {
 	return nil
 }
 
-func (this *Events) CheckEvent(token string, id string) (result int) {
+func (this *Events) deploy(ctx context.Context, owner string, deployment model.Deployment) (err error) {
+	for _, h := range this.handlers {
+		err = h.Deploy(ctx, owner, deployment)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *Events) remove(ctx context.Context, owner string, deploymentId string) (err error) {
 	for _, h := range this.handlers {
-		result = h.CheckEvent(token, id)
+		err = h.Remove(ctx, owner, deploymentId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Audit writes the entry to the audit log, if one is configured; write errors are transient, to retry the audited kafka message
+func (this *Events) Audit(entry model.AuditEntry) error {
+	if this.auditLog == nil {
+		return nil
+	}
+	err := this.auditLog.Write(entry)
+	if err != nil {
+		return errs.Transient("audit", err)
+	}
+	return nil
+}
+
+func (this *Events) CheckEvent(ctx context.Context, token string, id string) (result int) {
+	for _, h := range this.handlers {
+		result = h.CheckEvent(ctx, token, id)
 		if result == http.StatusOK || result == http.StatusBadRequest || result == http.StatusInternalServerError {
 			return result
 		}
@@ -184,12 +466,29 @@ func (this *Events) CheckEvent(token string, id string) (result int) {
 	return http.StatusNotFound
 }
 
-func (this *Events) GetEventStates(token string, ids []string) (states map[string]bool, err error, code int) {
+// GetEventDetails returns errs.ErrNotFound if no handler has deployed anything for the event
+func (this *Events) GetEventDetails(ctx context.Context, token string, id string) (details model.EventDetails, err error) {
+	details.EventId = id
+	for _, h := range this.handlers {
+		temp, err := h.GetEventDetails(ctx, token, id)
+		if err != nil {
+			return details, err
+		}
+		details.Pipelines = append(details.Pipelines, temp.Pipelines...)
+		details.ConditionalEvents = append(details.ConditionalEvents, temp.ConditionalEvents...)
+	}
+	if len(details.Pipelines) == 0 && len(details.ConditionalEvents) == 0 {
+		return details, errs.NotFound("", errors.New("event not found"))
+	}
+	return details, nil
+}
+
+func (this *Events) GetEventStates(ctx context.Context, token string, ids []string) (states map[string]bool, err error) {
 	states = map[string]bool{}
 	for _, h := range this.handlers {
-		temp, err, code := h.GetEventStates(token, ids)
+		temp, err := h.GetEventStates(ctx, token, ids)
 		if err != nil {
-			return states, err, code
+			return states, err
 		}
 		for key, value := range temp {
 			if !states[key] {
@@ -197,30 +496,42 @@ func (this *Events) GetEventStates(token string, ids []string) (states map[strin
 			}
 		}
 	}
-	return states, nil, http.StatusOK
+	return states, nil
 }
 
-func (this *Events) notifyProcessDeploymentDone(id string) {
-	if this.doneProducer != nil {
-		message := DoneNotification{
-			Command: "PUT",
-			Id:      id,
-			Handler: "github.com/SENERGY-Platform/event-deployment",
-		}
-		log.Println("send deployment done", message)
-		msg, err := json.Marshal(message)
-		if err != nil {
-			log.Println("ERROR:", err)
-			debug.PrintStack()
-			return
-		}
-		err = this.doneProducer.Produce(id, msg)
-		if err != nil {
-			log.Println("ERROR:", err)
-			debug.PrintStack()
-			return
+func (this *Events) ExchangePipelineCredential(ctx context.Context, key string) (token string, err error) {
+	result, err := this.analytics.ExchangePipelineCredential(ctx, key)
+	return string(result), err
+}
+
+// notifyProcessDeploymentDone returns errors of the outbox, to roll back the transaction of the deployment and to retry the command.
+// without outbox, produce errors are only logged, because the deployment is already stored.
+func (this *Events) notifyProcessDeploymentDone(ctx context.Context, id string) error {
+	if this.doneProducer == nil {
+		return nil
+	}
+	message := DoneNotification{
+		Command: "PUT",
+		Id:      id,
+		Handler: "github.com/SENERGY-Platform/event-deployment",
+	}
+	log.Println("send deployment done", message)
+	msg, err := json.Marshal(message)
+	if err != nil {
+		log.Println("ERROR:", err)
+		debug.PrintStack()
+		return err
+	}
+	err = this.doneProducer.Produce(ctx, id, msg)
+	if err != nil {
+		log.Println("ERROR:", err)
+		debug.PrintStack()
+		if this.transactions == nil {
+			return nil
 		}
+		return err
 	}
+	return nil
 }
 
 type DoneNotification struct {
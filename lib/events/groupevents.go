@@ -17,13 +17,17 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"log"
 	"runtime/debug"
+	"time"
 )
 
-func (this *Events) HandleDeviceGroupUpdate(msg []byte) error {
+func (this *Events) HandleDeviceGroupUpdate(ctx context.Context, msg []byte) error {
+	defer this.waitForRebuild()()
 	if this.config.Debug {
 		log.Println("DEBUG: receive device-group command:", string(msg))
 	}
@@ -33,7 +37,46 @@ func (this *Events) HandleDeviceGroupUpdate(msg []byte) error {
 		debug.PrintStack()
 		return err
 	}
-	return this.UpdateDeviceGroup(cmd.Id)
+	return this.handleAuditedDeviceGroupCommand(ctx, cmd)
+}
+
+// HandleDeviceGroupUpdates coalesces the messages per group id and handles the last command of each group once, in order of their first message.
+// malformed messages are logged and skipped, to not block the valid messages of the batch.
+func (this *Events) HandleDeviceGroupUpdates(ctx context.Context, msgs [][]byte) error {
+	defer this.waitForRebuild()()
+	groupIds := []string{}
+	commands := map[string]DeviceGroupCommand{}
+	for _, msg := range msgs {
+		if this.config.Debug {
+			log.Println("DEBUG: receive device-group command:", string(msg))
+		}
+		cmd := DeviceGroupCommand{}
+		err := json.Unmarshal(msg, &cmd)
+		if err != nil {
+			log.Println("ERROR: skip malformed device-group command:", err, string(msg))
+			entry := this.newDeviceGroupAuditEntry()
+			entry.Result = model.AuditResultIgnored
+			err = this.Audit(entry)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := commands[cmd.Id]; !ok {
+			groupIds = append(groupIds, cmd.Id)
+		}
+		commands[cmd.Id] = cmd
+	}
+	if len(msgs) > len(groupIds) {
+		log.Printf("coalesce %v device-group messages to %v updates\n", len(msgs), len(groupIds))
+	}
+	for _, groupId := range groupIds {
+		err := this.handleAuditedDeviceGroupCommand(ctx, commands[groupId])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type DeviceGroupCommand struct {
@@ -42,9 +85,78 @@ type DeviceGroupCommand struct {
 	DeviceGroup model.DeviceGroup `json:"device_group"`
 }
 
-func (this *Events) UpdateDeviceGroup(groupId string) (err error) {
+// handleAuditedDeviceGroupCommand handles the command and writes an audit entry for the device-group
+func (this *Events) handleAuditedDeviceGroupCommand(ctx context.Context, cmd DeviceGroupCommand) error {
+	start := time.Now()
+	entry := this.newDeviceGroupAuditEntry()
+	entry.Action = model.AuditActionGroupUpdate
+	if cmd.Command == "DELETE" {
+		entry.Action = model.AuditActionDelete
+	}
+	entry.DeviceGroupId = cmd.Id
+	err := this.handleDeviceGroupCommand(ctx, cmd)
+	entry.Finish(time.Since(start), err)
+	auditErr := this.Audit(entry)
+	if auditErr != nil {
+		return auditErr
+	}
+	return err
+}
+
+func (this *Events) newDeviceGroupAuditEntry() model.AuditEntry {
+	return model.AuditEntry{
+		Time: config.TimeNow(),
+		Source: model.AuditSource{
+			Kind:  model.AuditSourceKafka,
+			Topic: this.config.DeviceGroupTopic,
+		},
+	}
+}
+
+// handleDeviceGroupCommand uses the group of the message, if it is set; other commands than DELETE are handled as updates
+func (this *Events) handleDeviceGroupCommand(ctx context.Context, cmd DeviceGroupCommand) error {
+	if cmd.Command == "DELETE" {
+		return this.removeDeviceGroup(ctx, cmd.Id)
+	}
+	var group *model.DeviceGroup
+	if cmd.Command == "PUT" && cmd.DeviceGroup.Id == cmd.Id {
+		group = &cmd.DeviceGroup
+	}
+	return this.updateDeviceGroup(ctx, cmd.Id, group)
+}
+
+// UpdateDeviceGroup redeploys the events of the group; if group is nil, it is requested from the device-repository
+func (this *Events) UpdateDeviceGroup(ctx context.Context, groupId string, group *model.DeviceGroup) (err error) {
+	done, err := this.rejectDuringRebuild()
+	if err != nil {
+		return err
+	}
+	defer done()
+	return this.updateDeviceGroup(ctx, groupId, group)
+}
+
+func (this *Events) updateDeviceGroup(ctx context.Context, groupId string, group *model.DeviceGroup) (err error) {
+	for _, h := range this.handlers {
+		err = h.UpdateDeviceGroup(ctx, groupId, group)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *Events) RemoveDeviceGroup(ctx context.Context, groupId string) (err error) {
+	done, err := this.rejectDuringRebuild()
+	if err != nil {
+		return err
+	}
+	defer done()
+	return this.removeDeviceGroup(ctx, groupId)
+}
+
+func (this *Events) removeDeviceGroup(ctx context.Context, groupId string) (err error) {
 	for _, h := range this.handlers {
-		err = h.UpdateDeviceGroup(groupId)
+		err = h.RemoveDeviceGroup(ctx, groupId)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package events
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type auditLogMock struct {
+	entries []model.AuditEntry
+	err     error
+}
+
+func (this *auditLogMock) Write(entry model.AuditEntry) error {
+	entry.Time = time.Time{}
+	entry.DurationMs = 0
+	this.entries = append(this.entries, entry)
+	return this.err
+}
+
+func TestDeviceGroupUpdateAudit(t *testing.T) {
+	msgs := [][]byte{
+		[]byte(`{"command":"PUT","id":"g1"}`),
+		[]byte(`{"command":`),
+		[]byte(`{"command":"DELETE","id":"g2"}`),
+		[]byte(`{"command":"PUT","id":"g1"}`),
+	}
+	conf := &config.ConfigStruct{DeviceGroupTopic: "device-groups"}
+	source := model.AuditSource{Kind: model.AuditSourceKafka, Topic: "device-groups"}
+
+	t.Run("audited", func(t *testing.T) {
+		auditLog := &auditLogMock{}
+		events := &Events{config: conf, auditLog: auditLog}
+		err := events.HandleDeviceGroupUpdates(context.Background(), msgs)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expected := []model.AuditEntry{
+			{Source: source, Result: model.AuditResultIgnored},
+			{Action: model.AuditActionGroupUpdate, DeviceGroupId: "g1", Source: source, Result: model.AuditResultSuccess},
+			{Action: model.AuditActionDelete, DeviceGroupId: "g2", Source: source, Result: model.AuditResultSuccess},
+		}
+		if !reflect.DeepEqual(auditLog.entries, expected) {
+			t.Errorf("\n%#v\n%#v\n", auditLog.entries, expected)
+		}
+	})
+
+	t.Run("failing audit log", func(t *testing.T) {
+		events := &Events{config: conf, auditLog: &auditLogMock{err: errors.New("test")}}
+		err := events.HandleDeviceGroupUpdate(context.Background(), msgs[0])
+		if err == nil || errs.IsSkippable(err) {
+			t.Error("expected transient error to retry the message", err)
+		}
+	})
+}
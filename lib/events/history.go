@@ -0,0 +1,209 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/deployments"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"log"
+	"runtime/debug"
+)
+
+type HistoryRepository interface {
+	AddDeploymentVersion(ctx context.Context, element model.DeploymentVersion, limit int64) (result model.DeploymentVersion, err error)
+	GetLatestDeploymentVersion(ctx context.Context, deploymentId string) (result model.DeploymentVersion, exists bool, err error)
+	GetDeploymentVersion(ctx context.Context, deploymentId string, version int64) (result model.DeploymentVersion, exists bool, err error)
+	ListDeploymentVersions(ctx context.Context, deploymentId string) (result []model.DeploymentVersion, err error)
+}
+
+var ErrNoHistory = errs.NotImplemented("", errors.New("no deployment history configured"))
+
+func (this *Events) useHistory(repo *deployments.Deployments) {
+	if repo == nil || this.config.ConditionalEventRepoMongoHistoryCollection == "" {
+		return
+	}
+	this.history = repo
+}
+
+// addVersion stores the deployment as new version, if it differs from the latest version.
+// retried and repeated deploy commands do not create new versions.
+func (this *Events) addVersion(ctx context.Context, owner string, deployment model.Deployment, changedBy string, comment string) error {
+	if this.history == nil {
+		return nil
+	}
+	version := model.DeploymentVersion{
+		DeploymentId:   deployment.Id,
+		Owner:          owner,
+		Deployment:     deployment.Deployment,
+		FilterCriteria: deployment.FilterCriteria,
+		Activation:     deployment.Activation,
+		ChangedBy:      changedBy,
+		ChangedAt:      config.TimeNow(),
+		Comment:        comment,
+	}
+	latest, exists, err := this.history.GetLatestDeploymentVersion(ctx, deployment.Id)
+	if err != nil {
+		return err
+	}
+	if exists && !latest.Removed && latest.Owner == owner {
+		//compared as json, because empty and nil lists are not distinguished by the stored version
+		latestJson, err := json.Marshal(latest.GetDeployment())
+		if err != nil {
+			return err
+		}
+		versionJson, err := json.Marshal(version.GetDeployment())
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(latestJson, versionJson) {
+			return nil
+		}
+	}
+	_, err = this.history.AddDeploymentVersion(ctx, version, this.config.DeploymentHistoryLimit)
+	return err
+}
+
+func (this *Events) addRemovedVersion(ctx context.Context, owner string, deploymentId string) error {
+	if this.history == nil {
+		return nil
+	}
+	latest, exists, err := this.history.GetLatestDeploymentVersion(ctx, deploymentId)
+	if err != nil || !exists || latest.Removed {
+		return err
+	}
+	_, err = this.history.AddDeploymentVersion(ctx, model.DeploymentVersion{
+		DeploymentId: deploymentId,
+		Owner:        owner,
+		Removed:      true,
+		ChangedBy:    owner,
+		ChangedAt:    config.TimeNow(),
+	}, this.config.DeploymentHistoryLimit)
+	return err
+}
+
+// ListDeploymentVersions returns the versions of the deployment, newest first.
+// only admins and the owner of the latest version may read the history.
+func (this *Events) ListDeploymentVersions(ctx context.Context, token string, deploymentId string) (result []model.DeploymentVersion, err error) {
+	if this.history == nil {
+		return result, ErrNoHistory
+	}
+	claims, err := auth.ParseUnverified(token)
+	if err != nil {
+		return result, errs.Invalid("", err)
+	}
+	result, err = this.history.ListDeploymentVersions(ctx, deploymentId)
+	if err != nil {
+		log.Println("ERROR:", err)
+		debug.PrintStack()
+		return result, err
+	}
+	if len(result) == 0 || (!claims.IsAdmin() && result[0].Owner != claims.Sub) {
+		return []model.DeploymentVersion{}, errs.NotFound("", errors.New("deployment history not found"))
+	}
+	return result, nil
+}
+
+// RollbackDeployment deploys a previous version again.
+// if a deployment producer is configured, the rollback is published as deployment command to the deployment topic,
+// so that it is handled like other deployments (done notification, history entry) and is not undone by a replay of the topic;
+// the returned version is the rolled back version and the new version is stored, when the command is consumed.
+// otherwise the rollback is only applied locally and the returned version is the new stored version.
+func (this *Events) RollbackDeployment(ctx context.Context, token string, deploymentId string, version int64) (result model.DeploymentVersion, err error) {
+	if this.history == nil {
+		return result, ErrNoHistory
+	}
+	claims, err := auth.ParseUnverified(token)
+	if err != nil {
+		return result, errs.Invalid("", err)
+	}
+	latest, exists, err := this.history.GetLatestDeploymentVersion(ctx, deploymentId)
+	if err != nil {
+		log.Println("ERROR:", err)
+		debug.PrintStack()
+		return result, err
+	}
+	if !exists || (!claims.IsAdmin() && latest.Owner != claims.Sub) {
+		return result, errs.NotFound("", errors.New("deployment history not found"))
+	}
+	target, exists, err := this.history.GetDeploymentVersion(ctx, deploymentId, version)
+	if err != nil {
+		log.Println("ERROR:", err)
+		debug.PrintStack()
+		return result, err
+	}
+	if !exists {
+		return result, errs.NotFound("", errors.New("deployment version not found"))
+	}
+	if target.Removed {
+		return result, errs.Invalid("", errors.New("version is a removal of the deployment"))
+	}
+	comment := fmt.Sprintf("rollback to version %v", version)
+	if this.deploymentProducer != nil {
+		err = this.publishRollback(ctx, target, claims.Sub, comment)
+		if err != nil {
+			log.Println("ERROR:", err)
+			debug.PrintStack()
+			return result, err
+		}
+		return target, nil
+	}
+	done, err := this.rejectDuringRebuild()
+	if err != nil {
+		return result, err
+	}
+	defer done()
+	err = this.deployVersion(ctx, target.Owner, target.GetDeployment(), claims.Sub, comment, false)
+	if err != nil {
+		log.Println("ERROR:", err)
+		debug.PrintStack()
+		return result, err
+	}
+	result, _, err = this.history.GetLatestDeploymentVersion(ctx, deploymentId)
+	if err != nil {
+		log.Println("ERROR:", err)
+		debug.PrintStack()
+		return result, err
+	}
+	return result, nil
+}
+
+func (this *Events) publishRollback(ctx context.Context, target model.DeploymentVersion, changedBy string, comment string) error {
+	deployment := target.GetDeployment()
+	msg, err := json.Marshal(DeploymentCommand{
+		Command:    "PUT",
+		Id:         target.DeploymentId,
+		Owner:      target.Owner,
+		Deployment: &deployment,
+		Source:     "event-deployment",
+		Version:    models.CurrentDeploymentModelVersion,
+		ChangedBy:  changedBy,
+		Comment:    comment,
+	})
+	if err != nil {
+		return err
+	}
+	return this.deploymentProducer.Produce(ctx, target.DeploymentId, msg)
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/golang-jwt/jwt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type historyMock struct {
+	mux      sync.Mutex
+	versions []model.DeploymentVersion
+}
+
+func (this *historyMock) AddDeploymentVersion(ctx context.Context, element model.DeploymentVersion, limit int64) (result model.DeploymentVersion, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	element.Version = 1
+	for _, v := range this.versions {
+		if v.DeploymentId == element.DeploymentId && v.Version >= element.Version {
+			element.Version = v.Version + 1
+		}
+	}
+	this.versions = append(this.versions, element)
+	return element, nil
+}
+
+func (this *historyMock) GetLatestDeploymentVersion(ctx context.Context, deploymentId string) (result model.DeploymentVersion, exists bool, err error) {
+	list, _ := this.ListDeploymentVersions(ctx, deploymentId)
+	if len(list) == 0 {
+		return result, false, nil
+	}
+	return list[0], true, nil
+}
+
+func (this *historyMock) GetDeploymentVersion(ctx context.Context, deploymentId string, version int64) (result model.DeploymentVersion, exists bool, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for _, v := range this.versions {
+		if v.DeploymentId == deploymentId && v.Version == version {
+			return v, true, nil
+		}
+	}
+	return result, false, nil
+}
+
+func (this *historyMock) ListDeploymentVersions(ctx context.Context, deploymentId string) (result []model.DeploymentVersion, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for i := len(this.versions) - 1; i >= 0; i-- {
+		if this.versions[i].DeploymentId == deploymentId {
+			result = append(result, this.versions[i])
+		}
+	}
+	return result, nil
+}
+
+type producerMock struct {
+	mux      sync.Mutex
+	messages [][]byte
+	err      error
+}
+
+func (this *producerMock) Produce(ctx context.Context, key string, message []byte) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if this.err != nil {
+		return this.err
+	}
+	this.messages = append(this.messages, message)
+	return nil
+}
+
+func (this *producerMock) take() (result [][]byte) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	result, this.messages = this.messages, nil
+	return result
+}
+
+func TestRollbackDeployment(t *testing.T) {
+	ctx := context.Background()
+	token := func(t *testing.T, user string) string {
+		result, err := auth.GenerateInternalUserToken(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	setup := func(t *testing.T, deploymentProducer *producerMock) (events *Events, history *historyMock, done *producerMock) {
+		history = &historyMock{}
+		done = &producerMock{}
+		events = &Events{config: &config.ConfigStruct{}, metrics: metrics.New(), history: history, doneProducer: done}
+		if deploymentProducer != nil {
+			events.deploymentProducer = deploymentProducer
+		}
+		for _, name := range []string{"v1", "v2"} {
+			err := events.Deploy(ctx, "owner", model.Deployment{Deployment: models.Deployment{Id: "dep", Name: name}})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := events.Remove(ctx, "owner", "dep")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = events.Deploy(ctx, "owner", model.Deployment{Deployment: models.Deployment{Id: "dep", Name: "v4"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		done.take()
+		return events, history, done
+	}
+
+	t.Run("foreign user", func(t *testing.T) {
+		events, _, _ := setup(t, nil)
+		_, err := events.RollbackDeployment(ctx, token(t, "stranger"), "dep", 1)
+		if !errors.Is(err, errs.ErrNotFound) {
+			t.Error(err)
+		}
+	})
+
+	t.Run("removal version", func(t *testing.T) {
+		events, _, _ := setup(t, nil)
+		_, err := events.RollbackDeployment(ctx, token(t, "owner"), "dep", 3)
+		if !errors.Is(err, errs.ErrInvalid) {
+			t.Error(err)
+		}
+	})
+
+	t.Run("local", func(t *testing.T) {
+		events, _, done := setup(t, nil)
+		result, err := events.RollbackDeployment(ctx, token(t, "owner"), "dep", 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if result.Version != 5 || result.Deployment.Name != "v1" || result.ChangedBy != "owner" || result.Comment != "rollback to version 1" {
+			t.Errorf("%#v", result)
+		}
+		if len(done.take()) != 1 {
+			t.Error("expected done notification")
+		}
+	})
+
+	t.Run("published", func(t *testing.T) {
+		deploymentProducer := &producerMock{}
+		events, history, done := setup(t, deploymentProducer)
+		adminToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.Claims{
+			Sub:         "admin",
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+			RealmAccess: map[string][]string{"roles": {"admin"}},
+		}).SignedString([]byte("test"))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		result, err := events.RollbackDeployment(ctx, "Bearer "+adminToken, "dep", 2)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if result.Version != 2 || result.Deployment.Name != "v2" {
+			t.Errorf("%#v", result)
+		}
+		latest, _, _ := history.GetLatestDeploymentVersion(ctx, "dep")
+		if latest.Version != 4 {
+			t.Error("rollback is stored when the command is handled", latest.Version)
+		}
+
+		messages := deploymentProducer.take()
+		if len(messages) != 1 {
+			t.Error(len(messages))
+			return
+		}
+		cmd := DeploymentCommand{}
+		err = json.Unmarshal(messages[0], &cmd)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if cmd.Command != "PUT" || cmd.Id != "dep" || cmd.Owner != "owner" || cmd.Deployment == nil || cmd.Deployment.Name != "v2" || cmd.ChangedBy != "admin" {
+			t.Errorf("%#v", cmd)
+			return
+		}
+
+		err = events.HandleCommand(ctx, messages[0])
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		latest, _, _ = history.GetLatestDeploymentVersion(ctx, "dep")
+		if latest.Version != 5 || latest.Deployment.Name != "v2" || latest.Owner != "owner" || latest.ChangedBy != "admin" || latest.Comment != "rollback to version 2" {
+			t.Errorf("%#v", latest)
+		}
+		if len(done.take()) != 1 {
+			t.Error("expected done notification")
+		}
+	})
+}
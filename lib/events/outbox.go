@@ -0,0 +1,68 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package events
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/deployments"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"log"
+)
+
+type TransactionRepository interface {
+	Transaction(ctx context.Context, f func(ctx context.Context) error) error
+}
+
+type OutboxRepository interface {
+	RequeueParkedOutboxEntries(ctx context.Context) (count int64, err error)
+}
+
+// useOutbox writes the deployment changes and the outbox entries of their messages in one transaction,
+// if conditional_event_repo_mongo_outbox_collection is configured.
+// device group updates and scheduled activations may change many deployments and are not written in one transaction;
+// their description changes are written to the outbox after each description.
+func (this *Events) useOutbox(repo *deployments.Deployments) {
+	if repo == nil || this.config.ConditionalEventRepoMongoOutboxCollection == "" {
+		return
+	}
+	this.transactions = repo
+	this.outbox = repo
+}
+
+// RequeueOutboxEntries lets the outbox relay publish the parked entries again, e.g. after a missing producer was configured
+func (this *Events) RequeueOutboxEntries(ctx context.Context) (result model.OutboxRequeueResult, err error) {
+	if this.outbox == nil {
+		return result, errs.NotImplemented("", errors.New("no outbox configured"))
+	}
+	result.Requeued, err = this.outbox.RequeueParkedOutboxEntries(ctx)
+	if err != nil {
+		return result, err
+	}
+	log.Println("requeued parked outbox entries", result.Requeued)
+	return result, nil
+}
+
+// inTransaction runs f in a transaction, if the outbox is used; otherwise f is called directly.
+// f may be called again, if the transaction is retried.
+func (this *Events) inTransaction(ctx context.Context, f func(ctx context.Context) error) error {
+	if this.transactions == nil {
+		return f(ctx)
+	}
+	return this.transactions.Transaction(ctx, f)
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package events
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents"
+	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"reflect"
+	"testing"
+)
+
+type transactionMock struct {
+	calls  int
+	active bool
+}
+
+func (this *transactionMock) Transaction(ctx context.Context, f func(ctx context.Context) error) error {
+	this.calls++
+	this.active = true
+	defer func() { this.active = false }()
+	return f(ctx)
+}
+
+// scopeHandlerMock records, if it is called in a transaction
+type scopeHandlerMock struct {
+	Handler
+	transactions *transactionMock
+	calls        []string
+}
+
+func (this *scopeHandlerMock) record(call string) {
+	if this.transactions.active {
+		call = call + " in transaction"
+	}
+	this.calls = append(this.calls, call)
+}
+
+func (this *scopeHandlerMock) Deploy(ctx context.Context, owner string, deployment model.Deployment) error {
+	this.record("deploy")
+	return nil
+}
+
+func (this *scopeHandlerMock) Remove(ctx context.Context, owner string, deploymentId string) error {
+	this.record("remove")
+	return nil
+}
+
+type transactionalHandlerMock struct {
+	scopeHandlerMock
+	outdated int
+}
+
+func (this *transactionalHandlerMock) PrepareDeploy(ctx context.Context, owner string, deployment model.Deployment) (store func(ctx context.Context) error, err error) {
+	this.record("prepare")
+	return func(ctx context.Context) error {
+		if this.outdated > 0 {
+			this.outdated--
+			return conditionalevents.ErrOutdatedPreparation
+		}
+		this.record("store")
+		return nil
+	}, nil
+}
+
+func TestDoneNotificationErrors(t *testing.T) {
+	ctx := context.Background()
+	deployment := model.Deployment{Deployment: models.Deployment{Id: "dep", Name: "dep"}}
+	done := &producerMock{err: errors.New("test")}
+
+	t.Run("without outbox", func(t *testing.T) {
+		events := &Events{config: &config.ConfigStruct{}, metrics: metrics.New(), doneProducer: done}
+		err := events.Deploy(ctx, "owner", deployment)
+		if err != nil {
+			t.Error("produce errors should only be logged, because the deployment is stored", err)
+		}
+	})
+
+	t.Run("outbox", func(t *testing.T) {
+		transactions := &transactionMock{}
+		events := &Events{config: &config.ConfigStruct{}, metrics: metrics.New(), doneProducer: done, transactions: transactions}
+		err := events.Deploy(ctx, "owner", deployment)
+		if err == nil {
+			t.Error("outbox errors should roll back the deployment")
+		}
+		err = events.Remove(ctx, "owner", "dep")
+		if err != nil {
+			t.Error(err)
+		}
+		if transactions.calls != 2 {
+			t.Error(transactions.calls)
+		}
+	})
+}
+
+func TestTransactionScope(t *testing.T) {
+	ctx := context.Background()
+	deployment := model.Deployment{Deployment: models.Deployment{Id: "dep", Name: "dep"}}
+	setup := func() (events *Events, transactions *transactionMock, external *scopeHandlerMock, transactional *transactionalHandlerMock) {
+		transactions = &transactionMock{}
+		external = &scopeHandlerMock{transactions: transactions}
+		transactional = &transactionalHandlerMock{scopeHandlerMock: scopeHandlerMock{transactions: transactions}}
+		events = &Events{config: &config.ConfigStruct{}, metrics: metrics.New(), handlers: []Handler{external, transactional}, transactions: transactions}
+		return events, transactions, external, transactional
+	}
+
+	t.Run("deploy", func(t *testing.T) {
+		events, transactions, external, transactional := setup()
+		err := events.Deploy(ctx, "owner", deployment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(external.calls, []string{"deploy"}) {
+			t.Error(external.calls)
+		}
+		if !reflect.DeepEqual(transactional.calls, []string{"prepare", "store in transaction"}) {
+			t.Error(transactional.calls)
+		}
+		if transactions.calls != 1 {
+			t.Error(transactions.calls)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		events, transactions, external, transactional := setup()
+		err := events.Remove(ctx, "owner", "dep")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(external.calls, []string{"remove"}) {
+			t.Error(external.calls)
+		}
+		if !reflect.DeepEqual(transactional.calls, []string{"remove in transaction"}) {
+			t.Error(transactional.calls)
+		}
+		if transactions.calls != 1 {
+			t.Error(transactions.calls)
+		}
+	})
+
+	t.Run("outdated preparation", func(t *testing.T) {
+		events, transactions, external, transactional := setup()
+		transactional.outdated = 1
+		err := events.Deploy(ctx, "owner", deployment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(external.calls, []string{"deploy"}) {
+			t.Error("external handlers should only be called once", external.calls)
+		}
+		if !reflect.DeepEqual(transactional.calls, []string{"prepare", "prepare", "store in transaction"}) {
+			t.Error(transactional.calls)
+		}
+		if transactions.calls != 2 {
+			t.Error(transactions.calls)
+		}
+	})
+
+	t.Run("repeatedly outdated preparation", func(t *testing.T) {
+		events, _, _, transactional := setup()
+		transactional.outdated = preparationConflictRetries
+		err := events.Deploy(ctx, "owner", deployment)
+		if !errors.Is(err, conditionalevents.ErrOutdatedPreparation) {
+			t.Error(err)
+		}
+	})
+}
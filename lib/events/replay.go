@@ -0,0 +1,176 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package events
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/analyticsevents"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"log"
+	"sync"
+)
+
+type replay struct {
+	ctx    context.Context
+	wg     *sync.WaitGroup
+	source interfaces.ReplaySource
+	mux    sync.Mutex
+	status model.ReplayStatus
+}
+
+func newReplay(ctx context.Context, wg *sync.WaitGroup, source interfaces.ReplaySource) *replay {
+	return &replay{ctx: ctx, wg: wg, source: source}
+}
+
+var ErrRebuildRunning = errs.Conflict("", errors.New("deployment rebuild is running"))
+
+var ErrRebuildRequiresSingleInstance = errs.NotImplemented("", errors.New("deployment rebuild requires single_instance"))
+
+// StartReplay handles the requested messages of the deployment topic in the background; only one replay runs at a time.
+// replayed commands are not audited, do not add history versions and do not send done notifications.
+// a rebuild is not supported with analytics events, see analyticsevents.Events.RemoveDeploymentsExcept.
+// a rebuild is only supported with config.SingleInstance, because it can only pause the consumers and api of this instance:
+// other instances would apply newer commands, which older replayed commands overwrite, and create deployments, which the rebuild removes.
+func (this *Events) StartReplay(request model.ReplayRequest) (status model.ReplayStatus, err error) {
+	if this.replay.source == nil {
+		return status, errs.NotImplemented("", errors.New("no replay source configured"))
+	}
+	if request.Mode == model.ReplayModeRebuild && !this.config.SingleInstance {
+		return status, ErrRebuildRequiresSingleInstance
+	}
+	if request.Mode == model.ReplayModeRebuild && this.config.EnableAnalyticsEvents {
+		return status, analyticsevents.ErrRebuildNotImplemented
+	}
+	switch request.Mode {
+	case "", model.ReplayModeRange:
+		request.Mode = model.ReplayModeRange
+		if request.Partition < 0 {
+			return status, errs.Invalid("", errors.New("partition must not be negative"))
+		}
+		if request.EndOffset >= 0 && request.EndOffset < request.StartOffset {
+			return status, errs.Invalid("", errors.New("end_offset must not be less than start_offset"))
+		}
+	case model.ReplayModeRebuild:
+		request.Partition = -1
+		request.StartOffset = -1
+		request.EndOffset = -1
+	default:
+		return status, errs.Invalid("", errors.New("unknown replay mode "+request.Mode))
+	}
+
+	this.replay.mux.Lock()
+	defer this.replay.mux.Unlock()
+	if this.replay.status.Running {
+		return this.replay.status, errs.Conflict("", errors.New("replay is already running"))
+	}
+	this.replay.status = model.ReplayStatus{Request: request, Running: true, Started: config.TimeNow()}
+	this.replay.wg.Add(1)
+	go func() {
+		defer this.replay.wg.Done()
+		err := this.runReplay(this.replay.ctx, request)
+		if err != nil {
+			log.Println("ERROR: replay failed", request, err)
+		} else {
+			log.Println("replay finished", request)
+		}
+		this.replay.mux.Lock()
+		defer this.replay.mux.Unlock()
+		finished := config.TimeNow()
+		this.replay.status.Running = false
+		this.replay.status.Finished = &finished
+		if err != nil {
+			this.replay.status.Error = err.Error()
+		}
+	}()
+	return this.replay.status, nil
+}
+
+// GetReplayStatus returns the status of the running or last replay
+func (this *Events) GetReplayStatus() model.ReplayStatus {
+	this.replay.mux.Lock()
+	defer this.replay.mux.Unlock()
+	return this.replay.status
+}
+
+// waitForRebuild blocks consumed messages while a rebuild runs; the returned function has to be called after the message was handled
+func (this *Events) waitForRebuild() (done func()) {
+	this.rebuild.RLock()
+	return this.rebuild.RUnlock
+}
+
+// rejectDuringRebuild returns ErrRebuildRunning instead of blocking api mutations until the rebuild finished;
+// the returned function has to be called after the mutation
+func (this *Events) rejectDuringRebuild() (done func(), err error) {
+	if !this.rebuild.TryRLock() {
+		return nil, ErrRebuildRunning
+	}
+	return this.rebuild.RUnlock, nil
+}
+
+// runReplay handles the messages without audit entries, history versions and done notifications.
+// a rebuild pauses the consumers of this (single) instance, so that their newer commands are not overwritten by older replayed commands;
+// stored deployments are replaced in place and only deployments without command in the topic are removed after the replay,
+// so that the events of the other deployments stay active during the rebuild.
+func (this *Events) runReplay(ctx context.Context, request model.ReplayRequest) error {
+	log.Println("start replay", request)
+	rebuild := request.Mode == model.ReplayModeRebuild
+	if rebuild {
+		this.rebuild.Lock()
+		defer this.rebuild.Unlock()
+	}
+	deployed := map[string]bool{}
+	err := this.replay.source.ReadRange(ctx, this.config, this.config.DeploymentTopic, request.Partition, request.StartOffset, request.EndOffset, func(msg interfaces.Message) error {
+		entry := model.AuditEntry{}
+		err := this.handleCommand(ctx, msg.Value, &entry, true)
+		if err == nil && entry.Result == "" {
+			switch entry.Action {
+			case "PUT":
+				deployed[entry.DeploymentId] = true
+			case "DELETE":
+				delete(deployed, entry.DeploymentId)
+			}
+		}
+		if err != nil && errs.IsSkippable(err) {
+			log.Println("ERROR: permanent error, skip replayed message", msg.Partition, msg.Offset, errs.Dependency(err), err)
+			this.replay.mux.Lock()
+			this.replay.status.Skipped++
+			this.replay.mux.Unlock()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		this.replay.mux.Lock()
+		this.replay.status.Handled++
+		this.replay.mux.Unlock()
+		return nil
+	})
+	if err != nil || !rebuild {
+		return err
+	}
+	for _, h := range this.handlers {
+		err = h.RemoveDeploymentsExcept(ctx, deployed)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
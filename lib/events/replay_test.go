@@ -0,0 +1,225 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+type replaySourceMock struct {
+	messages [][]byte
+	//if set, the source waits for it before the first message is handled
+	wait chan struct{}
+}
+
+func (this *replaySourceMock) ReadRange(ctx context.Context, config config.Config, topic string, partition int, startOffset int64, endOffset int64, listener func(msg interfaces.Message) error) error {
+	if this.wait != nil {
+		<-this.wait
+	}
+	for i, value := range this.messages {
+		err := listener(interfaces.Message{Topic: topic, Offset: int64(i), Value: value})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type handlerMock struct {
+	Handler
+	mux      sync.Mutex
+	deployed map[string]string
+}
+
+func (this *handlerMock) Deploy(ctx context.Context, owner string, deployment model.Deployment) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.deployed[deployment.Id] = deployment.Name
+	return nil
+}
+
+func (this *handlerMock) Remove(ctx context.Context, owner string, deploymentId string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.deployed, deploymentId)
+	return nil
+}
+
+func (this *handlerMock) RemoveDeploymentsExcept(ctx context.Context, keep map[string]bool) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for id := range this.deployed {
+		if !keep[id] {
+			delete(this.deployed, id)
+		}
+	}
+	return nil
+}
+
+func (this *handlerMock) ids() (result []string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for id := range this.deployed {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestRebuildReplay(t *testing.T) {
+	command := func(t *testing.T, cmd DeploymentCommand) []byte {
+		cmd.Owner = "owner"
+		cmd.Version = models.CurrentDeploymentModelVersion
+		if cmd.Command == "PUT" {
+			cmd.Deployment = &model.Deployment{Deployment: models.Deployment{Id: cmd.Id, Name: cmd.Id}}
+		}
+		result, err := json.Marshal(cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	setup := func(t *testing.T, source *replaySourceMock) (events *Events, handler *handlerMock, history *historyMock, done *producerMock, auditLog *auditLogMock, wg *sync.WaitGroup) {
+		wg = &sync.WaitGroup{}
+		handler = &handlerMock{deployed: map[string]string{"dep1": "old", "dep3": "old"}}
+		history = &historyMock{}
+		done = &producerMock{}
+		auditLog = &auditLogMock{}
+		events = &Events{
+			config:       &config.ConfigStruct{DeploymentTopic: "deployments", SingleInstance: true},
+			handlers:     []Handler{handler},
+			metrics:      metrics.New(),
+			history:      history,
+			doneProducer: done,
+			auditLog:     auditLog,
+			replay:       newReplay(context.Background(), wg, source),
+		}
+		return events, handler, history, done, auditLog, wg
+	}
+
+	t.Run("rebuild", func(t *testing.T) {
+		events, handler, history, done, auditLog, wg := setup(t, &replaySourceMock{messages: [][]byte{
+			command(t, DeploymentCommand{Command: "PUT", Id: "dep1"}),
+			command(t, DeploymentCommand{Command: "PUT", Id: "dep2"}),
+			command(t, DeploymentCommand{Command: "PUT", Id: "dep4"}),
+			command(t, DeploymentCommand{Command: "DELETE", Id: "dep4"}),
+			[]byte(`{"command":`),
+		}})
+		_, err := events.StartReplay(model.ReplayRequest{Mode: model.ReplayModeRebuild})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		wg.Wait()
+		status := events.GetReplayStatus()
+		if status.Error != "" || status.Handled != 5 || status.Skipped != 0 {
+			t.Errorf("%#v", status)
+		}
+		if ids := handler.ids(); !reflect.DeepEqual(ids, []string{"dep1", "dep2"}) {
+			t.Error("deployments without command should be removed, others kept", ids)
+		}
+		if handler.deployed["dep1"] != "dep1" {
+			t.Error("stored deployment should be replaced by the replayed command", handler.deployed["dep1"])
+		}
+		if len(auditLog.entries) != 0 || len(history.versions) != 0 || len(done.take()) != 0 {
+			t.Error("replayed commands should not be audited, stored as version or notified", auditLog.entries, history.versions)
+		}
+	})
+
+	t.Run("paused consumers and api", func(t *testing.T) {
+		source := &replaySourceMock{wait: make(chan struct{}), messages: [][]byte{
+			command(t, DeploymentCommand{Command: "PUT", Id: "dep1"}),
+		}}
+		events, handler, _, _, auditLog, wg := setup(t, source)
+		_, err := events.StartReplay(model.ReplayRequest{Mode: model.ReplayModeRebuild})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		//wait until the rebuild holds the lock
+		for events.rebuild.TryRLock() {
+			events.rebuild.RUnlock()
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		err = events.Deploy(context.Background(), "owner", model.Deployment{Deployment: models.Deployment{Id: "dep5", Name: "dep5"}})
+		if !errors.Is(err, ErrRebuildRunning) || !errors.Is(err, errs.ErrConflict) {
+			t.Error(err)
+		}
+
+		msg := command(t, DeploymentCommand{Command: "PUT", Id: "dep6"})
+		consumed := make(chan error)
+		go func() {
+			consumed <- events.HandleCommand(context.Background(), msg)
+		}()
+		select {
+		case err = <-consumed:
+			t.Error("consumed command should wait for the rebuild", err)
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		close(source.wait)
+		err = <-consumed
+		if err != nil {
+			t.Error(err)
+		}
+		wg.Wait()
+		if ids := handler.ids(); !reflect.DeepEqual(ids, []string{"dep1", "dep6"}) {
+			t.Error("consumed command should be handled after the rebuild", ids)
+		}
+		if len(auditLog.entries) != 1 || auditLog.entries[0].DeploymentId != "dep6" {
+			t.Error("only the consumed command should be audited", auditLog.entries)
+		}
+	})
+
+	t.Run("multiple instances", func(t *testing.T) {
+		events, _, _, _, _, wg := setup(t, &replaySourceMock{})
+		events.config.SingleInstance = false
+		_, err := events.StartReplay(model.ReplayRequest{Mode: model.ReplayModeRebuild})
+		if !errors.Is(err, ErrRebuildRequiresSingleInstance) {
+			t.Error(err)
+		}
+		_, err = events.StartReplay(model.ReplayRequest{Mode: model.ReplayModeRange})
+		if err != nil {
+			t.Error("range replays should not require single_instance", err)
+		}
+		wg.Wait()
+	})
+
+	t.Run("analytics events", func(t *testing.T) {
+		events, _, _, _, _, _ := setup(t, &replaySourceMock{})
+		events.config.EnableAnalyticsEvents = true
+		_, err := events.StartReplay(model.ReplayRequest{Mode: model.ReplayModeRebuild})
+		if !errors.Is(err, errs.ErrNotImplemented) {
+			t.Error(err)
+		}
+	})
+}
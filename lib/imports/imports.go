@@ -17,15 +17,21 @@
 package imports
 
 import (
+	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/auth"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
 	"github.com/SENERGY-Platform/models/go/models"
-	"net/http"
 	"net/url"
 	"runtime/debug"
 )
 
+// names of the dependencies in errors
+const (
+	dependencyImportDeploy     = "import-deploy"
+	dependencyImportRepository = "import-repository"
+)
+
 type FactoryType struct{}
 
 func (this *FactoryType) New(config config.Config) (interfaces.Imports, error) {
@@ -50,40 +56,40 @@ func New(config config.Config) (*Imports, error) {
 	}, nil
 }
 
-func (this *Imports) GetTopic(user string, importId string) (topic string, err error, code int) {
-	instance, err, code := this.GetImportInstance(user, importId)
+func (this *Imports) GetTopic(ctx context.Context, user string, importId string) (topic string, err error) {
+	instance, err := this.GetImportInstance(ctx, user, importId)
 	if err != nil {
 		debug.PrintStack()
-		return "", err, code
+		return "", err
 	}
 	topic = instance.KafkaTopic
-	return topic, nil, 200
+	return topic, nil
 }
 
-func (this *Imports) GetImportInstance(user string, importId string) (importInstance models.Import, err error, code int) {
-	token, err := this.auth.GetUserToken(user)
+func (this *Imports) GetImportInstance(ctx context.Context, user string, importId string) (importInstance models.Import, err error) {
+	token, err := this.auth.GetUserToken(ctx, user)
 	if err != nil {
 		debug.PrintStack()
-		return importInstance, err, http.StatusInternalServerError
+		return importInstance, err
 	}
-	err = token.GetJSON(this.config.ImportDeployUrl+"/instances/"+url.PathEscape(importId), &importInstance)
+	err = token.GetJSON(ctx, dependencyImportDeploy, this.config.ImportDeployUrl+"/instances/"+url.PathEscape(importId), &importInstance)
 	if err != nil {
 		debug.PrintStack()
-		return importInstance, err, http.StatusBadGateway
+		return importInstance, err
 	}
-	return importInstance, nil, 200
+	return importInstance, nil
 }
 
-func (this *Imports) GetImportType(user string, importTypeId string) (importInstance models.ImportType, err error, code int) {
-	token, err := this.auth.GetUserToken(user)
+func (this *Imports) GetImportType(ctx context.Context, user string, importTypeId string) (importInstance models.ImportType, err error) {
+	token, err := this.auth.GetUserToken(ctx, user)
 	if err != nil {
 		debug.PrintStack()
-		return importInstance, err, http.StatusInternalServerError
+		return importInstance, err
 	}
-	err = token.GetJSON(this.config.ImportRepositoryUrl+"/import-types/"+url.PathEscape(importTypeId), &importInstance)
+	err = token.GetJSON(ctx, dependencyImportRepository, this.config.ImportRepositoryUrl+"/import-types/"+url.PathEscape(importTypeId), &importInstance)
 	if err != nil {
 		debug.PrintStack()
-		return importInstance, err, http.StatusBadGateway
+		return importInstance, err
 	}
-	return importInstance, nil, 200
+	return importInstance, nil
 }
@@ -0,0 +1,238 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inprocess
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/kafka"
+	"log"
+	"sync"
+	"time"
+)
+
+const retryTimeout = 10 * time.Minute
+
+func (this *Factory) NewConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(delivery []byte) error) error {
+	return this.NewMessageConsumer(ctx, wg, config, topic, func(msg interfaces.Message) error {
+		return listener(msg.Value)
+	})
+}
+
+func (this *Factory) NewMessageConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(msg interfaces.Message) error) error {
+	t, err := this.getTopic(topic)
+	if err != nil {
+		return err
+	}
+	offset, err := t.start(config.ConsumerGroup, config.ConsumerStartPosition)
+	if err != nil {
+		return err
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer log.Println("close in-process consumer for topic ", topic)
+		for {
+			records, changed := t.read(offset)
+			for _, r := range records {
+				//on shutdown, only the in-flight message is finished
+				if ctx.Err() != nil {
+					return
+				}
+				err := retry(ctx, func() error {
+					return listener(t.message(r))
+				}, retryTimeout)
+				if !handled(ctx, topic, err) {
+					return
+				}
+				offset = r.Offset + 1
+				err = t.commit(config.ConsumerGroup, offset)
+				if err != nil {
+					log.Println("ERROR: unable to commit message", topic, err)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+		}
+	}()
+	return nil
+}
+
+// NewBatchConsumer collects messages per key like the kafka batch consumer, until no new message of the key arrived for quietPeriod
+// or the first collected message of the key is older than maxDelay
+func (this *Factory) NewBatchConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, quietPeriod time.Duration, maxDelay time.Duration, listener func(deliveries [][]byte) error) error {
+	t, err := this.getTopic(topic)
+	if err != nil {
+		return err
+	}
+	offset, err := t.start(config.ConsumerGroup, config.ConsumerStartPosition)
+	if err != nil {
+		return err
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer log.Println("close in-process batch consumer for topic ", topic)
+		batches := kafka.NewKeyBatches[record](quietPeriod, maxDelay)
+		pending := kafka.NewPendingOffsets()
+		for {
+			records, changed := t.read(offset)
+			for _, r := range records {
+				batches.Add(r.Key, r, time.Now())
+				pending.Add(0, r.Offset)
+				offset = r.Offset + 1
+			}
+			for _, batch := range batches.TakeDue(time.Now()) {
+				if ctx.Err() != nil {
+					log.Println("WARNING: shutdown while messages are unhandled (no commit)", topic, batches.Len()+len(batch))
+					return
+				}
+				deliveries := [][]byte{}
+				for _, r := range batch {
+					deliveries = append(deliveries, r.Value)
+				}
+				err := retry(ctx, func() error {
+					return listener(deliveries)
+				}, retryTimeout)
+				if !handled(ctx, topic, err) {
+					return
+				}
+				for _, r := range batch {
+					pending.Done(0, r.Offset)
+				}
+			}
+			if committable, ok := pending.Committable()[0]; ok {
+				err = t.commit(config.ConsumerGroup, committable+1)
+				if err != nil {
+					log.Println("ERROR: unable to commit messages", topic, err)
+				}
+			}
+			var due <-chan time.Time
+			if next, ok := batches.NextDue(); ok {
+				due = time.After(time.Until(next))
+			}
+			select {
+			case <-ctx.Done():
+				if batches.Len() > 0 {
+					log.Println("WARNING: shutdown while messages are unhandled (no commit)", topic, batches.Len())
+				}
+				return
+			case <-changed:
+			case <-due:
+			}
+		}
+	}()
+	return nil
+}
+
+// NewBroadcastConsumer starts at the end of the topic and commits nothing; see interfaces.SourcingFactory
+func (this *Factory) NewBroadcastConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(delivery []byte) error) error {
+	t, err := this.getTopic(topic)
+	if err != nil {
+		return err
+	}
+	offset := t.end()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer log.Println("close in-process broadcast consumer for topic ", topic)
+		for {
+			records, changed := t.read(offset)
+			for _, r := range records {
+				if ctx.Err() != nil {
+					return
+				}
+				err := listener(r.Value)
+				if err != nil {
+					log.Println("ERROR: unable to handle message, skip", topic, err)
+				}
+				offset = r.Offset + 1
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+		}
+	}()
+	return nil
+}
+
+// handled returns false if the consumer has to stop; skippable errors are skipped like in the kafka consumers
+func handled(ctx context.Context, topic string, err error) bool {
+	if err != nil && ctx.Err() != nil {
+		log.Println("WARNING: shutdown while message is unhandled (no commit)", topic, err)
+		return false
+	}
+	if err != nil && errs.IsSkippable(err) {
+		log.Println("ERROR: permanent error, skip message", topic, errs.Dependency(err), err)
+		return true
+	}
+	if err != nil {
+		log.Fatal("ERROR: unable to handle message (no commit)", err)
+	}
+	return true
+}
+
+type Producer struct {
+	topic *topic
+}
+
+func (this *Factory) NewProducer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string) (interfaces.Producer, error) {
+	t, err := this.getTopic(topic)
+	if err != nil {
+		return nil, err
+	}
+	return &Producer{topic: t}, nil
+}
+
+func (this *Producer) Produce(ctx context.Context, key string, message []byte) error {
+	return this.topic.add(key, message)
+}
+
+// ReadRange reads partition 0; see interfaces.ReplaySource
+func (this *Factory) ReadRange(ctx context.Context, config config.Config, topic string, partition int, startOffset int64, endOffset int64, listener func(msg interfaces.Message) error) error {
+	if partition > 0 {
+		return errs.Invalid("", errors.New("in-process topics only have partition 0"))
+	}
+	t, err := this.getTopic(topic)
+	if err != nil {
+		return err
+	}
+	records, _ := t.read(max(startOffset, 0))
+	for _, r := range records {
+		if endOffset >= 0 && r.Offset > endOffset {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err = retry(ctx, func() error {
+			return listener(t.message(r))
+		}, retryTimeout)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inprocess
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInProcess(t *testing.T) {
+	dir := t.TempDir()
+	conf := &config.ConfigStruct{ConsumerGroup: "test"}
+
+	received := []string{}
+	produce := func(factory *Factory, messages ...string) {
+		t.Helper()
+		producer, err := factory.NewProducer(context.Background(), &sync.WaitGroup{}, conf, "test-topic")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, msg := range messages {
+			err = producer.Produce(context.Background(), msg, []byte(msg))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	consume := func(factory *Factory, expected ...string) {
+		t.Helper()
+		wg := &sync.WaitGroup{}
+		ctx, cancel := context.WithCancel(context.Background())
+		mux := sync.Mutex{}
+		done := make(chan struct{})
+		received = []string{}
+		err := factory.NewConsumer(ctx, wg, conf, "test-topic", func(delivery []byte) error {
+			mux.Lock()
+			defer mux.Unlock()
+			if string(delivery) == "permanent" {
+				return errs.Invalid("", errors.New("test"))
+			}
+			received = append(received, string(delivery))
+			if len(received) == len(expected) {
+				close(done)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("timeout")
+		}
+		cancel()
+		wg.Wait()
+		if !reflect.DeepEqual(received, expected) {
+			t.Error(received, expected)
+		}
+	}
+
+	factory, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	produce(factory, "a", "permanent", "b")
+	consume(factory, "a", "b")
+	produce(factory, "c")
+	consume(factory, "c")
+
+	t.Run("persistence", func(t *testing.T) {
+		reloaded, err := New(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		produce(reloaded, "d")
+		consume(reloaded, "d")
+
+		conf.ConsumerStartPosition = "earliest"
+		defer func() {
+			conf.ConsumerStartPosition = ""
+			conf.ConsumerGroup = "test"
+		}()
+		produce(reloaded, "e")
+		consume(reloaded, "e") //the committed offset of the group is kept
+		conf.ConsumerGroup = "test2"
+		consume(reloaded, "a", "b", "c", "d", "e")
+	})
+
+	t.Run("read range", func(t *testing.T) {
+		offsets := []int64{}
+		err = factory.ReadRange(context.Background(), conf, "test-topic", -1, 1, 2, func(msg interfaces.Message) error {
+			offsets = append(offsets, msg.Offset)
+			return nil
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(offsets, []int64{1, 2}) {
+			t.Error(offsets)
+		}
+		err = factory.ReadRange(context.Background(), conf, "test-topic", 1, -1, -1, func(msg interfaces.Message) error {
+			return nil
+		})
+		if !errors.Is(err, errs.ErrInvalid) {
+			t.Error(err)
+		}
+	})
+}
+
+func TestInProcessBatch(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	factory, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &config.ConfigStruct{ConsumerGroup: "test"}
+	batches := make(chan [][]byte, 10)
+	err = factory.NewBatchConsumer(ctx, wg, conf, "batch-topic", 200*time.Millisecond, time.Second, func(deliveries [][]byte) error {
+		batches <- deliveries
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	producer, err := factory.NewProducer(ctx, wg, conf, "batch-topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"a", "b", "c"} {
+		err = producer.Produce(ctx, "g1", []byte(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = producer.Produce(ctx, "g2", []byte("d"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []int{3, 1} {
+		select {
+		case batch := <-batches:
+			if len(batch) != expected {
+				t.Error(len(batch), expected)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("timeout")
+		}
+	}
+}
+
+func TestInProcessBroadcast(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	factory, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &config.ConfigStruct{}
+	producer, err := factory.NewProducer(ctx, wg, conf, "broadcast-topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = producer.Produce(ctx, "old", []byte("old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := []chan string{make(chan string, 10), make(chan string, 10)}
+	for _, c := range received {
+		err = factory.NewBroadcastConsumer(ctx, wg, conf, "broadcast-topic", func(delivery []byte) error {
+			c <- string(delivery)
+			return errors.New("errors are skipped")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, msg := range []string{"a", "b"} {
+		err = producer.Produce(ctx, msg, []byte(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, c := range received {
+		for _, expected := range []string{"a", "b"} {
+			select {
+			case msg := <-c:
+				if msg != expected {
+					t.Error(i, msg, expected)
+				}
+			case <-time.After(5 * time.Second):
+				t.Error("timeout", i, expected)
+			}
+		}
+	}
+}
+
+func TestInProcessRetryUnauthorized(t *testing.T) {
+	factory, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &config.ConfigStruct{ConsumerGroup: "test"}
+	producer, err := factory.NewProducer(context.Background(), &sync.WaitGroup{}, conf, "retry-topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	consume := func(listener func(delivery []byte) error) {
+		t.Helper()
+		wg := &sync.WaitGroup{}
+		ctx, cancel := context.WithCancel(context.Background())
+		err := factory.NewConsumer(ctx, wg, conf, "retry-topic", listener)
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Second)
+		cancel()
+		wg.Wait()
+	}
+
+	err = producer.Produce(context.Background(), "a", []byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := sync.Mutex{}
+	received := []string{}
+	consume(func(delivery []byte) error {
+		mux.Lock()
+		defer mux.Unlock()
+		received = append(received, string(delivery))
+		if len(received) == 1 {
+			return errs.FromStatusCode("auth", http.StatusUnauthorized, errors.New("invalid client credentials"))
+		}
+		return nil
+	})
+	if !reflect.DeepEqual(received, []string{"a", "a"}) {
+		t.Error("unauthorized dependency errors should be retried", received)
+	}
+
+	//the message is committed after the successful retry
+	err = producer.Produce(context.Background(), "b", []byte("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	received = []string{}
+	consume(func(delivery []byte) error {
+		mux.Lock()
+		defer mux.Unlock()
+		received = append(received, string(delivery))
+		return nil
+	})
+	if !reflect.DeepEqual(received, []string{"b"}) {
+		t.Error(received)
+	}
+}
+
+func TestInProcessShutdown(t *testing.T) {
+	factory, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &config.ConfigStruct{ConsumerGroup: "test"}
+	producer, err := factory.NewProducer(context.Background(), &sync.WaitGroup{}, conf, "shutdown-topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"a", "b", "c"} {
+		err = producer.Produce(context.Background(), msg, []byte(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	ctx, cancel := context.WithCancel(context.Background())
+	received := []string{}
+	err = factory.NewConsumer(ctx, wg, conf, "shutdown-topic", func(delivery []byte) error {
+		received = append(received, string(delivery))
+		cancel()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if !reflect.DeepEqual(received, []string{"a"}) {
+		t.Error("only the in-flight message should be handled on shutdown", received)
+	}
+
+	//the remaining messages are handled by the next consumer
+	wg = &sync.WaitGroup{}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	received = []string{}
+	err = factory.NewConsumer(ctx, wg, conf, "shutdown-topic", func(delivery []byte) error {
+		received = append(received, string(delivery))
+		if len(received) == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if !reflect.DeepEqual(received, []string{"b", "c"}) {
+		t.Error(received)
+	}
+}
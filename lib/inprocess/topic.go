@@ -0,0 +1,259 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inprocess
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/kafka"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Factory implements interfaces.SourcingFactory without message broker, for development and tests.
+// topics have a single partition 0 and are kept in memory; consumer groups have committed offsets like in kafka,
+// but a group is expected to have only one consumer per topic.
+// if dir is set, messages and committed offsets are stored in this directory and loaded again on the next start.
+type Factory struct {
+	dir    string
+	mux    sync.Mutex
+	topics map[string]*topic
+}
+
+func New(dir string) (*Factory, error) {
+	if dir != "" {
+		err := os.MkdirAll(dir, 0750)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Factory{dir: dir, topics: map[string]*topic{}}, nil
+}
+
+type record struct {
+	Offset int64     `json:"offset"`
+	Key    string    `json:"key"`
+	Value  []byte    `json:"value"`
+	Time   time.Time `json:"time"`
+}
+
+type topic struct {
+	name    string
+	dir     string
+	mux     sync.Mutex
+	records []record
+	offsets map[string]int64 //next offset per consumer group
+	changed chan struct{}    //closed and replaced on every new record
+}
+
+func (this *Factory) getTopic(name string) (result *topic, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	result, ok := this.topics[name]
+	if ok {
+		return result, nil
+	}
+	result = &topic{name: name, dir: this.dir, offsets: map[string]int64{}, changed: make(chan struct{})}
+	err = result.load()
+	if err != nil {
+		return nil, err
+	}
+	this.topics[name] = result
+	return result, nil
+}
+
+func (this *topic) add(key string, value []byte) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	r := record{Offset: int64(len(this.records)), Key: key, Value: value, Time: config.TimeNow()}
+	err := this.persistRecord(r)
+	if err != nil {
+		return err
+	}
+	this.records = append(this.records, r)
+	close(this.changed)
+	this.changed = make(chan struct{})
+	return nil
+}
+
+// read returns the records from offset on and a channel, that is closed when the next record is added
+func (this *topic) read(offset int64) (records []record, changed <-chan struct{}) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if offset < int64(len(this.records)) {
+		records = append(records, this.records[offset:]...)
+	}
+	return records, this.changed
+}
+
+// end returns the offset of the next record
+func (this *topic) end() int64 {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return int64(len(this.records))
+}
+
+func (this *topic) commit(group string, offset int64) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.offsets[group] = offset
+	return this.persistOffsets()
+}
+
+// start returns the offset at which a consumer of the group starts; see config.ConsumerStartPosition.
+// the position is only used, if the group has no committed offset
+func (this *topic) start(group string, position string) (offset int64, err error) {
+	this.mux.Lock()
+	committedOffset, committed := this.offsets[group]
+	switch position {
+	case "":
+	case kafka.StartPositionEarliest:
+		offset = 0
+	case kafka.StartPositionLatest:
+		offset = int64(len(this.records))
+	default:
+		t, err := time.Parse(time.RFC3339, position)
+		if err != nil {
+			this.mux.Unlock()
+			return 0, errors.New("invalid consumer start position " + position)
+		}
+		offset = int64(len(this.records))
+		for _, r := range this.records {
+			if !r.Time.Before(t) {
+				offset = r.Offset
+				break
+			}
+		}
+	}
+	this.mux.Unlock()
+	if committed {
+		return committedOffset, nil
+	}
+	return offset, this.commit(group, offset)
+}
+
+func (this *topic) message(r record) interfaces.Message {
+	return interfaces.Message{Topic: this.name, Partition: 0, Offset: r.Offset, Value: r.Value}
+}
+
+func (this *topic) recordsFile() string {
+	return filepath.Join(this.dir, url.PathEscape(this.name)+".jsonl")
+}
+
+func (this *topic) offsetsFile() string {
+	return filepath.Join(this.dir, url.PathEscape(this.name)+".offsets.json")
+}
+
+func (this *topic) load() error {
+	if this.dir == "" {
+		return nil
+	}
+	file, err := os.Open(this.recordsFile())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			r := record{}
+			err = json.Unmarshal(scanner.Bytes(), &r)
+			if err != nil {
+				return err
+			}
+			this.records = append(this.records, r)
+		}
+		err = scanner.Err()
+		if err != nil {
+			return err
+		}
+	}
+	offsets, err := os.ReadFile(this.offsetsFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(offsets, &this.offsets)
+}
+
+func (this *topic) persistRecord(r record) error {
+	if this.dir == "" {
+		return nil
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(this.recordsFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// persistOffsets replaces the offsets file, so that a crash never leaves a partially written file
+func (this *topic) persistOffsets() error {
+	if this.dir == "" {
+		return nil
+	}
+	content, err := json.Marshal(this.offsets)
+	if err != nil {
+		return err
+	}
+	temp := this.offsetsFile() + ".tmp"
+	err = os.WriteFile(temp, content, 0640)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp, this.offsetsFile())
+}
+
+// retry mirrors the retry of the kafka consumers: skippable errors and a done ctx stop it immediately
+func retry(ctx context.Context, f func() error, timeout time.Duration) (err error) {
+	start := time.Now()
+	for i := int64(1); ; i++ {
+		err = f()
+		if err == nil || errs.IsSkippable(err) {
+			return err
+		}
+		log.Println("ERROR: in-process listener error:", err)
+		wait := time.Duration(i) * time.Second
+		if time.Since(start)+wait >= timeout {
+			return err
+		}
+		log.Println("ERROR: retry after:", wait.String())
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
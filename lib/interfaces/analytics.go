@@ -21,22 +21,34 @@ import (
 	"github.com/SENERGY-Platform/event-deployment/lib/auth"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"time"
 )
 
 type AnalyticsFactory interface {
-	New(ctx context.Context, config config.Config) (Analytics, error)
+	New(ctx context.Context, config config.Config, credentials PipelineCredentials) (Analytics, error)
+}
+
+// PipelineCredentials replaces user tokens in pipeline configs; is nil if config.PipelineAuthMode is not credential_reference
+type PipelineCredentials interface {
+	Issue(ctx context.Context, owner string, deploymentId string, eventId string) (key string, err error)
+	Revoke(ctx context.Context, key string) error
+	Exchange(ctx context.Context, key string) (token auth.AuthToken, err error)
 }
 
 type Analytics interface {
-	UpdateGroupDeployment(token auth.AuthToken, pipelineId string, label string, owner string, desc model.GroupEventDescription, serviceIds []string, serviceToDeviceIdsMapping map[string][]string, serviceToPathsMapping map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, castExtensions []model.ConverterExtension, useMarshaller bool) (err error)
-	DeployGroup(token auth.AuthToken, label string, user string, desc model.GroupEventDescription, serviceIds []string, serviceToDeviceIdsMapping map[string][]string, serviceToPathsMapping map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, castExtensions []model.ConverterExtension, useMarshaller bool) (pipelineId string, err error)
-	DeployImport(token auth.AuthToken, label string, user string, desc model.GroupEventDescription, topic string, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (pipelineId string, err error)
-	DeployGenericSource(token auth.AuthToken, label string, owner string, desc model.GroupEventDescription, path string, from string, to string, extensions []model.ConverterExtension) (pipelineId string, err error)
-	DeployDevice(token auth.AuthToken, label string, user string, deploymentId string, flowId string, eventId string, deviceId string, serviceId string, value string, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (pipelineId string, err error)
-	DeployDeviceWithMarshaller(token auth.AuthToken, label string, user string, deploymentId string, flowId string, eventId string, deviceId string, serviceId string, value string, path string, functionId string, aspectNodeId string, targetCharacteristicId string) (pipelineId string, err error)
-	Remove(user string, pipelineId string) error
-	GetPipelinesByDeploymentId(owner string, deploymentId string) (pipelineIds []string, err error)
-	GetPipelineByEventId(owner string, eventId string) (pipelineId string, exists bool, err error)
-	GetPipelinesByDeviceGroupId(owner string, groupId string) (pipelineIds []string, pipelineToGroupDescription map[string]model.GroupEventDescription, pipelineNames map[string]string, err error)
-	GetEventStates(userId string, eventIds []string) (states map[string]bool, err error)
+	UpdateGroupDeployment(ctx context.Context, token auth.AuthToken, pipelineId string, label string, owner string, desc model.GroupEventDescription, serviceIds []string, serviceToDeviceIdsMapping map[string][]string, serviceToPathsMapping map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, castExtensions []model.ConverterExtension, useMarshaller bool) (err error)
+	DeployGroup(ctx context.Context, token auth.AuthToken, label string, user string, desc model.GroupEventDescription, serviceIds []string, serviceToDeviceIdsMapping map[string][]string, serviceToPathsMapping map[string][]string, serviceToPathAndCharacteristic map[string][]model.PathAndCharacteristic, castExtensions []model.ConverterExtension, useMarshaller bool) (pipelineId string, err error)
+	DeployImport(ctx context.Context, token auth.AuthToken, label string, user string, desc model.GroupEventDescription, topic string, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (pipelineId string, err error)
+	DeployGenericSource(ctx context.Context, token auth.AuthToken, label string, owner string, desc model.GroupEventDescription, path string, from string, to string, extensions []model.ConverterExtension) (pipelineId string, err error)
+	DeployDevice(ctx context.Context, token auth.AuthToken, label string, user string, deploymentId string, flowId string, eventId string, deviceId string, serviceId string, value string, path string, castFrom string, castTo string, castExtensions []model.ConverterExtension) (pipelineId string, err error)
+	DeployDeviceWithMarshaller(ctx context.Context, token auth.AuthToken, label string, user string, deploymentId string, flowId string, eventId string, deviceId string, serviceId string, value string, path string, functionId string, aspectNodeId string, targetCharacteristicId string) (pipelineId string, err error)
+	Remove(ctx context.Context, user string, pipelineId string) error
+	GetPipelinesByDeploymentId(ctx context.Context, owner string, deploymentId string) (pipelineIds []string, err error)
+	GetPipelineByEventId(ctx context.Context, owner string, eventId string) (pipelineId string, exists bool, err error)
+	GetPipelinesByDeviceGroupId(ctx context.Context, owner string, groupId string) (pipelineIds []string, pipelineToGroupDescription map[string]model.GroupEventDescription, pipelineNames map[string]string, err error)
+	GetEventStates(ctx context.Context, userId string, eventIds []string) (states map[string]bool, err error)
+	GetEventPipelines(ctx context.Context, owner string, eventId string) (pipelines []model.EventPipelineDetails, err error)
+	GetPipelinesWithExpiredUserToken(ctx context.Context, owner string, buffer time.Duration) (pipelineIds []string, err error)
+	UpdatePipelineUserToken(ctx context.Context, token auth.AuthToken, owner string, pipelineId string) (err error)
+	ExchangePipelineCredential(ctx context.Context, key string) (token auth.AuthToken, err error)
 }
@@ -17,6 +17,7 @@
 package interfaces
 
 import (
+	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"github.com/SENERGY-Platform/models/go/models"
@@ -27,10 +28,12 @@ type DevicesFactory interface {
 }
 
 type Devices interface {
-	GetDeviceInfosOfGroup(groupId string) (devices []model.Device, deviceTypeIds []string, err error, code int)
-	GetDeviceInfosOfDevices(deviceIds []string) (devices []model.Device, deviceTypeIds []string, err error, code int)
-	GetDeviceTypeSelectables(criteria []model.FilterCriteria) (result []model.DeviceTypeSelectable, err error, code int)
-	GetConcept(conceptId string) (result model.Concept, err error, code int)
-	GetFunction(functionId string) (result model.Function, err error, code int)
-	GetService(serviceId string) (result models.Service, err error, code int)
+	GetDeviceInfosOfGroup(ctx context.Context, groupId string) (devices []model.Device, deviceTypeIds []string, err error)
+	GetDeviceInfosOfDevices(ctx context.Context, deviceIds []string) (devices []model.Device, deviceTypeIds []string, err error)
+	GetDeviceGroups(ctx context.Context, groupIds []string) (result []model.DeviceGroup, err error)
+	GetDeviceTypes(ctx context.Context, deviceTypeIds []string) (result []model.DeviceType, err error)
+	GetDeviceTypeSelectables(ctx context.Context, criteria []model.FilterCriteria) (result []model.DeviceTypeSelectable, err error)
+	GetConcept(ctx context.Context, conceptId string) (result model.Concept, err error)
+	GetFunction(ctx context.Context, functionId string) (result model.Function, err error)
+	GetService(ctx context.Context, serviceId string) (result models.Service, err error)
 }
@@ -19,20 +19,41 @@ package interfaces
 import (
 	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/deployments"
+	"github.com/SENERGY-Platform/event-deployment/lib/leader"
 	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
-	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"sync"
 )
 
 type EventsFactory interface {
-	New(ctx context.Context, config config.Config, analytics Analytics, devices Devices, imports Imports, doneProducer Producer, m *metrics.Metrics) (Events, error)
+	New(ctx context.Context, wg *sync.WaitGroup, config config.Config, analytics Analytics, devices Devices, imports Imports, doneProducer Producer, m *metrics.Metrics, elector *leader.Elector, auditLog AuditLog, replaySource ReplaySource, descChangeProducer Producer, deploymentProducer Producer, repo *deployments.Deployments) (Events, error)
 }
 
+// Events returns errors classified by the errs package; permanent errors of kafka messages are not retried
 type Events interface {
-	HandleCommand(msg []byte) error
-	Remove(owner string, deploymentId string) (err error)
-	Deploy(owner string, deployment models.Deployment) (err error)
-	HandleDeviceGroupUpdate(msg []byte) error
-	UpdateDeviceGroup(groupId string) (err error)
-	CheckEvent(token string, id string) int
-	GetEventStates(token string, ids []string) (states map[string]bool, err error, code int)
+	HandleCommand(ctx context.Context, msg []byte) error
+	HandleCommandMessage(ctx context.Context, msg Message) error
+	Remove(ctx context.Context, owner string, deploymentId string) (err error)
+	Deploy(ctx context.Context, owner string, deployment model.Deployment) (err error)
+	HandleDeviceGroupUpdate(ctx context.Context, msg []byte) error
+	HandleDeviceGroupUpdates(ctx context.Context, msgs [][]byte) error
+	UpdateDeviceGroup(ctx context.Context, groupId string, group *model.DeviceGroup) (err error)
+	RemoveDeviceGroup(ctx context.Context, groupId string) (err error)
+	CheckEvent(ctx context.Context, token string, id string) int
+	GetEventDetails(ctx context.Context, token string, id string) (details model.EventDetails, err error)
+	GetEventStates(ctx context.Context, token string, ids []string) (states map[string]bool, err error)
+	ExchangePipelineCredential(ctx context.Context, key string) (token string, err error)
+	ListDeploymentVersions(ctx context.Context, token string, deploymentId string) (result []model.DeploymentVersion, err error)
+	RollbackDeployment(ctx context.Context, token string, deploymentId string, version int64) (result model.DeploymentVersion, err error)
+	StartReplay(request model.ReplayRequest) (status model.ReplayStatus, err error)
+	GetReplayStatus() model.ReplayStatus
+	RequeueOutboxEntries(ctx context.Context) (result model.OutboxRequeueResult, err error)
+	//Audit has no context, because entries of canceled or timed out mutations must be written too
+	Audit(entry model.AuditEntry) error
+}
+
+// AuditLog receives an entry for every deployment mutation; failed writes are returned
+type AuditLog interface {
+	Write(entry model.AuditEntry) error
 }
@@ -17,6 +17,7 @@
 package interfaces
 
 import (
+	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
 	"github.com/SENERGY-Platform/models/go/models"
 )
@@ -26,7 +27,7 @@ type ImportsFactory interface {
 }
 
 type Imports interface {
-	GetTopic(user string, importId string) (topic string, err error, code int)
-	GetImportInstance(user string, importId string) (importInstance models.Import, err error, code int)
-	GetImportType(user string, importTypeId string) (importInstance models.ImportType, err error, code int)
+	GetTopic(ctx context.Context, user string, importId string) (topic string, err error)
+	GetImportInstance(ctx context.Context, user string, importId string) (importInstance models.Import, err error)
+	GetImportType(ctx context.Context, user string, importTypeId string) (importInstance models.ImportType, err error)
 }
@@ -19,13 +19,36 @@ package interfaces
 import (
 	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"sync"
+	"time"
 )
 
 type SourcingFactory interface {
-	NewConsumer(ctx context.Context, config config.Config, topic string, listener func(delivery []byte) error) error
-	NewProducer(ctx context.Context, config config.Config, topic string) (Producer, error)
+	NewConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(delivery []byte) error) error
+	NewMessageConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(msg Message) error) error
+	NewBatchConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, quietPeriod time.Duration, maxDelay time.Duration, listener func(deliveries [][]byte) error) error
+	NewProducer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string) (Producer, error)
+	// NewBroadcastConsumer passes every message, that is produced after the start, to the listener of every instance.
+	// it uses no consumer group and commits nothing; listener errors are logged and the message is skipped.
+	NewBroadcastConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(delivery []byte) error) error
+	ReplaySource
+}
+
+// ReplaySource reads a range of messages without consumer group.
+// a negative partition reads all partitions, a negative startOffset starts at the first message
+// and a negative endOffset stops at the last message at the start of the read; endOffset is inclusive.
+type ReplaySource interface {
+	ReadRange(ctx context.Context, config config.Config, topic string, partition int, startOffset int64, endOffset int64, listener func(msg Message) error) error
+}
+
+// Message is a consumed message with its position in the topic
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Value     []byte
 }
 
 type Producer interface {
-	Produce(key string, message []byte) error
+	Produce(ctx context.Context, key string, message []byte) error
 }
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"slices"
+	"time"
+)
+
+// DefaultBatchMaxDelay is used by batch consumers, if no max delay is configured;
+// it limits the delay of a key, which receives messages more often than the quiet period
+const DefaultBatchMaxDelay = time.Minute
+
+// KeyBatches collects messages per message key (e.g. the device-group id).
+// a key is due, when no message of the key arrived for the quiet period or its first collected message waited for the max delay.
+type KeyBatches[T any] struct {
+	quietPeriod time.Duration
+	maxDelay    time.Duration
+	keys        []string //in order of their first collected message
+	batches     map[string]*keyBatch[T]
+}
+
+type keyBatch[T any] struct {
+	messages []T
+	first    time.Time
+	last     time.Time
+}
+
+// NewKeyBatches uses DefaultBatchMaxDelay if maxDelay is 0
+func NewKeyBatches[T any](quietPeriod time.Duration, maxDelay time.Duration) *KeyBatches[T] {
+	if maxDelay <= 0 {
+		maxDelay = DefaultBatchMaxDelay
+	}
+	return &KeyBatches[T]{quietPeriod: quietPeriod, maxDelay: maxDelay, batches: map[string]*keyBatch[T]{}}
+}
+
+func (this *KeyBatches[T]) Add(key string, message T, now time.Time) {
+	batch, ok := this.batches[key]
+	if !ok {
+		batch = &keyBatch[T]{first: now}
+		this.batches[key] = batch
+		this.keys = append(this.keys, key)
+	}
+	batch.messages = append(batch.messages, message)
+	batch.last = now
+}
+
+// Len returns the count of collected messages
+func (this *KeyBatches[T]) Len() (result int) {
+	for _, batch := range this.batches {
+		result += len(batch.messages)
+	}
+	return result
+}
+
+// NextDue returns the time at which the next key is due; ok is false if no message is collected
+func (this *KeyBatches[T]) NextDue() (due time.Time, ok bool) {
+	for _, batch := range this.batches {
+		if !ok || batch.due(this.quietPeriod, this.maxDelay).Before(due) {
+			due = batch.due(this.quietPeriod, this.maxDelay)
+			ok = true
+		}
+	}
+	return due, ok
+}
+
+// TakeDue removes and returns the messages of every due key, one batch per key in order of their first message
+func (this *KeyBatches[T]) TakeDue(now time.Time) (result [][]T) {
+	this.keys = slices.DeleteFunc(this.keys, func(key string) bool {
+		batch := this.batches[key]
+		if batch.due(this.quietPeriod, this.maxDelay).After(now) {
+			return false
+		}
+		result = append(result, batch.messages)
+		delete(this.batches, key)
+		return true
+	})
+	return result
+}
+
+func (this *keyBatch[T]) due(quietPeriod time.Duration, maxDelay time.Duration) time.Time {
+	due := this.last.Add(quietPeriod)
+	if limit := this.first.Add(maxDelay); limit.Before(due) {
+		return limit
+	}
+	return due
+}
+
+// PendingOffsets tracks fetched and not yet committed offsets per partition.
+// batches of different keys are handled out of order; an offset is committable,
+// when it and all earlier fetched offsets of its partition are handled.
+type PendingOffsets struct {
+	partitions map[int][]pendingOffset
+}
+
+type pendingOffset struct {
+	offset  int64
+	handled bool
+}
+
+func NewPendingOffsets() *PendingOffsets {
+	return &PendingOffsets{partitions: map[int][]pendingOffset{}}
+}
+
+// Add expects increasing offsets per partition
+func (this *PendingOffsets) Add(partition int, offset int64) {
+	this.partitions[partition] = append(this.partitions[partition], pendingOffset{offset: offset})
+}
+
+func (this *PendingOffsets) Done(partition int, offset int64) {
+	for i, pending := range this.partitions[partition] {
+		if pending.offset == offset {
+			this.partitions[partition][i].handled = true
+			return
+		}
+	}
+}
+
+// Committable removes and returns the highest committable offset of each partition
+func (this *PendingOffsets) Committable() (result map[int]int64) {
+	result = map[int]int64{}
+	for partition, pending := range this.partitions {
+		i := 0
+		for i < len(pending) && pending[i].handled {
+			result[partition] = pending[i].offset
+			i++
+		}
+		if i == len(pending) {
+			delete(this.partitions, partition)
+		} else {
+			this.partitions[partition] = pending[i:]
+		}
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestKeyBatches(t *testing.T) {
+	start := time.Now()
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+	batches := NewKeyBatches[string](5*time.Second, 0)
+
+	batches.Add("g1", "a", at(0))
+	batches.Add("g2", "b", at(1))
+	batches.Add("g1", "c", at(3))
+	if due, ok := batches.NextDue(); !ok || !due.Equal(at(6)) {
+		t.Error(due, ok)
+	}
+	if result := batches.TakeDue(at(5)); len(result) != 0 {
+		t.Error(result)
+	}
+	if result := batches.TakeDue(at(6)); !reflect.DeepEqual(result, [][]string{{"b"}}) {
+		t.Error(result)
+	}
+	if result := batches.TakeDue(at(8)); !reflect.DeepEqual(result, [][]string{{"a", "c"}}) {
+		t.Error(result)
+	}
+	if _, ok := batches.NextDue(); ok || batches.Len() != 0 {
+		t.Error(batches.Len())
+	}
+
+	t.Run("steady traffic is limited by the max delay", func(t *testing.T) {
+		for i := 0; i < 120; i++ {
+			batches.Add("g1", "m", at(i))
+			if i < 60 && len(batches.TakeDue(at(i))) != 0 {
+				t.Error("unexpected batch before max delay", i)
+				return
+			}
+			if i == 60 {
+				result := batches.TakeDue(at(i))
+				if len(result) != 1 || len(result[0]) != 61 {
+					t.Error(result)
+				}
+				return
+			}
+		}
+	})
+}
+
+func TestPendingOffsets(t *testing.T) {
+	pending := NewPendingOffsets()
+	for _, offset := range []int64{1, 2, 3} {
+		pending.Add(0, offset)
+	}
+	pending.Add(1, 7)
+
+	pending.Done(0, 2)
+	pending.Done(1, 7)
+	if result := pending.Committable(); !reflect.DeepEqual(result, map[int]int64{1: 7}) {
+		t.Error(result)
+	}
+	pending.Done(0, 1)
+	if result := pending.Committable(); !reflect.DeepEqual(result, map[int]int64{0: 2}) {
+		t.Error(result)
+	}
+	pending.Done(0, 3)
+	if result := pending.Committable(); !reflect.DeepEqual(result, map[int]int64{0: 3}) {
+		t.Error(result)
+	}
+	if result := pending.Committable(); len(result) != 0 {
+		t.Error(result)
+	}
+}
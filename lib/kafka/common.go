@@ -17,22 +17,40 @@
 package kafka
 
 import (
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
 	"github.com/SENERGY-Platform/event-deployment/lib/kafka/topicconfig"
 )
 
-func InitTopic(bootstrapUrl string, topics ...string) (err error) {
+// DefaultTopicConfig is used for topics without entry in config.KafkaTopicConfigs, if no "default" entry is configured
+var DefaultTopicConfig = config.KafkaTopicConfig{
+	Partitions:        1,
+	ReplicationFactor: 1,
+	Config: map[string]string{
+		"retention.ms":              "-1",
+		"retention.bytes":           "-1",
+		"cleanup.policy":            "compact",
+		"delete.retention.ms":       "86400000",
+		"segment.ms":                "604800000",
+		"min.cleanable.dirty.ratio": "0.1",
+	},
+}
+
+func InitTopic(config config.Config, topics ...string) (err error) {
 	for _, topic := range topics {
-		err = topicconfig.Ensure(bootstrapUrl, topic, map[string]string{
-			"retention.ms":              "-1",
-			"retention.bytes":           "-1",
-			"cleanup.policy":            "compact",
-			"delete.retention.ms":       "86400000",
-			"segment.ms":                "604800000",
-			"min.cleanable.dirty.ratio": "0.1",
-		})
+		err = topicconfig.Ensure(config, topic, GetTopicConfig(config, topic))
 		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+func GetTopicConfig(config config.Config, topic string) config.KafkaTopicConfig {
+	if result, ok := config.KafkaTopicConfigs[topic]; ok {
+		return result
+	}
+	if result, ok := config.KafkaTopicConfigs["default"]; ok {
+		return result
+	}
+	return DefaultTopicConfig
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package connection
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/IBM/sarama"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	kafkascram "github.com/segmentio/kafka-go/sasl/scram"
+	"github.com/xdg-go/scram"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	SaslPlain       = "plain"
+	SaslScramSha256 = "scram-sha-256"
+	SaslScramSha512 = "scram-sha-512"
+)
+
+// Brokers returns the bootstrap brokers of config.KafkaUrl, which may list several brokers separated by comma
+func Brokers(config config.Config) (result []string) {
+	for _, broker := range strings.Split(config.KafkaUrl, ",") {
+		broker = strings.TrimSpace(broker)
+		if broker != "" {
+			result = append(result, broker)
+		}
+	}
+	return result
+}
+
+// TlsConfig returns nil if config.KafkaTls is false
+func TlsConfig(config config.Config) (result *tls.Config, err error) {
+	if !config.KafkaTls {
+		return nil, nil
+	}
+	result = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.KafkaTlsInsecureSkipVerify,
+	}
+	if config.KafkaTlsCaFile != "" {
+		ca, err := os.ReadFile(config.KafkaTlsCaFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read kafka ca file: %w", err)
+		}
+		result.RootCAs = x509.NewCertPool()
+		if !result.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificate found in kafka ca file")
+		}
+	}
+	if config.KafkaTlsCertFile != "" || config.KafkaTlsKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.KafkaTlsCertFile, config.KafkaTlsKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load kafka client certificate: %w", err)
+		}
+		result.Certificates = []tls.Certificate{cert}
+	}
+	return result, nil
+}
+
+// SaslMechanism returns nil if config.KafkaSaslMechanism is not configured
+func SaslMechanism(config config.Config) (result sasl.Mechanism, err error) {
+	switch strings.ToLower(config.KafkaSaslMechanism) {
+	case "":
+		return nil, nil
+	case SaslPlain:
+		return plain.Mechanism{Username: config.KafkaSaslUser, Password: config.KafkaSaslPassword}, nil
+	case SaslScramSha256:
+		return kafkascram.Mechanism(kafkascram.SHA256, config.KafkaSaslUser, config.KafkaSaslPassword)
+	case SaslScramSha512:
+		return kafkascram.Mechanism(kafkascram.SHA512, config.KafkaSaslUser, config.KafkaSaslPassword)
+	default:
+		return nil, fmt.Errorf("unknown kafka sasl mechanism %v", config.KafkaSaslMechanism)
+	}
+}
+
+// Dialer is used by readers and for direct broker connections
+func Dialer(config config.Config) (result *kafka.Dialer, err error) {
+	tlsConfig, err := TlsConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mechanism, err := SaslMechanism(config)
+	if err != nil {
+		return nil, err
+	}
+	return &kafka.Dialer{
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		TLS:           tlsConfig,
+		SASLMechanism: mechanism,
+	}, nil
+}
+
+// Transport is used by writers
+func Transport(config config.Config) (result *kafka.Transport, err error) {
+	tlsConfig, err := TlsConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mechanism, err := SaslMechanism(config)
+	if err != nil {
+		return nil, err
+	}
+	return &kafka.Transport{
+		TLS:  tlsConfig,
+		SASL: mechanism,
+	}, nil
+}
+
+// Sarama returns the config of sarama admin clients
+func Sarama(config config.Config) (result *sarama.Config, err error) {
+	result = sarama.NewConfig()
+	result.Version = sarama.V2_4_0_0
+	tlsConfig, err := TlsConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		result.Net.TLS.Enable = true
+		result.Net.TLS.Config = tlsConfig
+	}
+	mechanism := strings.ToLower(config.KafkaSaslMechanism)
+	if mechanism == "" {
+		return result, nil
+	}
+	result.Net.SASL.Enable = true
+	result.Net.SASL.User = config.KafkaSaslUser
+	result.Net.SASL.Password = config.KafkaSaslPassword
+	switch mechanism {
+	case SaslPlain:
+		result.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	case SaslScramSha256:
+		result.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		result.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hash: scram.SHA256}
+		}
+	case SaslScramSha512:
+		result.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		result.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hash: scram.SHA512}
+		}
+	default:
+		return nil, fmt.Errorf("unknown kafka sasl mechanism %v", config.KafkaSaslMechanism)
+	}
+	return result, nil
+}
+
+// scramClient implements sarama.SCRAMClient
+type scramClient struct {
+	hash         scram.HashGeneratorFcn
+	conversation *scram.ClientConversation
+}
+
+func (this *scramClient) Begin(userName, password, authzID string) (err error) {
+	client, err := this.hash.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	this.conversation = client.NewConversation()
+	return nil
+}
+
+func (this *scramClient) Step(challenge string) (response string, err error) {
+	return this.conversation.Step(challenge)
+}
+
+func (this *scramClient) Done() bool {
+	return this.conversation.Done()
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package connection
+
+import (
+	"github.com/IBM/sarama"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"reflect"
+	"testing"
+)
+
+func TestBrokers(t *testing.T) {
+	result := Brokers(&config.ConfigStruct{KafkaUrl: "kafka-0:9092, kafka-1:9092,,kafka-2:9092"})
+	if !reflect.DeepEqual(result, []string{"kafka-0:9092", "kafka-1:9092", "kafka-2:9092"}) {
+		t.Error(result)
+	}
+}
+
+func TestPlaintext(t *testing.T) {
+	conf := &config.ConfigStruct{KafkaUrl: "kafka:9092"}
+	dialer, err := Dialer(conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if dialer.TLS != nil || dialer.SASLMechanism != nil {
+		t.Error(dialer)
+	}
+	sconfig, err := Sarama(conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if sconfig.Net.TLS.Enable || sconfig.Net.SASL.Enable {
+		t.Error(sconfig.Net)
+	}
+}
+
+func TestSasl(t *testing.T) {
+	for mechanism, expected := range map[string]sarama.SASLMechanism{
+		"plain":         sarama.SASLTypePlaintext,
+		"SCRAM-SHA-256": sarama.SASLTypeSCRAMSHA256,
+		"scram-sha-512": sarama.SASLTypeSCRAMSHA512,
+	} {
+		t.Run(mechanism, func(t *testing.T) {
+			conf := &config.ConfigStruct{KafkaSaslMechanism: mechanism, KafkaSaslUser: "user", KafkaSaslPassword: "pw"}
+			m, err := SaslMechanism(conf)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if m == nil {
+				t.Error("missing mechanism")
+				return
+			}
+			sconfig, err := Sarama(conf)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if sconfig.Net.SASL.Mechanism != expected {
+				t.Error(sconfig.Net.SASL.Mechanism)
+				return
+			}
+			err = sconfig.Validate()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if sconfig.Net.SASL.SCRAMClientGeneratorFunc != nil {
+				client := sconfig.Net.SASL.SCRAMClientGeneratorFunc()
+				err = client.Begin("user", "pw", "")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				first, err := client.Step("")
+				if err != nil || first == "" || client.Done() {
+					t.Error(first, err)
+					return
+				}
+			}
+		})
+	}
+	t.Run("unknown", func(t *testing.T) {
+		conf := &config.ConfigStruct{KafkaSaslMechanism: "gssapi"}
+		_, err := SaslMechanism(conf)
+		if err == nil {
+			t.Error("expected error")
+		}
+		_, err = Sarama(conf)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestTls(t *testing.T) {
+	conf := &config.ConfigStruct{KafkaTls: true, KafkaTlsInsecureSkipVerify: true}
+	tlsConfig, err := TlsConfig(conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if tlsConfig == nil || !tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs != nil {
+		t.Error(tlsConfig)
+		return
+	}
+	transport, err := Transport(conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if transport.TLS == nil {
+		t.Error("missing tls config in transport")
+		return
+	}
+
+	conf.KafkaTlsCaFile = t.TempDir() + "/missing.pem"
+	_, err = TlsConfig(conf)
+	if err == nil {
+		t.Error("expected error")
+	}
+}
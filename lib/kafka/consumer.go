@@ -20,33 +20,32 @@ import (
 	"context"
 	"errors"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/kafka/connection"
 	"github.com/segmentio/kafka-go"
 	"io"
 	"log"
 	"os"
+	"sync"
 	"time"
 )
 
-func NewConsumer(ctx context.Context, config config.Config, topic string, listener func(delivery []byte) error) (err error) {
-	if config.InitTopics {
-		err = InitTopic(config.KafkaUrl, topic)
-		if err != nil {
-			log.Println("ERROR: unable to create topic", err)
-			return err
-		}
-	}
-	r := kafka.NewReader(kafka.ReaderConfig{
-		CommitInterval:         0, //synchronous commits
-		Brokers:                []string{config.KafkaUrl},
-		GroupID:                config.ConsumerGroup,
-		Topic:                  topic,
-		MaxWait:                1 * time.Second,
-		Logger:                 log.New(io.Discard, "", 0),
-		ErrorLogger:            log.New(os.Stdout, "[KAFKA-ERR]", log.LstdFlags),
-		WatchPartitionChanges:  true,
-		PartitionWatchInterval: time.Minute,
+func NewConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(delivery []byte) error) (err error) {
+	return NewMessageConsumer(ctx, wg, config, topic, func(msg interfaces.Message) error {
+		return listener(msg.Value)
 	})
+}
+
+// NewMessageConsumer passes the topic position of each message to the listener
+func NewMessageConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(msg interfaces.Message) error) (err error) {
+	r, shutdownTimeout, err := newReader(ctx, config, topic)
+	if err != nil {
+		return err
+	}
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		defer r.Close()
 		defer log.Println("close consumer for topic ", topic)
 		for {
@@ -63,16 +62,28 @@ func NewConsumer(ctx context.Context, config config.Config, topic string, listen
 					return
 				}
 
-				err = retry(func() error {
-					return listener(m.Value)
+				err = retry(ctx, func() error {
+					return listener(interfaces.Message{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset, Value: m.Value})
 				}, func(n int64) time.Duration {
 					return time.Duration(n) * time.Second
 				}, 10*time.Minute)
 
-				if err != nil {
+				if err != nil && ctx.Err() != nil {
+					log.Println("WARNING: shutdown while message is unhandled (no commit)", topic, err)
+					return
+				}
+				if err != nil && errs.IsSkippable(err) {
+					log.Println("ERROR: permanent error, skip message", topic, errs.Dependency(err), err)
+				} else if err != nil {
 					log.Fatal("ERROR: unable to handle message (no commit)", err)
-				} else {
-					err = r.CommitMessages(ctx, m)
+				}
+
+				//ctx may already be canceled by a shutdown; the handled message must still be committed
+				commitCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+				err = r.CommitMessages(commitCtx, m)
+				cancel()
+				if err != nil {
+					log.Println("ERROR: unable to commit message", topic, err)
 				}
 			}
 		}
@@ -80,17 +91,201 @@ func NewConsumer(ctx context.Context, config config.Config, topic string, listen
 	return nil
 }
 
-func retry(f func() error, waitProvider func(n int64) time.Duration, timeout time.Duration) (err error) {
+// NewBatchConsumer collects messages per message key until no new message of the key arrived for quietPeriod or the first collected message of the key is older than maxDelay.
+// the listener receives the collected messages of each due key at once; if maxDelay is 0, DefaultBatchMaxDelay is used.
+// because keys are handled independently, a partition is only committed up to its first unhandled message.
+func NewBatchConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, quietPeriod time.Duration, maxDelay time.Duration, listener func(deliveries [][]byte) error) (err error) {
+	r, shutdownTimeout, err := newReader(ctx, config, topic)
+	if err != nil {
+		return err
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer r.Close()
+		defer log.Println("close batch consumer for topic ", topic)
+		batches := NewKeyBatches[kafka.Message](quietPeriod, maxDelay)
+		pending := NewPendingOffsets()
+		for {
+			fetchCtx, cancel := ctx, context.CancelFunc(func() {})
+			if due, ok := batches.NextDue(); ok {
+				fetchCtx, cancel = context.WithDeadline(ctx, due)
+			}
+			m, err := r.FetchMessage(fetchCtx)
+			cancel()
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				if batches.Len() > 0 {
+					log.Println("WARNING: shutdown while messages are unhandled (no commit)", topic, batches.Len())
+				}
+				return
+			}
+			if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+				log.Fatal("ERROR: while consuming topic ", topic, err)
+				return
+			}
+			if err == nil {
+				batches.Add(string(m.Key), m, time.Now())
+				pending.Add(m.Partition, m.Offset)
+			}
+
+			for _, batch := range batches.TakeDue(time.Now()) {
+				deliveries := [][]byte{}
+				for _, message := range batch {
+					deliveries = append(deliveries, message.Value)
+				}
+				err = retry(ctx, func() error {
+					return listener(deliveries)
+				}, func(n int64) time.Duration {
+					return time.Duration(n) * time.Second
+				}, 10*time.Minute)
+
+				if err != nil && ctx.Err() != nil {
+					log.Println("WARNING: shutdown while messages are unhandled (no commit)", topic, err)
+					return
+				}
+				if err != nil && errs.IsSkippable(err) {
+					log.Println("ERROR: permanent error, skip messages", topic, errs.Dependency(err), err)
+				} else if err != nil {
+					log.Fatal("ERROR: unable to handle messages (no commit)", err)
+				}
+				for _, message := range batch {
+					pending.Done(message.Partition, message.Offset)
+				}
+			}
+
+			commits := []kafka.Message{}
+			for partition, offset := range pending.Committable() {
+				commits = append(commits, kafka.Message{Topic: topic, Partition: partition, Offset: offset})
+			}
+			if len(commits) == 0 {
+				continue
+			}
+			//ctx may already be canceled by a shutdown; the handled messages must still be committed
+			commitCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			err = r.CommitMessages(commitCtx, commits...)
+			cancel()
+			if err != nil {
+				log.Println("ERROR: unable to commit messages", topic, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// NewBroadcastConsumer reads every partition of the topic from its end on, without consumer group and commits;
+// so every instance receives every new message and no consumer group is left behind by a restarted instance.
+// partitions, that are added to the topic after the start, are not read.
+func NewBroadcastConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(delivery []byte) error) (err error) {
+	client, err := newClient(config)
+	if err != nil {
+		return err
+	}
+	partitions, err := getPartitions(ctx, client, topic)
+	if err != nil {
+		return err
+	}
+	dialer, err := connection.Dialer(config)
+	if err != nil {
+		return err
+	}
+	for _, partition := range partitions {
+		r := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     connection.Brokers(config),
+			Dialer:      dialer,
+			Topic:       topic,
+			Partition:   partition,
+			MaxWait:     1 * time.Second,
+			Logger:      log.New(io.Discard, "", 0),
+			ErrorLogger: log.New(os.Stdout, "[KAFKA-ERR]", log.LstdFlags),
+		})
+		err = r.SetOffset(kafka.LastOffset)
+		if err != nil {
+			r.Close()
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer r.Close()
+			defer log.Println("close broadcast consumer for topic ", topic, partition)
+			for {
+				m, err := r.ReadMessage(ctx)
+				if ctx.Err() != nil || errors.Is(err, io.EOF) {
+					return
+				}
+				if err != nil {
+					log.Fatal("ERROR: while consuming topic ", topic, err)
+					return
+				}
+				err = listener(m.Value)
+				if err != nil {
+					log.Println("ERROR: unable to handle message, skip", topic, err)
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+func newReader(ctx context.Context, config config.Config, topic string) (r *kafka.Reader, shutdownTimeout time.Duration, err error) {
+	if config.InitTopics {
+		err = InitTopic(config, topic)
+		if err != nil {
+			log.Println("ERROR: unable to create topic", err)
+			return nil, 0, err
+		}
+	}
+	err = resetConsumerGroupOffsets(ctx, config, topic)
+	if err != nil {
+		log.Println("ERROR: unable to reset consumer group offsets", err)
+		return nil, 0, err
+	}
+	shutdownTimeout, err = time.ParseDuration(config.ShutdownTimeout)
+	if err != nil {
+		log.Println("WARNING: invalid shutdown timeout --> use 10s\n", err)
+		shutdownTimeout = 10 * time.Second
+		err = nil
+	}
+	dialer, err := connection.Dialer(config)
+	if err != nil {
+		log.Println("ERROR: invalid kafka connection config", err)
+		return nil, 0, err
+	}
+	r = kafka.NewReader(kafka.ReaderConfig{
+		CommitInterval:         0, //synchronous commits
+		Brokers:                connection.Brokers(config),
+		Dialer:                 dialer,
+		GroupID:                config.ConsumerGroup,
+		Topic:                  topic,
+		MaxWait:                1 * time.Second,
+		Logger:                 log.New(io.Discard, "", 0),
+		ErrorLogger:            log.New(os.Stdout, "[KAFKA-ERR]", log.LstdFlags),
+		WatchPartitionChanges:  true,
+		PartitionWatchInterval: time.Minute,
+	})
+	return r, shutdownTimeout, nil
+}
+
+// retry stops retrying when ctx is done, to not delay a shutdown.
+// errors of skippable messages (see errs.IsSkippable) are returned without retry.
+func retry(ctx context.Context, f func() error, waitProvider func(n int64) time.Duration, timeout time.Duration) (err error) {
 	err = errors.New("")
 	start := time.Now()
 	for i := int64(1); err != nil && time.Since(start) < timeout; i++ {
 		err = f()
 		if err != nil {
 			log.Println("ERROR: kafka listener error:", err)
+			if errs.IsSkippable(err) {
+				return err
+			}
 			wait := waitProvider(i)
 			if time.Since(start)+wait < timeout {
 				log.Println("ERROR: retry after:", wait.String())
-				time.Sleep(wait)
+				select {
+				case <-ctx.Done():
+					return err
+				case <-time.After(wait):
+				}
 			} else {
 				return err
 			}
@@ -20,16 +20,34 @@ import (
 	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"sync"
+	"time"
 )
 
 type FactoryType struct{}
 
 var Factory = FactoryType{}
 
-func (FactoryType) NewConsumer(ctx context.Context, config config.Config, topic string, listener func(delivery []byte) error) error {
-	return NewConsumer(ctx, config, topic, listener)
+func (FactoryType) NewConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(delivery []byte) error) error {
+	return NewConsumer(ctx, wg, config, topic, listener)
 }
 
-func (FactoryType) NewProducer(ctx context.Context, config config.Config, topic string) (interfaces.Producer, error) {
-	return NewProducer(ctx, config, topic)
+func (FactoryType) NewMessageConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(msg interfaces.Message) error) error {
+	return NewMessageConsumer(ctx, wg, config, topic, listener)
+}
+
+func (FactoryType) NewBatchConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, quietPeriod time.Duration, maxDelay time.Duration, listener func(deliveries [][]byte) error) error {
+	return NewBatchConsumer(ctx, wg, config, topic, quietPeriod, maxDelay, listener)
+}
+
+func (FactoryType) NewBroadcastConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string, listener func(delivery []byte) error) error {
+	return NewBroadcastConsumer(ctx, wg, config, topic, listener)
+}
+
+func (FactoryType) NewProducer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string) (interfaces.Producer, error) {
+	return NewProducer(ctx, wg, config, topic)
+}
+
+func (FactoryType) ReadRange(ctx context.Context, config config.Config, topic string, partition int, startOffset int64, endOffset int64, listener func(msg interfaces.Message) error) error {
+	return ReadRange(ctx, config, topic, partition, startOffset, endOffset, listener)
 }
@@ -18,8 +18,13 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
 	"github.com/SENERGY-Platform/event-deployment/lib/tests/docker"
+	"github.com/segmentio/kafka-go"
+	"net/http"
 	"reflect"
 	"sync"
 	"testing"
@@ -60,7 +65,7 @@ func TestKafka(t *testing.T) {
 
 	wait := sync.WaitGroup{}
 
-	err = Factory.NewConsumer(ctx, config, "test", func(delivery []byte) error {
+	err = Factory.NewConsumer(ctx, wg, config, "test", func(delivery []byte) error {
 		mux.Lock()
 		defer mux.Unlock()
 		consumed = append(consumed, string(delivery))
@@ -73,21 +78,21 @@ func TestKafka(t *testing.T) {
 		return
 	}
 
-	producer, err := Factory.NewProducer(ctx, config, "test")
+	producer, err := Factory.NewProducer(ctx, wg, config, "test")
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
 	wait.Add(1)
-	err = producer.Produce("key", []byte("foo"))
+	err = producer.Produce(ctx, "key", []byte("foo"))
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
 	wait.Add(1)
-	err = producer.Produce("key", []byte("bar"))
+	err = producer.Produce(ctx, "key", []byte("bar"))
 	if err != nil {
 		t.Error(err)
 		return
@@ -103,6 +108,202 @@ func TestKafka(t *testing.T) {
 	}
 }
 
+func TestBatchKafka(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := config.LoadConfig("../../config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.Debug = false
+	config.InitTopics = true
+
+	_, zkIp, err := Zookeeper(ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.KafkaUrl, err = Kafka(ctx, wg, zkIp+":2181")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(1 * time.Second)
+
+	batches := [][]string{}
+	mux := sync.Mutex{}
+
+	err = Factory.NewBatchConsumer(ctx, wg, config, "test", 2*time.Second, time.Minute, func(deliveries [][]byte) error {
+		mux.Lock()
+		defer mux.Unlock()
+		batch := []string{}
+		for _, delivery := range deliveries {
+			batch = append(batch, string(delivery))
+		}
+		batches = append(batches, batch)
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	producer, err := Factory.NewProducer(ctx, wg, config, "test")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, msg := range []string{"a", "b", "c"} {
+		err = producer.Produce(ctx, "key", []byte(msg))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	time.Sleep(10 * time.Second)
+	mux.Lock()
+	defer mux.Unlock()
+	if !reflect.DeepEqual(batches, [][]string{{"a", "b", "c"}}) {
+		t.Error(batches)
+		return
+	}
+}
+
+func TestReplayKafka(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := config.LoadConfig("../../config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.Debug = false
+	config.InitTopics = true
+
+	_, zkIp, err := Zookeeper(ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	zookeeperUrl := zkIp + ":2181"
+
+	//kafka
+	config.KafkaUrl, err = Kafka(ctx, wg, zookeeperUrl)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(1 * time.Second)
+
+	producer, err := Factory.NewProducer(ctx, wg, config, "test-replay")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, msg := range []string{"foo", "bar", "baz"} {
+		err = producer.Produce(ctx, "key", []byte(msg))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	read := func(partition int, startOffset int64, endOffset int64, listenerErr error) (result []string, err error) {
+		err = ReadRange(ctx, config, "test-replay", partition, startOffset, endOffset, func(msg interfaces.Message) error {
+			result = append(result, string(msg.Value))
+			return listenerErr
+		})
+		return result, err
+	}
+
+	t.Run("whole topic", func(t *testing.T) {
+		result, err := read(-1, -1, -1, nil)
+		if err != nil || !reflect.DeepEqual(result, []string{"foo", "bar", "baz"}) {
+			t.Error(result, err)
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		result, err := read(0, 1, 1, nil)
+		if err != nil || !reflect.DeepEqual(result, []string{"bar"}) {
+			t.Error(result, err)
+		}
+	})
+
+	t.Run("skippable error stops the read", func(t *testing.T) {
+		result, err := read(0, -1, -1, errs.Invalid("test", errors.New("invalid")))
+		if !errors.Is(err, errs.ErrInvalid) || !reflect.DeepEqual(result, []string{"foo"}) {
+			t.Error(result, err)
+		}
+	})
+}
+
+func TestRetryPermanentError(t *testing.T) {
+	wait := func(n int64) time.Duration { return time.Millisecond }
+
+	calls := 0
+	err := retry(context.Background(), func() error {
+		calls++
+		return errs.NotFound("test", errors.New("missing"))
+	}, wait, time.Second)
+	if !errors.Is(err, errs.ErrNotFound) || calls != 1 {
+		t.Error(calls, err)
+		return
+	}
+
+	calls = 0
+	err = retry(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return errs.Transient("test", errors.New("timeout"))
+		}
+		return nil
+	}, wait, time.Second)
+	if err != nil || calls != 3 {
+		t.Error(calls, err)
+		return
+	}
+
+	//a rotated service credential must not drop the message
+	calls = 0
+	err = retry(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return errs.FromStatusCode("auth", http.StatusUnauthorized, errors.New("invalid client credentials"))
+		}
+		return nil
+	}, wait, time.Second)
+	if err != nil || calls != 3 {
+		t.Error(calls, err)
+		return
+	}
+}
+
+func TestParseStartPosition(t *testing.T) {
+	for position, expected := range map[string]int64{
+		StartPositionEarliest:  kafka.FirstOffset,
+		StartPositionLatest:    kafka.LastOffset,
+		"2026-01-02T03:04:05Z": time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli(),
+	} {
+		actual, err := ParseStartPosition(position)
+		if err != nil || actual != expected {
+			t.Error(position, actual, expected, err)
+		}
+	}
+	_, err := ParseStartPosition("yesterday")
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
 var Kafka = docker.Kafka
 
 var Zookeeper = docker.Zookeeper
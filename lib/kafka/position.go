@@ -0,0 +1,197 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/kafka/connection"
+	"github.com/segmentio/kafka-go"
+	"log"
+	"time"
+)
+
+const (
+	StartPositionEarliest = "earliest"
+	StartPositionLatest   = "latest"
+)
+
+// ParseStartPosition returns kafka.FirstOffset, kafka.LastOffset or the unix milliseconds of a RFC3339 timestamp
+func ParseStartPosition(position string) (timestamp int64, err error) {
+	switch position {
+	case StartPositionEarliest:
+		return kafka.FirstOffset, nil
+	case StartPositionLatest:
+		return kafka.LastOffset, nil
+	}
+	t, err := time.Parse(time.RFC3339, position)
+	if err != nil {
+		return 0, fmt.Errorf("invalid consumer start position %v: expected %v, %v or a RFC3339 timestamp", position, StartPositionEarliest, StartPositionLatest)
+	}
+	return t.UnixMilli(), nil
+}
+
+// resetConsumerGroupOffsets commits config.ConsumerStartPosition for every partition of the topic without committed offset of the consumer group,
+// before the consumer joins its group. committed offsets are kept, so that restarts resume where the group stopped.
+// kafka only accepts the commit, if the consumer group has no active members; otherwise the committed offsets of the other members are used.
+func resetConsumerGroupOffsets(ctx context.Context, config config.Config, topic string) error {
+	if config.ConsumerStartPosition == "" {
+		return nil
+	}
+	timestamp, err := ParseStartPosition(config.ConsumerStartPosition)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(config)
+	if err != nil {
+		return err
+	}
+	partitions, err := getPartitions(ctx, client, topic)
+	if err != nil {
+		return err
+	}
+	partitions, err = getPartitionsWithoutCommit(ctx, client, config.ConsumerGroup, topic, partitions)
+	if err != nil {
+		return err
+	}
+	if len(partitions) == 0 {
+		return nil
+	}
+	commits := []kafka.OffsetCommit{}
+	for _, partition := range partitions {
+		offset, err := getOffset(ctx, client, topic, partition, timestamp)
+		if err != nil {
+			return err
+		}
+		commits = append(commits, kafka.OffsetCommit{Partition: partition, Offset: offset})
+	}
+	resp, err := client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      config.ConsumerGroup,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		log.Println("WARNING: unable to reset offsets of consumer group --> use committed offsets", config.ConsumerGroup, topic, err)
+		return nil
+	}
+	for _, partition := range resp.Topics[topic] {
+		if partition.Error != nil {
+			log.Println("WARNING: unable to reset offsets of consumer group --> use committed offsets", config.ConsumerGroup, topic, partition.Partition, partition.Error)
+			return nil
+		}
+	}
+	log.Println("reset offsets of consumer group", config.ConsumerGroup, topic, config.ConsumerStartPosition, commits)
+	return nil
+}
+
+// getPartitionsWithoutCommit returns the partitions, for which the consumer group has no committed offset
+func getPartitionsWithoutCommit(ctx context.Context, client *kafka.Client, group string, topic string, partitions []int) (result []int, err error) {
+	resp, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: group,
+		Topics:  map[string][]int{topic: partitions},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	committed := map[int]bool{}
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, p.Error
+		}
+		if p.CommittedOffset >= 0 {
+			committed[p.Partition] = true
+		}
+	}
+	for _, partition := range partitions {
+		if !committed[partition] {
+			result = append(result, partition)
+		}
+	}
+	return result, nil
+}
+
+func newClient(config config.Config) (*kafka.Client, error) {
+	transport, err := connection.Transport(config)
+	if err != nil {
+		return nil, err
+	}
+	return &kafka.Client{
+		Addr:      kafka.TCP(connection.Brokers(config)...),
+		Transport: transport,
+		Timeout:   10 * time.Second,
+	}, nil
+}
+
+func getPartitions(ctx context.Context, client *kafka.Client, topic string) (result []int, err error) {
+	metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range metadata.Topics {
+		if t.Name != topic {
+			continue
+		}
+		if t.Error != nil {
+			return nil, t.Error
+		}
+		for _, partition := range t.Partitions {
+			result = append(result, partition.ID)
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.New("no partitions found for topic " + topic)
+	}
+	return result, nil
+}
+
+// getOffset returns the offset of the first message at or after timestamp;
+// kafka.FirstOffset and kafka.LastOffset are resolved to the current first and next offset of the partition
+func getOffset(ctx context.Context, client *kafka.Client, topic string, partition int, timestamp int64) (offset int64, err error) {
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: {{Partition: partition, Timestamp: timestamp}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range resp.Topics[topic] {
+		if p.Partition != partition {
+			continue
+		}
+		if p.Error != nil {
+			return 0, p.Error
+		}
+		switch timestamp {
+		case kafka.FirstOffset:
+			return p.FirstOffset, nil
+		case kafka.LastOffset:
+			return p.LastOffset, nil
+		}
+		for o := range p.Offsets {
+			if o >= 0 {
+				return o, nil
+			}
+		}
+		//no message at or after timestamp
+		return getOffset(ctx, client, topic, partition, kafka.LastOffset)
+	}
+	return 0, fmt.Errorf("no offset found for topic %v partition %v", topic, partition)
+}
@@ -20,26 +20,32 @@ import (
 	"context"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/kafka/connection"
 	"github.com/segmentio/kafka-go"
 	"io"
 	"log"
 	"os"
+	"sync"
 )
 
 type Producer struct {
 	writer *kafka.Writer
-	ctx    context.Context
 }
 
-func NewProducer(ctx context.Context, config config.Config, topic string) (p interfaces.Producer, err error) {
-	result := &Producer{ctx: ctx}
+func NewProducer(ctx context.Context, wg *sync.WaitGroup, config config.Config, topic string) (p interfaces.Producer, err error) {
+	result := &Producer{}
 	if config.InitTopics {
-		err = InitTopic(config.KafkaUrl, topic)
+		err = InitTopic(config, topic)
 		if err != nil {
 			log.Println("ERROR: unable to create topic", err)
 			return nil, err
 		}
 	}
+	transport, err := connection.Transport(config)
+	if err != nil {
+		log.Println("ERROR: invalid kafka connection config", err)
+		return nil, err
+	}
 	var logger *log.Logger = nil
 
 	if config.Debug {
@@ -49,7 +55,8 @@ func NewProducer(ctx context.Context, config config.Config, topic string) (p int
 	}
 
 	result.writer = &kafka.Writer{
-		Addr:        kafka.TCP(config.KafkaUrl),
+		Addr:        kafka.TCP(connection.Brokers(config)...),
+		Transport:   transport,
 		Topic:       topic,
 		Async:       false,
 		Logger:      logger,
@@ -57,15 +64,17 @@ func NewProducer(ctx context.Context, config config.Config, topic string) (p int
 		BatchSize:   1,
 		Balancer:    &kafka.Hash{},
 	}
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		<-ctx.Done()
 		result.writer.Close()
 	}()
 	return result, nil
 }
 
-func (this *Producer) Produce(key string, message []byte) error {
-	return this.writer.WriteMessages(this.ctx, kafka.Message{
+func (this *Producer) Produce(ctx context.Context, key string, message []byte) error {
+	return this.writer.WriteMessages(ctx, kafka.Message{
 		Key:   []byte(key),
 		Value: message,
 		Time:  config.TimeNow(),
@@ -0,0 +1,115 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/kafka/connection"
+	"github.com/segmentio/kafka-go"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// ReadRange passes the messages of a partition from startOffset to endOffset (inclusive) to the listener, without consumer group and commits.
+// a negative partition reads every partition of the topic, a negative startOffset starts at the first available message
+// and a negative endOffset stops at the last message that existed when the partition was opened.
+// listener errors are retried like in NewMessageConsumer; skippable errors (see errs.IsSkippable) stop the read.
+func ReadRange(ctx context.Context, config config.Config, topic string, partition int, startOffset int64, endOffset int64, listener func(msg interfaces.Message) error) error {
+	client, err := newClient(config)
+	if err != nil {
+		return err
+	}
+	partitions := []int{partition}
+	if partition < 0 {
+		partitions, err = getPartitions(ctx, client, topic)
+		if err != nil {
+			return err
+		}
+	}
+	for _, p := range partitions {
+		err = readPartitionRange(ctx, config, client, topic, p, startOffset, endOffset, listener)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readPartitionRange(ctx context.Context, config config.Config, client *kafka.Client, topic string, partition int, startOffset int64, endOffset int64, listener func(msg interfaces.Message) error) error {
+	first, err := getOffset(ctx, client, topic, partition, kafka.FirstOffset)
+	if err != nil {
+		return err
+	}
+	next, err := getOffset(ctx, client, topic, partition, kafka.LastOffset)
+	if err != nil {
+		return err
+	}
+	if startOffset < first {
+		startOffset = first
+	}
+	if endOffset < 0 || endOffset >= next {
+		endOffset = next - 1
+	}
+	if startOffset > endOffset {
+		log.Println("nothing to read in", topic, partition, startOffset, endOffset)
+		return nil
+	}
+	dialer, err := connection.Dialer(config)
+	if err != nil {
+		return err
+	}
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     connection.Brokers(config),
+		Dialer:      dialer,
+		Topic:       topic,
+		Partition:   partition,
+		MaxWait:     1 * time.Second,
+		Logger:      log.New(io.Discard, "", 0),
+		ErrorLogger: log.New(os.Stdout, "[KAFKA-ERR]", log.LstdFlags),
+	})
+	defer r.Close()
+	err = r.SetOffset(startOffset)
+	if err != nil {
+		return err
+	}
+	log.Println("read", topic, partition, startOffset, endOffset)
+	for {
+		m, err := r.ReadMessage(ctx)
+		if err != nil {
+			return err
+		}
+		//compacted topics may have gaps between offsets
+		if m.Offset > endOffset {
+			return nil
+		}
+		err = retry(ctx, func() error {
+			return listener(interfaces.Message{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset, Value: m.Value})
+		}, func(n int64) time.Duration {
+			return time.Duration(n) * time.Second
+		}, 10*time.Minute)
+		if err != nil {
+			return err
+		}
+		if m.Offset >= endOffset {
+			return nil
+		}
+	}
+}
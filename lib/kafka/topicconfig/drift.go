@@ -0,0 +1,68 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topicconfig
+
+import (
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"sort"
+	"strconv"
+)
+
+const SettingPartitions = "partitions"
+const SettingReplicationFactor = "replication_factor"
+
+// Drift describes a setting of an existing topic that differs from its config.KafkaTopicConfig.
+// Setting is SettingPartitions, SettingReplicationFactor or the name of a topic config entry.
+type Drift struct {
+	Setting  string
+	Expected string
+	Actual   string
+}
+
+// GetDrift compares the expected settings with the actual settings of a topic.
+// config entries missing in actual are reported with an empty Actual value; entries not in expected are ignored.
+func GetDrift(expected config.KafkaTopicConfig, partitions int32, replicationFactor int16, actual map[string]string) (result []Drift) {
+	if expectedPartitions := max(expected.Partitions, 1); expectedPartitions != partitions {
+		result = append(result, Drift{
+			Setting:  SettingPartitions,
+			Expected: strconv.Itoa(int(expectedPartitions)),
+			Actual:   strconv.Itoa(int(partitions)),
+		})
+	}
+	if expectedReplicationFactor := max(expected.ReplicationFactor, 1); expectedReplicationFactor != replicationFactor {
+		result = append(result, Drift{
+			Setting:  SettingReplicationFactor,
+			Expected: strconv.Itoa(int(expectedReplicationFactor)),
+			Actual:   strconv.Itoa(int(replicationFactor)),
+		})
+	}
+	keys := []string{}
+	for key := range expected.Config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if actual[key] != expected.Config[key] {
+			result = append(result, Drift{
+				Setting:  key,
+				Expected: expected.Config[key],
+				Actual:   actual[key],
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topicconfig
+
+import (
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"reflect"
+	"testing"
+)
+
+func TestGetDrift(t *testing.T) {
+	expected := config.KafkaTopicConfig{
+		Partitions: 3,
+		Config: map[string]string{
+			"cleanup.policy": "delete",
+			"retention.ms":   "604800000",
+		},
+	}
+	t.Run("no drift", func(t *testing.T) {
+		drift := GetDrift(expected, 3, 1, map[string]string{
+			"cleanup.policy": "delete",
+			"retention.ms":   "604800000",
+			"segment.ms":     "604800000",
+		})
+		if len(drift) != 0 {
+			t.Error(drift)
+		}
+	})
+	t.Run("drift", func(t *testing.T) {
+		drift := GetDrift(expected, 1, 2, map[string]string{
+			"cleanup.policy": "compact",
+		})
+		if !reflect.DeepEqual(drift, []Drift{
+			{Setting: SettingPartitions, Expected: "3", Actual: "1"},
+			{Setting: SettingReplicationFactor, Expected: "1", Actual: "2"},
+			{Setting: "cleanup.policy", Expected: "delete", Actual: "compact"},
+			{Setting: "retention.ms", Expected: "604800000", Actual: ""},
+		}) {
+			t.Error(drift)
+		}
+	})
+}
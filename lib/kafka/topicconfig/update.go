@@ -3,14 +3,18 @@ package topicconfig
 import (
 	"errors"
 	"github.com/IBM/sarama"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/kafka/connection"
 	"github.com/segmentio/kafka-go"
 	"log"
 	"net"
 	"strconv"
 )
 
-func Ensure(bootstrapUrl string, topic string, config map[string]string) (err error) {
-	controller, err := getKafkaController(bootstrapUrl)
+// Ensure creates the topic if it does not exist. drift between topicConfig and an existing topic is logged
+// and corrected if config.KafkaTopicConfigCorrectDrift is set.
+func Ensure(config config.Config, topic string, topicConfig config.KafkaTopicConfig) (err error) {
+	controller, err := getKafkaController(config)
 	if err != nil {
 		log.Println("ERROR: unable to find controller", err)
 		return err
@@ -19,47 +23,126 @@ func Ensure(bootstrapUrl string, topic string, config map[string]string) (err er
 		log.Println("ERROR: unable to find controller")
 		return errors.New("unable to find controller")
 	}
-	return EnsureWithBroker(controller, topic, config)
+	return EnsureWithBroker(config, controller, topic, topicConfig)
 }
 
-func EnsureWithBroker(broker string, topic string, config map[string]string) (err error) {
-	sconfig := sarama.NewConfig()
-	sconfig.Version = sarama.V2_4_0_0
+func EnsureWithBroker(config config.Config, broker string, topic string, topicConfig config.KafkaTopicConfig) (err error) {
+	sconfig, err := connection.Sarama(config)
+	if err != nil {
+		return err
+	}
 	admin, err := sarama.NewClusterAdmin([]string{broker}, sconfig)
 	if err != nil {
 		return err
 	}
+	defer admin.Close()
 
-	temp := map[string]*string{}
-	for key, value := range config {
-		tempValue := value
-		temp[key] = &tempValue
+	metadata, err := admin.DescribeTopics([]string{topic})
+	if err != nil {
+		return err
+	}
+	if len(metadata) == 0 || errors.Is(metadata[0].Err, sarama.ErrUnknownTopicOrPartition) {
+		log.Println("create topic: ", topic, topicConfig)
+		return create(admin, topic, topicConfig)
+	}
+	if metadata[0].Err != sarama.ErrNoError {
+		return metadata[0].Err
 	}
 
-	err = set(admin, topic, temp)
+	entries, err := admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
 	if err != nil {
-		log.Println("WARNING: ", err)
-		log.Println("create topic: ", topic, config)
-		err = create(admin, topic, temp)
+		return err
 	}
-
-	return err
+	actual := map[string]string{}
+	for _, entry := range entries {
+		actual[entry.Name] = entry.Value
+	}
+	replicationFactor := int16(0)
+	if len(metadata[0].Partitions) > 0 {
+		replicationFactor = int16(len(metadata[0].Partitions[0].Replicas))
+	}
+	drift := GetDrift(topicConfig, int32(len(metadata[0].Partitions)), replicationFactor, actual)
+	if len(drift) == 0 {
+		return nil
+	}
+	log.Println("WARNING: config drift of topic", topic, drift)
+	if !config.KafkaTopicConfigCorrectDrift {
+		return nil
+	}
+	return correct(admin, topic, drift)
 }
 
-func set(admin sarama.ClusterAdmin, topic string, config map[string]*string) (err error) {
-	return admin.AlterConfig(sarama.TopicResource, topic, config, false)
+func correct(admin sarama.ClusterAdmin, topic string, drift []Drift) (err error) {
+	entries := map[string]sarama.IncrementalAlterConfigsEntry{}
+	for _, d := range drift {
+		switch d.Setting {
+		case SettingPartitions:
+			partitions, err := strconv.ParseInt(d.Expected, 10, 32)
+			if err != nil {
+				return err
+			}
+			current, err := strconv.ParseInt(d.Actual, 10, 32)
+			if err != nil {
+				return err
+			}
+			if partitions < current {
+				log.Println("WARNING: unable to reduce partitions of topic", topic, d.Actual, d.Expected)
+				continue
+			}
+			log.Println("increase partitions of topic", topic, d.Actual, d.Expected)
+			err = admin.CreatePartitions(topic, int32(partitions), nil, false)
+			if err != nil {
+				return err
+			}
+		case SettingReplicationFactor:
+			log.Println("WARNING: replication factor of existing topics is not corrected", topic, d.Actual, d.Expected)
+		default:
+			value := d.Expected
+			entries[d.Setting] = sarama.IncrementalAlterConfigsEntry{
+				Operation: sarama.IncrementalAlterConfigsOperationSet,
+				Value:     &value,
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	log.Println("correct config of topic", topic, len(entries))
+	return admin.IncrementalAlterConfig(sarama.TopicResource, topic, entries, false)
 }
 
-func create(admin sarama.ClusterAdmin, topic string, config map[string]*string) (err error) {
+func create(admin sarama.ClusterAdmin, topic string, topicConfig config.KafkaTopicConfig) (err error) {
+	temp := map[string]*string{}
+	for key, value := range topicConfig.Config {
+		tempValue := value
+		temp[key] = &tempValue
+	}
 	return admin.CreateTopic(topic, &sarama.TopicDetail{
-		NumPartitions:     1,
-		ReplicationFactor: 1,
-		ConfigEntries:     config,
+		NumPartitions:     max(topicConfig.Partitions, 1),
+		ReplicationFactor: max(topicConfig.ReplicationFactor, 1),
+		ConfigEntries:     temp,
 	}, false)
 }
 
-func getKafkaController(bootstrapUrl string) (result string, err error) {
-	conn, err := kafka.Dial("tcp", bootstrapUrl)
+// getKafkaController asks the bootstrap brokers in order until one of them answers
+func getKafkaController(config config.Config) (result string, err error) {
+	dialer, err := connection.Dialer(config)
+	if err != nil {
+		return result, err
+	}
+	err = errors.New("no kafka broker configured")
+	for _, broker := range connection.Brokers(config) {
+		result, err = getKafkaControllerFromBroker(dialer, broker)
+		if err == nil {
+			return result, nil
+		}
+		log.Println("WARNING: unable to get kafka controller from", broker, err)
+	}
+	return result, err
+}
+
+func getKafkaControllerFromBroker(dialer *kafka.Dialer, broker string) (result string, err error) {
+	conn, err := dialer.Dial("tcp", broker)
 	if err != nil {
 		return result, err
 	}
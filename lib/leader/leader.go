@@ -0,0 +1,153 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package leader
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
+	"log"
+	"os"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const LeaseName = "event-deployment-leader"
+
+type LeaseRepository interface {
+	TryAcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (acquired bool, err error)
+	ReleaseLease(ctx context.Context, name string, holder string) error
+}
+
+// Elector decides which replica of the consumer group runs background jobs.
+// the leader holds a lease document and renews it every third of the lease duration;
+// on errors the instance steps down, because it can no longer be sure to hold the lease.
+type Elector struct {
+	repo          LeaseRepository
+	holder        string
+	leaseDuration time.Duration
+	leader        atomic.Bool
+	metrics       *metrics.Metrics
+}
+
+var ErrNoLeaderElection = errors.New("no leader election configured: configure conditional_event_repo_mongo_url and conditional_event_repo_mongo_lease_collection or set single_instance")
+
+// New starts the election. if repo is nil, no election is possible: the instance is always leader,
+// which is only allowed with config.SingleInstance, because background jobs would run on every instance.
+func New(ctx context.Context, wg *sync.WaitGroup, conf config.Config, repo LeaseRepository, m *metrics.Metrics) (result *Elector, err error) {
+	leaseDuration := 30 * time.Second
+	if conf.LeaderElectionLeaseDuration != "" {
+		leaseDuration, err = time.ParseDuration(conf.LeaderElectionLeaseDuration)
+		if err != nil {
+			return nil, err
+		}
+	}
+	hostname, _ := os.Hostname()
+	result = &Elector{
+		repo:          repo,
+		holder:        hostname + "_" + config.NewId(),
+		leaseDuration: leaseDuration,
+		metrics:       m,
+	}
+	if repo == nil {
+		if !conf.SingleInstance {
+			return nil, ErrNoLeaderElection
+		}
+		log.Println("WARNING: single_instance is set --> run without leader election, instance acts as leader")
+		result.setLeader(true)
+		return result, nil
+	}
+	result.update(ctx)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(leaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if result.IsLeader() {
+					result.setLeader(false)
+					//ctx is already canceled
+					err := repo.ReleaseLease(context.Background(), LeaseName, result.holder)
+					if err != nil {
+						log.Println("WARNING: unable to release leader lease", err)
+					}
+				}
+				return
+			case <-ticker.C:
+				result.update(ctx)
+			}
+		}
+	}()
+	return result, nil
+}
+
+func (this *Elector) IsLeader() bool {
+	return this.leader.Load()
+}
+
+// RunAsLeader calls job every interval, as long as this instance is the leader. the job receives ctx, to stop on shutdown.
+func (this *Elector) RunAsLeader(ctx context.Context, wg *sync.WaitGroup, name string, interval time.Duration, job func(ctx context.Context) error) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !this.IsLeader() {
+					continue
+				}
+				err := job(ctx)
+				if err != nil {
+					log.Println("ERROR: leader job", name, err)
+					debug.PrintStack()
+				}
+			}
+		}
+	}()
+}
+
+func (this *Elector) update(ctx context.Context) {
+	acquired, err := this.repo.TryAcquireLease(ctx, LeaseName, this.holder, this.leaseDuration)
+	if err != nil {
+		log.Println("ERROR: unable to acquire leader lease --> step down", err)
+		acquired = false
+	}
+	if acquired != this.IsLeader() {
+		log.Println("leader election:", this.holder, "is leader =", acquired)
+	}
+	this.setLeader(acquired)
+}
+
+func (this *Elector) setLeader(leader bool) {
+	this.leader.Store(leader)
+	if this.metrics != nil {
+		if leader {
+			this.metrics.IsLeader.Set(1)
+		} else {
+			this.metrics.IsLeader.Set(0)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package leader
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"sync"
+	"testing"
+	"time"
+)
+
+type LeaseRepoMock struct {
+	mux       sync.Mutex
+	holder    string
+	expiresAt time.Time
+}
+
+func (this *LeaseRepoMock) TryAcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (acquired bool, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := time.Now()
+	if this.holder != "" && this.holder != holder && this.expiresAt.After(now) {
+		return false, nil
+	}
+	this.holder = holder
+	this.expiresAt = now.Add(ttl)
+	return true, nil
+}
+
+func (this *LeaseRepoMock) ReleaseLease(ctx context.Context, name string, holder string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if this.holder == holder {
+		this.holder = ""
+	}
+	return nil
+}
+
+func TestElector(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conf := &config.ConfigStruct{LeaderElectionLeaseDuration: "300ms"}
+	repo := &LeaseRepoMock{}
+
+	ctx1, cancel1 := context.WithCancel(ctx)
+	defer cancel1()
+	e1, err := New(ctx1, wg, conf, repo, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	e2, err := New(ctx, wg, conf, repo, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !e1.IsLeader() || e2.IsLeader() {
+		t.Error(e1.IsLeader(), e2.IsLeader())
+		return
+	}
+
+	jobCalls := 0
+	mux := sync.Mutex{}
+	e2.RunAsLeader(ctx, wg, "test", 50*time.Millisecond, func(ctx context.Context) error {
+		mux.Lock()
+		defer mux.Unlock()
+		jobCalls++
+		return nil
+	})
+
+	time.Sleep(300 * time.Millisecond)
+	if !e1.IsLeader() || e2.IsLeader() {
+		t.Error("lease should be renewed by e1", e1.IsLeader(), e2.IsLeader())
+		return
+	}
+	mux.Lock()
+	if jobCalls != 0 {
+		t.Error("job should not run on non leader", jobCalls)
+	}
+	mux.Unlock()
+
+	cancel1()
+	time.Sleep(300 * time.Millisecond)
+	if e1.IsLeader() || !e2.IsLeader() {
+		t.Error("e2 should take over after e1 released the lease", e1.IsLeader(), e2.IsLeader())
+		return
+	}
+	mux.Lock()
+	if jobCalls == 0 {
+		t.Error("job should run on leader")
+	}
+	mux.Unlock()
+}
+
+func TestWithoutElection(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := New(ctx, wg, &config.ConfigStruct{}, nil, nil)
+	if !errors.Is(err, ErrNoLeaderElection) {
+		t.Error("missing leader election should fail, unless single_instance is set", err)
+	}
+	e, err := New(ctx, wg, &config.ConfigStruct{SingleInstance: true}, nil, nil)
+	if err != nil || !e.IsLeader() {
+		t.Error(err)
+	}
+}
@@ -18,67 +18,329 @@ package lib
 
 import (
 	"context"
+	"errors"
 	"github.com/SENERGY-Platform/event-deployment/lib/analytics"
 	"github.com/SENERGY-Platform/event-deployment/lib/api"
+	"github.com/SENERGY-Platform/event-deployment/lib/audit"
 	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/credentials"
 	"github.com/SENERGY-Platform/event-deployment/lib/devices"
 	"github.com/SENERGY-Platform/event-deployment/lib/events"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/deployments"
 	"github.com/SENERGY-Platform/event-deployment/lib/imports"
+	"github.com/SENERGY-Platform/event-deployment/lib/inprocess"
 	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
 	"github.com/SENERGY-Platform/event-deployment/lib/kafka"
+	"github.com/SENERGY-Platform/event-deployment/lib/leader"
 	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/event-deployment/lib/outbox"
 	"log"
+	"sync"
+	"time"
 )
 
-func StartDefault(ctx context.Context, config config.Config) error {
-	return Start(ctx, config, kafka.Factory, events.Factory, analytics.Factory, devices.Factory, api.Start)
+const (
+	SourcingKafka     = "kafka"
+	SourcingInProcess = "inprocess"
+)
+
+func StartDefault(ctx context.Context, config config.Config) (wg *sync.WaitGroup, err error) {
+	sourcing, err := NewSourcingFactory(config)
+	if err != nil {
+		return &sync.WaitGroup{}, err
+	}
+	return Start(ctx, config, sourcing, events.Factory, analytics.Factory, devices.Factory, api.Start)
+}
+
+// NewSourcingFactory selects the sourcing implementation by config.Sourcing; kafka is used if not set
+func NewSourcingFactory(config config.Config) (interfaces.SourcingFactory, error) {
+	switch config.Sourcing {
+	case "", SourcingKafka:
+		return kafka.Factory, nil
+	case SourcingInProcess:
+		log.Println("use in-process sourcing", config.InProcessSourcingDir)
+		return inprocess.New(config.InProcessSourcingDir)
+	default:
+		return nil, errors.New("unknown sourcing " + config.Sourcing)
+	}
 }
 
 type Producer interface {
-	Produce(key string, message []byte) error
+	Produce(ctx context.Context, key string, message []byte) error
 }
 
-func Start(ctx context.Context, config config.Config, sourcing interfaces.SourcingFactory, events interfaces.EventsFactory, analytics interfaces.AnalyticsFactory, devices interfaces.DevicesFactory, apiFactory func(ctx context.Context, config config.Config, ctrl interfaces.Events) error) error {
-	a, err := analytics.New(ctx, config)
+// Start returns a sync.WaitGroup, which is done after ctx is canceled and all components finished their shutdown.
+// consumers and the api stop on ctx.Done() but may finish their current work;
+// resources used by this work (db clients, producers) are closed after that.
+func Start(ctx context.Context, config config.Config, sourcing interfaces.SourcingFactory, events interfaces.EventsFactory, analytics interfaces.AnalyticsFactory, devices interfaces.DevicesFactory, apiFactory func(ctx context.Context, wg *sync.WaitGroup, config config.Config, ctrl interfaces.Events) error) (wg *sync.WaitGroup, err error) {
+	wg = &sync.WaitGroup{}
+	workerWg := &sync.WaitGroup{}
+	resourceCtx, closeResources := context.WithCancel(context.Background())
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		workerWg.Wait()
+		closeResources()
+	}()
+
+	//all mongo repositories share one client
+	var repo *deployments.Deployments
+	if config.ConditionalEventRepoMongoUrl != "" && config.ConditionalEventRepoMongoUrl != "-" {
+		repo, err = deployments.New(resourceCtx, wg, config)
+		if err != nil {
+			return wg, err
+		}
+	}
+
+	var pipelineCredentials interfaces.PipelineCredentials
+	if config.PipelineAuthMode == credentials.ModeCredentialReference {
+		pipelineCredentials, err = usePipelineCredentials(config, repo)
+		if err != nil {
+			return wg, err
+		}
+	}
+	a, err := analytics.New(resourceCtx, config, pipelineCredentials)
 	if err != nil {
-		return err
+		return wg, err
 	}
 	var producer Producer
 	if !config.DisableKafka && !config.DisableKafkaDoneProducer && config.DeploymentDoneTopic != "" && config.DeploymentDoneTopic != "-" {
 		log.Println("use deployment done producer")
-		producer, err = sourcing.NewProducer(ctx, config, config.DeploymentDoneTopic)
+		producer, err = sourcing.NewProducer(resourceCtx, wg, config, config.DeploymentDoneTopic)
 		if err != nil {
-			return err
+			return wg, err
 		}
 	}
 	m := metrics.New().Serve(ctx, config.MetricsPort)
 
+	var leaseRepo leader.LeaseRepository
+	if repo != nil && config.ConditionalEventRepoMongoLeaseCollection != "" {
+		leaseRepo = repo
+	}
+	elector, err := leader.New(ctx, workerWg, config, leaseRepo, m)
+	if err != nil {
+		return wg, err
+	}
+
 	i, err := imports.New(config)
 	if err != nil {
-		return err
+		return wg, err
 	}
 	d, err := devices.New(config)
 	if err != nil {
-		return err
+		return wg, err
+	}
+	invalidateDeviceGroup := func(delivery []byte) {}
+	if len(config.DevicesCacheTtl) > 0 {
+		cache, err := useDevicesCache(ctx, workerWg, config, sourcing, d, m)
+		if err != nil {
+			return wg, err
+		}
+		d = cache
+		invalidateDeviceGroup = cache.InvalidateDeviceGroup
+	}
+
+	var auditLog interfaces.AuditLog
+	if len(config.AuditSinks) > 0 {
+		auditLog, err = useAuditLog(resourceCtx, wg, config, sourcing, repo)
+		if err != nil {
+			return wg, err
+		}
 	}
 
-	event, err := events.New(ctx, config, a, d, i, producer, m)
+	var replaySource interfaces.ReplaySource
+	if !config.DisableKafka {
+		replaySource = sourcing
+	}
+	var descChangeProducer interfaces.Producer
+	if !config.DisableKafka && config.ConditionalEventDescChangeTopic != "" && config.ConditionalEventDescChangeTopic != "-" {
+		log.Println("use event description change producer")
+		descChangeProducer, err = sourcing.NewProducer(resourceCtx, wg, config, config.ConditionalEventDescChangeTopic)
+		if err != nil {
+			return wg, err
+		}
+	}
+	//rollbacks of the deployment history are published as deployment commands, to be handled like deployments of the process-deployment service
+	var deploymentProducer interfaces.Producer
+	if !config.DisableKafka && !config.DisableKafkaProcessDeployment && repo != nil && config.ConditionalEventRepoMongoHistoryCollection != "" {
+		deploymentProducer, err = sourcing.NewProducer(resourceCtx, wg, config, config.DeploymentTopic)
+		if err != nil {
+			return wg, err
+		}
+	}
+	producer, descChangeProducer, err = useOutbox(resourceCtx, wg, config, repo, elector, producer, descChangeProducer)
 	if err != nil {
-		return err
+		return wg, err
+	}
+	event, err := events.New(resourceCtx, wg, config, a, d, i, producer, m, elector, auditLog, replaySource, descChangeProducer, deploymentProducer, repo)
+	if err != nil {
+		return wg, err
+	}
+	//in-flight messages are not canceled by ctx, because resourceCtx is canceled after the consumers are stopped
+	commandCtx, err := newCommandContextFactory(resourceCtx, config)
+	if err != nil {
+		return wg, err
 	}
 	if !config.DisableKafka {
 		if !config.DisableKafkaProcessDeployment {
-			err = sourcing.NewConsumer(ctx, config, config.DeploymentTopic, event.HandleCommand)
+			err = sourcing.NewMessageConsumer(ctx, workerWg, config, config.DeploymentTopic, func(msg interfaces.Message) error {
+				ctx, cancel := commandCtx()
+				defer cancel()
+				return event.HandleCommandMessage(ctx, msg)
+			})
 			if err != nil {
-				return err
+				return wg, err
 			}
 		}
 		if !config.DisableKafkaDeviceGroupUpdate && config.DeviceGroupTopic != "" {
-			err = sourcing.NewConsumer(ctx, config, config.DeviceGroupTopic, event.HandleDeviceGroupUpdate)
+			err = useDeviceGroupConsumer(ctx, workerWg, config, sourcing, event, commandCtx, invalidateDeviceGroup)
 			if err != nil {
-				return err
+				return wg, err
 			}
 		}
 	}
-	return apiFactory(ctx, config, event)
+	return wg, apiFactory(ctx, workerWg, config, event)
+}
+
+func useDevicesCache(ctx context.Context, wg *sync.WaitGroup, config config.Config, sourcing interfaces.SourcingFactory, d interfaces.Devices, m *metrics.Metrics) (*devices.Cache, error) {
+	cache, err := devices.NewCache(ctx, wg, config, d, m)
+	if err != nil {
+		return nil, err
+	}
+	if !config.DisableKafka {
+		err = cache.ListenForInvalidation(ctx, wg, config, sourcing)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cache, nil
+}
+
+func usePipelineCredentials(config config.Config, repo *deployments.Deployments) (interfaces.PipelineCredentials, error) {
+	if repo == nil || config.ConditionalEventRepoMongoCredentialsCollection == "" {
+		return nil, errors.New("pipeline_auth_mode " + credentials.ModeCredentialReference + " needs conditional_event_repo_mongo_url and conditional_event_repo_mongo_credentials_collection")
+	}
+	return credentials.New(config, repo)
+}
+
+func useAuditLog(ctx context.Context, wg *sync.WaitGroup, config config.Config, sourcing interfaces.SourcingFactory, repo *deployments.Deployments) (interfaces.AuditLog, error) {
+	sinks := []audit.Sink{}
+	for _, name := range config.AuditSinks {
+		switch name {
+		case audit.SinkMongo:
+			if repo == nil || config.ConditionalEventRepoMongoAuditCollection == "" {
+				return nil, errors.New("audit sink " + audit.SinkMongo + " needs conditional_event_repo_mongo_url and conditional_event_repo_mongo_audit_collection")
+			}
+			sinks = append(sinks, audit.SinkFunc(func(entry model.AuditEntry) error {
+				return repo.AddAuditEntry(ctx, entry)
+			}))
+		case audit.SinkKafka:
+			if config.DisableKafka || config.AuditTopic == "" || config.AuditTopic == "-" {
+				return nil, errors.New("audit sink " + audit.SinkKafka + " needs kafka and audit_topic")
+			}
+			producer, err := sourcing.NewProducer(ctx, wg, config, config.AuditTopic)
+			if err != nil {
+				return nil, err
+			}
+			sink, err := audit.NewProducerSink(producer)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case audit.SinkFile:
+			if config.AuditFile == "" {
+				return nil, errors.New("audit sink " + audit.SinkFile + " needs audit_file")
+			}
+			sink, err := audit.NewFileSink(ctx, wg, config.AuditFile)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		default:
+			return nil, errors.New("unknown audit sink " + name)
+		}
+	}
+	log.Println("use audit sinks", config.AuditSinks)
+	return audit.New(sinks...), nil
+}
+
+// useOutbox replaces the producers with producers of the outbox, if conditional_event_repo_mongo_outbox_collection is configured.
+// the relay uses resourceCtx, to publish entries of commands that finish during the shutdown.
+func useOutbox(ctx context.Context, wg *sync.WaitGroup, config config.Config, repo *deployments.Deployments, elector *leader.Elector, doneProducer interfaces.Producer, descChangeProducer interfaces.Producer) (interfaces.Producer, interfaces.Producer, error) {
+	if repo == nil || config.ConditionalEventRepoMongoOutboxCollection == "" {
+		return doneProducer, descChangeProducer, nil
+	}
+	if doneProducer == nil && descChangeProducer == nil {
+		return nil, nil, nil
+	}
+	producers := map[string]interfaces.Producer{}
+	if doneProducer != nil {
+		producers[config.DeploymentDoneTopic] = doneProducer
+	}
+	if descChangeProducer != nil {
+		producers[config.ConditionalEventDescChangeTopic] = descChangeProducer
+	}
+	o := outbox.New(repo, producers, config.OutboxMaxAttempts)
+	err := o.Start(ctx, wg, config, elector)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Println("use outbox for", config.DeploymentDoneTopic, config.ConditionalEventDescChangeTopic)
+	if doneProducer != nil {
+		doneProducer = o.Producer(config.DeploymentDoneTopic)
+	}
+	if descChangeProducer != nil {
+		descChangeProducer = o.Producer(config.ConditionalEventDescChangeTopic)
+	}
+	return doneProducer, descChangeProducer, nil
+}
+
+// newCommandContextFactory returns a function, that creates the context for the handling of a single kafka message
+func newCommandContextFactory(ctx context.Context, config config.Config) (func() (context.Context, context.CancelFunc), error) {
+	if config.CommandTimeout == "" {
+		return func() (context.Context, context.CancelFunc) {
+			return context.WithCancel(ctx)
+		}, nil
+	}
+	timeout, err := time.ParseDuration(config.CommandTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(ctx, timeout)
+	}, nil
+}
+
+// useDeviceGroupConsumer invalidates cached infos of updated device-groups before their update is handled,
+// to not resolve the group with infos from before the update
+func useDeviceGroupConsumer(ctx context.Context, wg *sync.WaitGroup, config config.Config, sourcing interfaces.SourcingFactory, event interfaces.Events, commandCtx func() (context.Context, context.CancelFunc), invalidateDeviceGroup func(delivery []byte)) (err error) {
+	if config.DeviceGroupUpdateQuietPeriod == "" {
+		return sourcing.NewConsumer(ctx, wg, config, config.DeviceGroupTopic, func(msg []byte) error {
+			invalidateDeviceGroup(msg)
+			ctx, cancel := commandCtx()
+			defer cancel()
+			return event.HandleDeviceGroupUpdate(ctx, msg)
+		})
+	}
+	quietPeriod, err := time.ParseDuration(config.DeviceGroupUpdateQuietPeriod)
+	if err != nil {
+		return err
+	}
+	var maxDelay time.Duration
+	if config.DeviceGroupUpdateMaxDelay != "" {
+		maxDelay, err = time.ParseDuration(config.DeviceGroupUpdateMaxDelay)
+		if err != nil {
+			return err
+		}
+	}
+	return sourcing.NewBatchConsumer(ctx, wg, config, config.DeviceGroupTopic, quietPeriod, maxDelay, func(msgs [][]byte) error {
+		for _, msg := range msgs {
+			invalidateDeviceGroup(msg)
+		}
+		ctx, cancel := commandCtx()
+		defer cancel()
+		return event.HandleDeviceGroupUpdates(ctx, msgs)
+	})
 }
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents/deployments"
+	"github.com/SENERGY-Platform/event-deployment/lib/inprocess"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/leader"
+	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
+	"sync"
+	"testing"
+	"time"
+)
+
+type analyticsFactoryMock struct{}
+
+func (this analyticsFactoryMock) New(ctx context.Context, config config.Config, credentials interfaces.PipelineCredentials) (interfaces.Analytics, error) {
+	return nil, nil
+}
+
+type devicesFactoryMock struct{}
+
+func (this devicesFactoryMock) New(config config.Config) (interfaces.Devices, error) {
+	return nil, nil
+}
+
+// blockingEvents handles commands until release is closed
+type blockingEvents struct {
+	interfaces.Events
+	resourceCtx context.Context
+	started     chan interfaces.Message
+	release     chan struct{}
+}
+
+func (this *blockingEvents) New(ctx context.Context, wg *sync.WaitGroup, config config.Config, analytics interfaces.Analytics, devices interfaces.Devices, imports interfaces.Imports, doneProducer interfaces.Producer, m *metrics.Metrics, elector *leader.Elector, auditLog interfaces.AuditLog, replaySource interfaces.ReplaySource, descChangeProducer interfaces.Producer, deploymentProducer interfaces.Producer, repo *deployments.Deployments) (interfaces.Events, error) {
+	this.resourceCtx = ctx
+	return this, nil
+}
+
+func (this *blockingEvents) HandleCommandMessage(ctx context.Context, msg interfaces.Message) error {
+	this.started <- msg
+	<-this.release
+	return ctx.Err()
+}
+
+func TestDrainOnShutdown(t *testing.T) {
+	conf := &config.ConfigStruct{
+		ConsumerGroup:                 "test",
+		DeploymentTopic:               "deployments",
+		DisableKafkaDeviceGroupUpdate: true,
+	}
+	sourcing, err := inprocess.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	producer, err := sourcing.NewProducer(context.Background(), &sync.WaitGroup{}, conf, conf.DeploymentTopic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = producer.Produce(context.Background(), "d1", []byte("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := &blockingEvents{started: make(chan interfaces.Message, 1), release: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg, err := Start(ctx, conf, sourcing, events, analyticsFactoryMock{}, devicesFactoryMock{}, func(ctx context.Context, wg *sync.WaitGroup, config config.Config, ctrl interfaces.Events) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-events.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not consumed")
+	}
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("shutdown finished while a message is in flight")
+	case <-events.resourceCtx.Done():
+		t.Fatal("resources closed while a message is in flight")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	close(events.release)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not finish after the message was handled")
+	}
+	if events.resourceCtx.Err() == nil {
+		t.Error("resources should be closed after the shutdown")
+	}
+
+	//the in-flight message was committed: a restarted consumer of the group only receives new messages
+	err = producer.Produce(context.Background(), "d2", []byte("second"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 2)
+	consumerCtx, stopConsumer := context.WithCancel(context.Background())
+	consumerWg := &sync.WaitGroup{}
+	err = sourcing.NewConsumer(consumerCtx, consumerWg, conf, conf.DeploymentTopic, func(delivery []byte) error {
+		received <- string(delivery)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-received:
+		if msg != "second" {
+			t.Error("in-flight message was not committed", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("timeout")
+	}
+	stopConsumer()
+	consumerWg.Wait()
+}
@@ -32,6 +32,9 @@ type Metrics struct {
 	RemovedConditionalEvents  prometheus.Counter
 	DeployedAnalyticsEvents   prometheus.Counter
 	RemovedAnalyticsEvents    prometheus.Counter
+	IsLeader                  prometheus.Gauge
+	DevicesCacheHits          *prometheus.CounterVec
+	DevicesCacheMisses        *prometheus.CounterVec
 	httphandler               http.Handler
 }
 
@@ -62,6 +65,18 @@ func New() *Metrics {
 			Name: "event_manager_removed_analytics_events",
 			Help: "count of removed analytics events since startup",
 		}),
+		IsLeader: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "event_manager_is_leader",
+			Help: "1 if this instance is the leader for background jobs, else 0",
+		}),
+		DevicesCacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "event_manager_devices_cache_hits",
+			Help: "count of device-repository requests answered by the cache since startup",
+		}, []string{"kind"}),
+		DevicesCacheMisses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "event_manager_devices_cache_misses",
+			Help: "count of device-repository requests not answered by the cache since startup",
+		}, []string{"kind"}),
 		httphandler: promhttp.HandlerFor(
 			reg,
 			promhttp.HandlerOpts{
@@ -76,6 +91,9 @@ func New() *Metrics {
 	reg.MustRegister(m.RemovedProcesses)
 	reg.MustRegister(m.RemovedConditionalEvents)
 	reg.MustRegister(m.RemovedAnalyticsEvents)
+	reg.MustRegister(m.IsLeader)
+	reg.MustRegister(m.DevicesCacheHits)
+	reg.MustRegister(m.DevicesCacheMisses)
 
 	return m
 }
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Activation restricts the time in which the events of a deployment are deployed.
+// events are active between From and Until (both optional) and, if a Schedule is set, only during one of its windows.
+type Activation struct {
+	From     *time.Time           `json:"from,omitempty" bson:"from,omitempty"`
+	Until    *time.Time           `json:"until,omitempty" bson:"until,omitempty"`
+	Schedule []ActivationSchedule `json:"schedule,omitempty" bson:"schedule,omitempty"`
+	Location string               `json:"location,omitempty" bson:"location,omitempty"` //IANA time zone of the schedule; default UTC
+}
+
+// ActivationSchedule is a recurring daily window like weekdays 08:00-18:00.
+// if End is not after Start, the window ends on the following day.
+type ActivationSchedule struct {
+	Weekdays []time.Weekday `json:"weekdays,omitempty" bson:"weekdays,omitempty"` //weekday of the window start, 0 = sunday; empty = every day
+	Start    string         `json:"start" bson:"start"`                           //15:04
+	End      string         `json:"end" bson:"end"`                               //15:04
+}
+
+const activationClockLayout = "15:04"
+
+func (this Activation) Validate() error {
+	if this.From != nil && this.Until != nil && !this.From.Before(*this.Until) {
+		return errors.New("activation.from must be before activation.until")
+	}
+	_, err := this.location()
+	if err != nil {
+		return err
+	}
+	for _, s := range this.Schedule {
+		_, err = time.Parse(activationClockLayout, s.Start)
+		if err != nil {
+			return fmt.Errorf("invalid activation.schedule.start %v: %w", s.Start, err)
+		}
+		_, err = time.Parse(activationClockLayout, s.End)
+		if err != nil {
+			return fmt.Errorf("invalid activation.schedule.end %v: %w", s.End, err)
+		}
+		for _, day := range s.Weekdays {
+			if day < time.Sunday || day > time.Saturday {
+				return fmt.Errorf("invalid activation.schedule.weekdays value %v", day)
+			}
+		}
+	}
+	return nil
+}
+
+// IsActive expects a validated Activation
+func (this Activation) IsActive(t time.Time) bool {
+	if this.From != nil && t.Before(*this.From) {
+		return false
+	}
+	if this.Until != nil && !t.Before(*this.Until) {
+		return false
+	}
+	if len(this.Schedule) == 0 {
+		return true
+	}
+	loc, _ := this.location()
+	t = t.In(loc)
+	for _, s := range this.Schedule {
+		//a window started yesterday may span midnight
+		for _, day := range []time.Time{t.AddDate(0, 0, -1), t} {
+			start, end, ok := s.window(day, loc)
+			if ok && !t.Before(start) && t.Before(end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NextChange returns the first point in time after t at which IsActive may change.
+// ok is false if the state never changes again.
+func (this Activation) NextChange(t time.Time) (next time.Time, ok bool) {
+	candidates := []time.Time{}
+	if this.From != nil {
+		candidates = append(candidates, *this.From)
+	}
+	if this.Until != nil {
+		candidates = append(candidates, *this.Until)
+	}
+	loc, _ := this.location()
+	local := t.In(loc)
+	for _, s := range this.Schedule {
+		for i := -1; i <= 7; i++ {
+			start, end, windowOk := s.window(local.AddDate(0, 0, i), loc)
+			if windowOk {
+				candidates = append(candidates, start, end)
+			}
+		}
+	}
+	for _, c := range candidates {
+		if c.After(t) && (!ok || c.Before(next)) {
+			next = c
+			ok = true
+		}
+	}
+	if ok && this.Until != nil && next.After(*this.Until) {
+		return next, false
+	}
+	return next, ok
+}
+
+func (this Activation) location() (*time.Location, error) {
+	if this.Location == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(this.Location)
+}
+
+func (this ActivationSchedule) window(day time.Time, loc *time.Location) (start time.Time, end time.Time, ok bool) {
+	if len(this.Weekdays) > 0 && !slices.Contains(this.Weekdays, day.Weekday()) {
+		return start, end, false
+	}
+	startClock, err := time.Parse(activationClockLayout, this.Start)
+	if err != nil {
+		return start, end, false
+	}
+	endClock, err := time.Parse(activationClockLayout, this.End)
+	if err != nil {
+		return start, end, false
+	}
+	y, m, d := day.Date()
+	start = time.Date(y, m, d, startClock.Hour(), startClock.Minute(), 0, 0, loc)
+	end = time.Date(y, m, d, endClock.Hour(), endClock.Minute(), 0, 0, loc)
+	if !end.After(start) {
+		end = time.Date(y, m, d+1, endClock.Hour(), endClock.Minute(), 0, 0, loc)
+	}
+	return start, end, true
+}
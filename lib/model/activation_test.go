@@ -0,0 +1,120 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActivation(t *testing.T) {
+	parse := func(s string) time.Time {
+		result, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	ptr := func(t time.Time) *time.Time {
+		return &t
+	}
+
+	workdays := Activation{
+		Schedule: []ActivationSchedule{{
+			Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			Start:    "08:00",
+			End:      "18:00",
+		}},
+		Location: "Europe/Berlin",
+	}
+	night := Activation{
+		Schedule: []ActivationSchedule{{Start: "22:00", End: "06:00"}},
+	}
+	dates := Activation{
+		From:  ptr(parse("2026-03-01T00:00:00Z")),
+		Until: ptr(parse("2026-04-01T00:00:00Z")),
+	}
+
+	t.Run("validate", func(t *testing.T) {
+		for _, a := range []Activation{workdays, night, dates} {
+			if err := a.Validate(); err != nil {
+				t.Error(err)
+			}
+		}
+		invalid := []Activation{
+			{Location: "Nowhere/Unknown"},
+			{Schedule: []ActivationSchedule{{Start: "8", End: "18:00"}}},
+			{Schedule: []ActivationSchedule{{Start: "08:00", End: "18:00", Weekdays: []time.Weekday{7}}}},
+			{From: dates.Until, Until: dates.From},
+		}
+		for _, a := range invalid {
+			if err := a.Validate(); err == nil {
+				t.Error("expected error", a)
+			}
+		}
+	})
+
+	t.Run("is active", func(t *testing.T) {
+		cases := []struct {
+			activation Activation
+			time       string
+			expected   bool
+		}{
+			{workdays, "2026-03-02T07:30:00Z", true},  //monday 08:30 in berlin
+			{workdays, "2026-03-02T06:30:00Z", false}, //monday 07:30 in berlin
+			{workdays, "2026-03-02T17:00:00Z", false}, //monday 18:00 in berlin
+			{workdays, "2026-03-07T10:00:00Z", false}, //saturday
+			{night, "2026-03-02T23:00:00Z", true},
+			{night, "2026-03-03T05:59:00Z", true},
+			{night, "2026-03-03T12:00:00Z", false},
+			{dates, "2026-02-28T23:59:59Z", false},
+			{dates, "2026-03-01T00:00:00Z", true},
+			{dates, "2026-04-01T00:00:00Z", false},
+		}
+		for _, c := range cases {
+			if actual := c.activation.IsActive(parse(c.time)); actual != c.expected {
+				t.Error(c.time, c.activation, actual, c.expected)
+			}
+		}
+	})
+
+	t.Run("next change", func(t *testing.T) {
+		cases := []struct {
+			activation Activation
+			time       string
+			expected   string
+		}{
+			{workdays, "2026-03-02T07:30:00Z", "2026-03-02T17:00:00Z"},
+			{workdays, "2026-03-06T18:00:00Z", "2026-03-09T07:00:00Z"}, //friday evening -> monday morning
+			{workdays, "2026-06-01T12:00:00Z", "2026-06-01T16:00:00Z"}, //summer time
+			{night, "2026-03-02T12:00:00Z", "2026-03-02T22:00:00Z"},
+			{night, "2026-03-02T23:00:00Z", "2026-03-03T06:00:00Z"},
+			{dates, "2026-02-01T00:00:00Z", "2026-03-01T00:00:00Z"},
+			{dates, "2026-03-10T00:00:00Z", "2026-04-01T00:00:00Z"},
+		}
+		for _, c := range cases {
+			next, ok := c.activation.NextChange(parse(c.time))
+			if !ok || !next.Equal(parse(c.expected)) {
+				t.Error(c.time, next, ok, c.expected)
+			}
+		}
+		_, ok := dates.NextChange(parse("2026-04-01T00:00:00Z"))
+		if ok {
+			t.Error("expected no further change")
+		}
+	})
+}
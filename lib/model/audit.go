@@ -0,0 +1,66 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import "time"
+
+const (
+	AuditActionPut         = "PUT"
+	AuditActionDelete      = "DELETE"
+	AuditActionGroupUpdate = "group-update"
+	AuditActionRollback    = "rollback"
+
+	AuditResultSuccess = "success"
+	AuditResultError   = "error"
+	AuditResultIgnored = "ignored"
+
+	AuditSourceKafka = "kafka"
+	AuditSourceHttp  = "http"
+)
+
+// AuditEntry records a deployment mutation
+type AuditEntry struct {
+	Time          time.Time   `json:"time" bson:"time"`
+	Who           string      `json:"who" bson:"who"` //owner of kafka commands, token subject of api requests
+	Action        string      `json:"action" bson:"action"`
+	DeploymentId  string      `json:"deployment_id,omitempty" bson:"deployment_id,omitempty"`
+	DeviceGroupId string      `json:"device_group_id,omitempty" bson:"device_group_id,omitempty"`
+	Source        AuditSource `json:"source" bson:"source"`
+	Result        string      `json:"result" bson:"result"`
+	Error         string      `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs    int64       `json:"duration_ms" bson:"duration_ms"`
+}
+
+type AuditSource struct {
+	Kind      string `json:"kind" bson:"kind"`
+	Topic     string `json:"topic,omitempty" bson:"topic,omitempty"`
+	Partition int    `json:"partition,omitempty" bson:"partition,omitempty"`
+	Offset    int64  `json:"offset,omitempty" bson:"offset,omitempty"`
+	Endpoint  string `json:"endpoint,omitempty" bson:"endpoint,omitempty"`
+	CallerIp  string `json:"caller_ip,omitempty" bson:"caller_ip,omitempty"`
+}
+
+// Finish sets result, error and duration of the entry
+func (this *AuditEntry) Finish(duration time.Duration, err error) {
+	this.DurationMs = duration.Milliseconds()
+	if err != nil {
+		this.Result = AuditResultError
+		this.Error = err.Error()
+	} else if this.Result == "" {
+		this.Result = AuditResultSuccess
+	}
+}
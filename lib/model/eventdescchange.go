@@ -0,0 +1,53 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import "time"
+
+const (
+	EventDescChangeCreated = "created"
+	EventDescChangeUpdated = "updated"
+	EventDescChangeDeleted = "deleted"
+)
+
+// EventDescChange is published on conditional_event_desc_change_topic for every created, updated and deleted event description.
+// the message key is "<device_id>/<service_id>" of the description; descriptions of imports use "<import_id>/<path>".
+//
+//	{
+//	  "type": "created" | "updated" | "deleted",
+//	  "time": "2026-01-01T00:00:00Z",
+//	  "deployment_id": "deployment id",
+//	  "description": {"user_id": "...", "deployment_id": "...", "event_id": "...", "device_id": "...", "service_id": "...", ...}
+//	}
+//
+// description is the EventDesc as stored in the event-worker repository; deleted changes contain the last stored version.
+type EventDescChange struct {
+	Type         string     `json:"type"`
+	Time         time.Time  `json:"time"`
+	DeploymentId string     `json:"deployment_id,omitempty"`
+	Description  *EventDesc `json:"description,omitempty"`
+}
+
+func (this EventDescChange) Key() string {
+	if this.Description == nil {
+		return ""
+	}
+	if this.Description.DeviceId == "" && this.Description.ImportId != "" {
+		return this.Description.ImportId + "/" + this.Description.Path
+	}
+	return this.Description.DeviceId + "/" + this.Description.ServiceId
+}
@@ -17,16 +17,52 @@
 package model
 
 import (
+	workermodel "github.com/SENERGY-Platform/event-worker/pkg/model"
 	"github.com/SENERGY-Platform/models/go/models"
+	"time"
 )
 
 type DeviceGroup = models.DeviceGroup
 
 type Device = models.Device
 
+type DeviceType = models.DeviceType
+
 type Deployment struct {
 	models.Deployment
 	UserId string
+
+	//additional filter criteria of event selections, combined with selection.filter_criteria as union; key = event id.
+	//models.Selection allows only one criteria and process-deployment does not set this field,
+	//so only deployments of the event-deployment api may use additional criteria.
+	FilterCriteria map[string][]FilterCriteria `json:"filter_criteria,omitempty"`
+
+	//if set, the events of the deployment are only deployed while the activation is active
+	Activation *Activation `json:"activation,omitempty"`
+}
+
+// GetFilterCriteria returns the function, aspect and device class of the event selection followed by the additional criteria of the event.
+// the selection has no interaction; the interaction of additional criteria is kept.
+func (this Deployment) GetFilterCriteria(eventId string, selection models.Selection) (result []FilterCriteria) {
+	criteria := FilterCriteria{}
+	if selection.FilterCriteria.FunctionId != nil {
+		criteria.FunctionId = *selection.FilterCriteria.FunctionId
+	}
+	if selection.FilterCriteria.AspectId != nil {
+		criteria.AspectId = *selection.FilterCriteria.AspectId
+	}
+	if selection.FilterCriteria.DeviceClassId != nil {
+		criteria.DeviceClassId = *selection.FilterCriteria.DeviceClassId
+	}
+	result = []FilterCriteria{criteria}
+	seen := map[FilterCriteria]bool{criteria: true}
+	for _, c := range this.FilterCriteria[eventId] {
+		if c != (FilterCriteria{}) && !seen[c] {
+			seen[c] = true
+			result = append(result, c)
+		}
+	}
+	return result
 }
 
 type PathOptionsResultElement struct {
@@ -49,6 +85,75 @@ type GroupEventDescription struct {
 	OperatorValue      string
 	CharacteristicId   string
 	UseMarshaller      bool
+
+	//criteria in addition to FunctionId and AspectId; matching services and paths are combined as union
+	AdditionalFilterCriteria []FilterCriteria
+}
+
+// EventPipelineDescription is stored as description of analytics event pipelines
+type EventPipelineDescription struct {
+	GenericEventSource *models.GenericEventSource `json:"generic_event_source,omitempty"`
+	ImportId           string                     `json:"import_id,omitempty"`
+	DeviceGroupId      string                     `json:"device_group_id,omitempty"`
+	DeviceId           string                     `json:"device_id,omitempty"`
+	ServiceId          string                     `json:"service_id,omitempty"`
+	FunctionId         string                     `json:"function_id,omitempty"`
+	AspectId           string                     `json:"aspect_id,omitempty"`
+	ValuePath          string                     `json:"value_path,omitempty"`
+	OperatorValue      string                     `json:"operator_value"`
+	EventId            string                     `json:"event_id"`
+	DeploymentId       string                     `json:"deployment_id"`
+	FlowId             string                     `json:"flow_id,omitempty"`
+	UseMarshaller      bool                       `json:"use_marshaller,omitempty"`
+
+	AdditionalFilterCriteria []FilterCriteria `json:"additional_filter_criteria,omitempty"`
+}
+
+// EventDetails describes what is deployed for an event
+type EventDetails struct {
+	EventId           string                 `json:"event_id"`
+	Pipelines         []EventPipelineDetails `json:"pipelines,omitempty"`
+	ConditionalEvents []EventDesc            `json:"conditional_events,omitempty"`
+}
+
+type EventPipelineDetails struct {
+	PipelineId  string                   `json:"pipeline_id"`
+	Name        string                   `json:"name"`
+	Description EventPipelineDescription `json:"description"`
+	Inputs      []EventPipelineInput     `json:"inputs"`
+}
+
+type EventPipelineInput struct {
+	OperatorId string   `json:"operator_id"`
+	Topic      string   `json:"topic"`
+	FilterType string   `json:"filter_type"`
+	FilterIds  []string `json:"filter_ids"`
+}
+
+type EventDesc = workermodel.EventDesc
+
+// DeploymentVersion is a stored version of a deployment. Descriptions are the event descriptions last generated for the version.
+type DeploymentVersion struct {
+	DeploymentId   string                      `json:"deployment_id" bson:"deployment_id"`
+	Version        int64                       `json:"version" bson:"version"`
+	Owner          string                      `json:"owner" bson:"owner"`
+	Deployment     models.Deployment           `json:"deployment" bson:"deployment"`
+	FilterCriteria map[string][]FilterCriteria `json:"filter_criteria,omitempty" bson:"filter_criteria,omitempty"`
+	Activation     *Activation                 `json:"activation,omitempty" bson:"activation,omitempty"`
+	Descriptions   []EventDesc                 `json:"descriptions,omitempty" bson:"descriptions,omitempty"`
+	Removed        bool                        `json:"removed,omitempty" bson:"removed,omitempty"`
+	ChangedBy      string                      `json:"changed_by" bson:"changed_by"`
+	ChangedAt      time.Time                   `json:"changed_at" bson:"changed_at"`
+	Comment        string                      `json:"comment,omitempty" bson:"comment,omitempty"`
+}
+
+func (this DeploymentVersion) GetDeployment() Deployment {
+	return Deployment{
+		Deployment:     this.Deployment,
+		UserId:         this.Owner,
+		FilterCriteria: this.FilterCriteria,
+		Activation:     this.Activation,
+	}
 }
 
 type PathAndCharacteristic struct {
@@ -57,9 +162,10 @@ type PathAndCharacteristic struct {
 }
 
 type FilterCriteria = struct {
-	FunctionId    string `json:"function_id"`
-	DeviceClassId string `json:"device_class_id"`
-	AspectId      string `json:"aspect_id"`
+	FunctionId    string             `json:"function_id"`
+	DeviceClassId string             `json:"device_class_id"`
+	AspectId      string             `json:"aspect_id"`
+	Interaction   models.Interaction `json:"interaction,omitempty"`
 }
 
 type DeviceTypeSelectable struct {
@@ -69,10 +175,10 @@ type DeviceTypeSelectable struct {
 }
 
 type ServicePathOption struct {
-	ServiceId        string `json:"service_id"`
-	Path             string `json:"path"`
-	CharacteristicId string `json:"characteristic_id"`
-	//AspectNode            AspectNode     `json:"aspect_node"`
+	ServiceId             string      `json:"service_id"`
+	Path                  string      `json:"path"`
+	CharacteristicId      string      `json:"characteristic_id"`
+	AspectNode            AspectNode  `json:"aspect_node"`
 	FunctionId            string      `json:"function_id"`
 	IsVoid                bool        `json:"is_void"`
 	Value                 interface{} `json:"value,omitempty"`
@@ -81,6 +187,8 @@ type ServicePathOption struct {
 	//Type                  Type           `json:"type,omitempty"`
 }
 
+type AspectNode = models.AspectNode
+
 type Function = models.Function
 
 type Concept = models.Concept
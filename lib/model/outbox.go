@@ -0,0 +1,38 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import "time"
+
+// OutboxEntry is a message, that is stored until the outbox relay published it.
+// parked entries are not published again, until they are requeued with POST /admin/outbox/requeue.
+type OutboxEntry struct {
+	Id              string    `json:"id" bson:"_id"`
+	Topic           string    `json:"topic" bson:"topic"`
+	Key             string    `json:"key" bson:"key"`
+	Message         []byte    `json:"message" bson:"message"`
+	Created         time.Time `json:"created" bson:"created"`
+	Attempts        int64     `json:"attempts,omitempty" bson:"attempts,omitempty"`                 //failed publish attempts
+	PermanentErrors int64     `json:"permanent_errors,omitempty" bson:"permanent_errors,omitempty"` //failed publish attempts with permanent errors
+	LastAttempt     time.Time `json:"last_attempt,omitempty" bson:"last_attempt,omitempty"`
+	LastError       string    `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	Parked          bool      `json:"parked,omitempty" bson:"parked,omitempty"`
+}
+
+type OutboxRequeueResult struct {
+	Requeued int64 `json:"requeued"`
+}
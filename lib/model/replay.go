@@ -0,0 +1,46 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import "time"
+
+const (
+	ReplayModeRange   = "range"
+	ReplayModeRebuild = "rebuild"
+)
+
+// ReplayRequest describes messages of the deployment topic, that are handled again like newly consumed commands.
+// mode range (default) replays partition from start_offset to end_offset (inclusive; negative = last message).
+// mode rebuild pauses the consumers, replays every partition from its first message and removes stored deployments without remaining commands afterwards;
+// it is only allowed with single_instance, because the consumers of other instances are not paused.
+// replayed commands are only applied to the stored deployments; they are not audited, store no history versions and send no done notifications.
+type ReplayRequest struct {
+	Mode        string `json:"mode"`
+	Partition   int    `json:"partition"`
+	StartOffset int64  `json:"start_offset"`
+	EndOffset   int64  `json:"end_offset"`
+}
+
+type ReplayStatus struct {
+	Request  ReplayRequest `json:"request"`
+	Running  bool          `json:"running"`
+	Started  time.Time     `json:"started"`
+	Finished *time.Time    `json:"finished,omitempty"`
+	Handled  int64         `json:"handled"`
+	Skipped  int64         `json:"skipped"` //messages skipped because they are invalid or refer to missing resources
+	Error    string        `json:"error,omitempty"`
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/leader"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"log"
+	"sync"
+	"time"
+)
+
+type Repository interface {
+	AddOutboxEntry(ctx context.Context, entry model.OutboxEntry) error
+	ListOutboxEntries(ctx context.Context, limit int64) (result []model.OutboxEntry, err error)
+	RemoveOutboxEntry(ctx context.Context, id string) error
+	SetOutboxEntryError(ctx context.Context, id string, lastError string, permanent bool) error
+	ParkOutboxEntry(ctx context.Context, id string, lastError string) error
+}
+
+// max entries published per relay run
+const relayBatchSize = 100
+
+// max wait between two publish attempts of a failed entry
+const maxRelayBackoff = time.Minute
+
+// Outbox stores messages in the repository, before a relay publishes them with the producer of their topic.
+// an entry is removed only after it was published, so every message is published at least once;
+// the relay stops a run at the first failed entry, to keep the order of the messages, and retries it with exponential backoff.
+// transient errors, like an unavailable kafka, are retried without limit.
+// entries with maxAttempts (0 = no limit) permanent errors, like ErrUnknownTopic, are parked, to not block the following entries.
+type Outbox struct {
+	repo        Repository
+	producers   map[string]interfaces.Producer
+	maxAttempts int64
+	interval    time.Duration
+}
+
+var ErrUnknownTopic = errs.Invalid("", errors.New("no producer for outbox topic"))
+
+func New(repo Repository, producers map[string]interfaces.Producer, maxAttempts int64) *Outbox {
+	return &Outbox{repo: repo, producers: producers, maxAttempts: maxAttempts, interval: time.Second}
+}
+
+// Start runs the relay every outbox_relay_interval (default 1s) on the leader instance
+func (this *Outbox) Start(ctx context.Context, wg *sync.WaitGroup, config config.Config, elector *leader.Elector) (err error) {
+	if config.OutboxRelayInterval != "" {
+		this.interval, err = time.ParseDuration(config.OutboxRelayInterval)
+		if err != nil {
+			return err
+		}
+	}
+	elector.RunAsLeader(ctx, wg, "outbox-relay", this.interval, this.Relay)
+	return nil
+}
+
+// Producer returns a producer, that writes to the outbox instead of the topic
+func (this *Outbox) Producer(topic string) interfaces.Producer {
+	return &producer{outbox: this, topic: topic}
+}
+
+type producer struct {
+	outbox *Outbox
+	topic  string
+}
+
+func (this *producer) Produce(ctx context.Context, key string, message []byte) error {
+	return this.outbox.repo.AddOutboxEntry(ctx, model.OutboxEntry{
+		Id:      config.NewId(),
+		Topic:   this.topic,
+		Key:     key,
+		Message: message,
+		Created: config.TimeNow(),
+	})
+}
+
+// Relay publishes the pending entries
+func (this *Outbox) Relay(ctx context.Context) error {
+	for {
+		entries, err := this.repo.ListOutboxEntries(ctx, relayBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if config.TimeNow().Before(this.nextAttempt(entry)) {
+				return nil
+			}
+			err = this.publish(ctx, entry)
+			if err != nil {
+				permanent := errs.IsPermanent(err)
+				log.Println("ERROR: unable to publish outbox entry", entry.Id, entry.Topic, entry.Attempts+1, permanent, err)
+				if !permanent || this.maxAttempts <= 0 || entry.PermanentErrors+1 < this.maxAttempts {
+					return this.repo.SetOutboxEntryError(ctx, entry.Id, err.Error(), permanent)
+				}
+				log.Println("ERROR: park outbox entry", entry.Id, entry.Topic)
+				err = this.repo.ParkOutboxEntry(ctx, entry.Id, err.Error())
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			err = this.repo.RemoveOutboxEntry(ctx, entry.Id)
+			if err != nil {
+				return err
+			}
+		}
+		if len(entries) < relayBatchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// nextAttempt returns the time of the next publish attempt of a failed entry;
+// the wait starts at the relay interval and doubles with every failed attempt up to maxRelayBackoff
+func (this *Outbox) nextAttempt(entry model.OutboxEntry) time.Time {
+	if entry.Attempts <= 0 {
+		return time.Time{}
+	}
+	backoff := this.interval
+	for i := int64(1); i < entry.Attempts && backoff < maxRelayBackoff; i++ {
+		backoff = 2 * backoff
+	}
+	return entry.LastAttempt.Add(min(backoff, maxRelayBackoff))
+}
+
+func (this *Outbox) publish(ctx context.Context, entry model.OutboxEntry) error {
+	producer, ok := this.producers[entry.Topic]
+	if !ok {
+		return fmt.Errorf("%w %v", ErrUnknownTopic, entry.Topic)
+	}
+	return producer.Produce(ctx, entry.Key, entry.Message)
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type repoMock struct {
+	mux     sync.Mutex
+	entries []model.OutboxEntry
+}
+
+func (this *repoMock) AddOutboxEntry(ctx context.Context, entry model.OutboxEntry) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.entries = append(this.entries, entry)
+	return nil
+}
+
+func (this *repoMock) ListOutboxEntries(ctx context.Context, limit int64) (result []model.OutboxEntry, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for _, entry := range this.entries {
+		if !entry.Parked && int64(len(result)) < limit {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func (this *repoMock) RemoveOutboxEntry(ctx context.Context, id string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.entries = slices.DeleteFunc(this.entries, func(entry model.OutboxEntry) bool {
+		return entry.Id == id
+	})
+	return nil
+}
+
+func (this *repoMock) SetOutboxEntryError(ctx context.Context, id string, lastError string, permanent bool) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for i, entry := range this.entries {
+		if entry.Id == id {
+			this.entries[i].Attempts++
+			if permanent {
+				this.entries[i].PermanentErrors++
+			}
+			this.entries[i].LastError = lastError
+			this.entries[i].LastAttempt = config.TimeNow()
+		}
+	}
+	return nil
+}
+
+func (this *repoMock) ParkOutboxEntry(ctx context.Context, id string, lastError string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for i, entry := range this.entries {
+		if entry.Id == id {
+			this.entries[i].Attempts++
+			this.entries[i].PermanentErrors++
+			this.entries[i].LastError = lastError
+			this.entries[i].LastAttempt = config.TimeNow()
+			this.entries[i].Parked = true
+		}
+	}
+	return nil
+}
+
+type producerMock struct {
+	err      error
+	messages []string
+}
+
+func (this *producerMock) Produce(ctx context.Context, key string, message []byte) error {
+	if this.err != nil {
+		return this.err
+	}
+	this.messages = append(this.messages, key+":"+string(message))
+	return nil
+}
+
+func TestOutbox(t *testing.T) {
+	now := time.Now()
+	timeNow := config.TimeNow
+	defer func() {
+		config.TimeNow = timeNow
+	}()
+	config.TimeNow = func() time.Time {
+		return now
+	}
+
+	ctx := context.Background()
+	repo := &repoMock{}
+	done := &producerMock{}
+	changes := &producerMock{}
+	outbox := New(repo, map[string]interfaces.Producer{"done": done, "changes": changes}, 3)
+
+	for i := 0; i < relayBatchSize+5; i++ {
+		err := outbox.Producer("done").Produce(ctx, "k", []byte(strconv.Itoa(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(done.messages) != 0 {
+		t.Error("messages should be published by the relay", done.messages)
+	}
+	err := outbox.Relay(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(done.messages) != relayBatchSize+5 || done.messages[0] != "k:0" || done.messages[relayBatchSize+4] != "k:"+strconv.Itoa(relayBatchSize+4) {
+		t.Error(len(done.messages), done.messages)
+	}
+	if len(repo.entries) != 0 {
+		t.Error(repo.entries)
+	}
+
+	t.Run("failed publish", func(t *testing.T) {
+		changes.err = errors.New("test error")
+		done.messages = nil
+		_ = outbox.Producer("done").Produce(ctx, "k", []byte("a"))
+		_ = outbox.Producer("changes").Produce(ctx, "k", []byte("b"))
+		_ = outbox.Producer("done").Produce(ctx, "k", []byte("c"))
+		relay := func(wait time.Duration) {
+			t.Helper()
+			now = now.Add(wait)
+			err = outbox.Relay(ctx)
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		relay(0)
+		relay(0)
+		if repo.entries[0].Attempts != 1 {
+			t.Error("the failed entry should wait for the backoff", repo.entries[0].Attempts)
+		}
+		relay(time.Second)
+		relay(time.Second)
+		if repo.entries[0].Attempts != 2 {
+			t.Error("the backoff should double", repo.entries[0].Attempts)
+		}
+		relay(time.Second)
+		if !reflect.DeepEqual(done.messages, []string{"k:a"}) {
+			t.Error("the relay should stop at the failed entry", done.messages)
+		}
+		if len(repo.entries) != 2 || repo.entries[0].Attempts != 3 || repo.entries[0].LastError != "test error" {
+			t.Errorf("%#v", repo.entries)
+		}
+
+		for i := 0; i < 10; i++ {
+			relay(maxRelayBackoff)
+		}
+		if len(repo.entries) != 2 || repo.entries[0].Parked || repo.entries[0].Attempts != 13 || repo.entries[0].PermanentErrors != 0 {
+			t.Errorf("transient errors should be retried without limit %#v", repo.entries[0])
+		}
+
+		changes.err = nil
+		relay(maxRelayBackoff)
+		if !reflect.DeepEqual(done.messages, []string{"k:a", "k:c"}) || !reflect.DeepEqual(changes.messages, []string{"k:b"}) {
+			t.Error(done.messages, changes.messages)
+		}
+		if len(repo.entries) != 0 {
+			t.Error(repo.entries)
+		}
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		changes.err = errs.Invalid("", errors.New("test error"))
+		done.messages = nil
+		changes.messages = nil
+		_ = outbox.Producer("changes").Produce(ctx, "k", []byte("a"))
+		_ = outbox.Producer("done").Produce(ctx, "k", []byte("b"))
+		for i := 0; i < 3; i++ {
+			now = now.Add(maxRelayBackoff)
+			err = outbox.Relay(ctx)
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		changes.err = nil
+		if !reflect.DeepEqual(done.messages, []string{"k:b"}) {
+			t.Error("the following entries should be published after the failed entry is parked", done.messages)
+		}
+		if len(repo.entries) != 1 || !repo.entries[0].Parked || repo.entries[0].Attempts != 3 || repo.entries[0].PermanentErrors != 3 {
+			t.Errorf("%#v", repo.entries)
+		}
+		now = now.Add(maxRelayBackoff)
+		err = outbox.Relay(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		if len(changes.messages) != 0 {
+			t.Error("parked entries should not be published", changes.messages)
+		}
+		repo.entries = nil
+	})
+
+	t.Run("unknown topic", func(t *testing.T) {
+		done.messages = nil
+		_ = outbox.Producer("unknown").Produce(ctx, "k", []byte("a"))
+		_ = outbox.Producer("done").Produce(ctx, "k", []byte("b"))
+		for i := 0; i < 3; i++ {
+			now = now.Add(maxRelayBackoff)
+			err = outbox.Relay(ctx)
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		if len(repo.entries) != 1 || !repo.entries[0].Parked || repo.entries[0].PermanentErrors != 3 {
+			t.Errorf("%#v", repo.entries)
+		}
+		if !reflect.DeepEqual(done.messages, []string{"k:b"}) {
+			t.Error(done.messages)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// used if http_client_timeout is not configured
+const defaultTimeout = 10 * time.Second
+
+// Permissions checks access to resources shared by the permissions-v2 service
+type Permissions struct {
+	url    string
+	topic  string
+	client *http.Client
+}
+
+// New returns nil if no permissions-v2 service is configured
+func New(conf config.Config) *Permissions {
+	if conf.PermissionsV2Url == "" || conf.PermissionsV2Url == "-" {
+		return nil
+	}
+	timeout, err := time.ParseDuration(conf.HttpClientTimeout)
+	if err != nil || timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Permissions{url: conf.PermissionsV2Url, topic: conf.PermissionsV2DeploymentTopic, client: &http.Client{Timeout: timeout}}
+}
+
+// CanReadDeployment checks if the token owner has read access to the deployment
+func (this *Permissions) CanReadDeployment(ctx context.Context, token string, deploymentId string) (access bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, this.url+"/check/"+url.PathEscape(this.topic)+"/"+url.PathEscape(deploymentId)+"?permissions=r", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", token)
+	resp, err := this.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, errors.New("unexpected permissions-v2 status code " + strconv.Itoa(resp.StatusCode))
+	}
+	err = json.NewDecoder(resp.Body).Decode(&access)
+	return access, err
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package permissions
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPermissions(t *testing.T) {
+	if New(&config.ConfigStruct{}) != nil {
+		t.Error("expected nil without configured url")
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Query().Get("permissions") != "r" || request.Header.Get("Authorization") != "Bearer token" {
+			http.Error(writer, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		switch request.URL.Path {
+		case "/check/deployments/shared":
+			writer.Write([]byte("true"))
+		case "/check/deployments/private":
+			writer.Write([]byte("false"))
+		default:
+			http.Error(writer, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := New(&config.ConfigStruct{PermissionsV2Url: server.URL, PermissionsV2DeploymentTopic: "deployments"})
+	for id, expected := range map[string]bool{"shared": true, "private": false, "unknown": false} {
+		access, err := p.CanReadDeployment(context.Background(), "Bearer token", id)
+		if err != nil {
+			t.Error(id, err)
+		}
+		if access != expected {
+			t.Error(id, access)
+		}
+	}
+}
@@ -182,19 +182,19 @@ func testConditionalEvent(t *testing.T, testcase string) {
 	}
 	defer closeTestPipelineRepoApi()
 
-	a, err := analytics.Factory.New(ctx, conf)
+	a, err := analytics.Factory.New(ctx, conf, nil)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
-	event, err := events.Factory.New(ctx, conf, a, &devicesMock, &mocks.ImportsMock{}, nil, metrics.New())
+	event, err := events.Factory.New(ctx, &wg, conf, a, &devicesMock, &mocks.ImportsMock{}, nil, metrics.New(), nil, nil, nil, nil, nil, nil)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
-	err = event.HandleCommand(deploymentCmd)
+	err = event.HandleCommand(ctx, deploymentCmd)
 	if err != nil {
 		t.Error(err)
 		return
@@ -75,6 +75,7 @@ func testDeployment(t *testing.T, testcase string) {
 	conf.DevicePathPrefix = ""
 	conf.GroupPathPrefix = ""
 	conf.Debug = true
+	conf.SingleInstance = true
 
 	ctx, cancel := context.WithCancel(context.Background())
 	wg := sync.WaitGroup{}
@@ -177,19 +178,19 @@ func testDeployment(t *testing.T, testcase string) {
 	}
 	defer closeTestFlowEngineApi()
 
-	a, err := analytics.Factory.New(ctx, conf)
+	a, err := analytics.Factory.New(ctx, conf, nil)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
-	event, err := events.Factory.New(ctx, conf, a, &devicesMock, &mocks.ImportsMock{}, nil, metrics.New())
+	event, err := events.Factory.New(ctx, &wg, conf, a, &devicesMock, &mocks.ImportsMock{}, nil, metrics.New(), nil, nil, nil, nil, nil, nil)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
-	err = event.HandleCommand(deploymentCmd)
+	err = event.HandleCommand(ctx, deploymentCmd)
 	if err != nil {
 		t.Error(err)
 		return
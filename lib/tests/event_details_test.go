@@ -0,0 +1,168 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/event-deployment/lib/api"
+	"github.com/SENERGY-Platform/event-deployment/lib/auth"
+	"github.com/SENERGY-Platform/event-deployment/lib/config"
+	"github.com/SENERGY-Platform/event-deployment/lib/events"
+	"github.com/SENERGY-Platform/event-deployment/lib/events/conditionalevents"
+	"github.com/SENERGY-Platform/event-deployment/lib/interfaces"
+	"github.com/SENERGY-Platform/event-deployment/lib/metrics"
+	"github.com/SENERGY-Platform/event-deployment/lib/model"
+	"github.com/SENERGY-Platform/event-deployment/lib/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// eventPipelinesMock returns the pipelines of the owner; other analytics methods are not used by the tests
+type eventPipelinesMock struct {
+	interfaces.Analytics
+	pipelines map[string][]model.EventPipelineDetails //key = owner
+}
+
+func (this *eventPipelinesMock) GetEventPipelines(ctx context.Context, owner string, eventId string) (result []model.EventPipelineDetails, err error) {
+	for _, pipeline := range this.pipelines[owner] {
+		if pipeline.Description.EventId == eventId {
+			result = append(result, pipeline)
+		}
+	}
+	return result, nil
+}
+
+func TestEventDetailsApi(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	getDetails := func(t *testing.T, router http.Handler, user string, eventId string) (code int, details model.EventDetails) {
+		t.Helper()
+		token, err := auth.GenerateInternalUserToken(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/events/"+eventId, nil)
+		req.Header.Set("Authorization", token)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code == http.StatusOK {
+			err = json.NewDecoder(resp.Body).Decode(&details)
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		return resp.Code, details
+	}
+
+	t.Run("analytics", func(t *testing.T) {
+		conf := &config.ConfigStruct{
+			AuthEndpoint:                "mocked",
+			EnableAnalyticsEvents:       true,
+			DevDisableTokenVerification: true,
+			SingleInstance:              true,
+		}
+		analyticsMock := &eventPipelinesMock{pipelines: map[string][]model.EventPipelineDetails{
+			"owner": {{PipelineId: "p1", Description: model.EventPipelineDescription{EventId: "e1", DeploymentId: "dep1"}}},
+		}}
+		ctrl, err := events.Factory.New(ctx, wg, conf, analyticsMock, &mocks.DevicesMock{}, nil, nil, metrics.New(), nil, nil, nil, nil, nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		router, err := api.Router(conf, ctrl)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		code, details := getDetails(t, router, "owner", "e1")
+		if code != http.StatusOK || len(details.Pipelines) != 1 || details.Pipelines[0].PipelineId != "p1" || len(details.ConditionalEvents) != 0 {
+			t.Error(code, details)
+		}
+		code, _ = getDetails(t, router, "stranger", "e1")
+		if code != http.StatusNotFound {
+			t.Error(code)
+		}
+		code, _ = getDetails(t, router, "owner", "unknown")
+		if code != http.StatusNotFound {
+			t.Error(code)
+		}
+	})
+
+	t.Run("conditional", func(t *testing.T) {
+		conf := &config.ConfigStruct{
+			AuthEndpoint:                "mocked",
+			ConditionalEventRepoType:    conditionalevents.RepositoryMemory,
+			DevDisableTokenVerification: true,
+			SingleInstance:              true,
+		}
+		devices := &mocks.DevicesMock{
+			GetDeviceInfosOfGroupValues: map[string][]model.Device{"g1": {
+				{Id: "d1", DeviceTypeId: "dt1"},
+				{Id: "d2", DeviceTypeId: "dt1"},
+			}},
+			GetDeviceTypeSelectablesValues: map[string]map[string][]model.DeviceTypeSelectable{
+				"f1": {"": {{DeviceTypeId: "dt1", Services: []models.Service{{Id: "s1"}}}}},
+			},
+		}
+		ctrl, err := events.Factory.New(ctx, wg, conf, nil, devices, nil, nil, metrics.New(), nil, nil, nil, nil, nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		router, err := api.Router(conf, ctrl)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		characteristicId := "c1"
+		functionId := "f1"
+		groupId := "g1"
+		err = ctrl.Deploy(ctx, "owner", model.Deployment{Deployment: models.Deployment{Id: "dep1", Elements: []models.Element{{ConditionalEvent: &models.ConditionalEvent{
+			EventId: "e1",
+			Selection: models.Selection{
+				FilterCriteria:        models.FilterCriteria{CharacteristicId: &characteristicId, FunctionId: &functionId},
+				SelectedDeviceGroupId: &groupId,
+			},
+		}}}}})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		code, details := getDetails(t, router, "owner", "e1")
+		if code != http.StatusOK || len(details.ConditionalEvents) != 2 || len(details.Pipelines) != 0 {
+			t.Error(code, details)
+		}
+		for _, desc := range details.ConditionalEvents {
+			if desc.UserId != "owner" || desc.DeploymentId != "dep1" || desc.EventId != "e1" {
+				t.Error(desc)
+			}
+		}
+		code, _ = getDetails(t, router, "stranger", "e1")
+		if code != http.StatusNotFound {
+			t.Error(code)
+		}
+	})
+}
@@ -17,11 +17,12 @@
 package mocks
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/event-deployment/lib/model"
 	"github.com/SENERGY-Platform/models/go/models"
-	"net/http"
 )
 
 type DevicesMock struct {
@@ -29,9 +30,10 @@ type DevicesMock struct {
 	GetDeviceTypeSelectablesValues map[string]map[string][]model.DeviceTypeSelectable
 	Functions                      map[string]model.Function
 	Concepts                       map[string]model.Concept
+	DeviceTypes                    map[string]model.DeviceType
 }
 
-func (this *DevicesMock) GetService(serviceId string) (result models.Service, err error, code int) {
+func (this *DevicesMock) GetService(ctx context.Context, serviceId string) (result models.Service, err error) {
 	str := `{
                 "attributes": [],
                 "description": "",
@@ -206,32 +208,54 @@ func (this *DevicesMock) GetService(serviceId string) (result models.Service, er
             }`
 	err = json.Unmarshal([]byte(str), &result)
 	if err != nil {
-		return result, err, http.StatusInternalServerError
+		return result, err
 	}
 	result.Id = serviceId
-	return result, nil, http.StatusOK
+	return result, nil
 }
 
-func (this *DevicesMock) GetDeviceTypeSelectables(criteria []model.FilterCriteria) (result []model.DeviceTypeSelectable, err error, code int) {
-	if len(criteria) != 1 {
-		return nil, errors.New("expect exactly 1 criteria"), http.StatusInternalServerError
+// GetDeviceTypeSelectables returns the union of the values of all criteria; values of the same device-type are merged
+func (this *DevicesMock) GetDeviceTypeSelectables(ctx context.Context, criteria []model.FilterCriteria) (result []model.DeviceTypeSelectable, err error) {
+	index := map[string]int{} //device-type id to index in result
+	for _, c := range criteria {
+		for _, selectable := range this.GetDeviceTypeSelectablesValues[c.FunctionId][c.AspectId] {
+			i, ok := index[selectable.DeviceTypeId]
+			if !ok {
+				index[selectable.DeviceTypeId] = len(result)
+				result = append(result, selectable)
+				continue
+			}
+			result[i] = mergeSelectables(result[i], selectable)
+		}
 	}
-	functionId := criteria[0].FunctionId
-	aspectId := criteria[0].AspectId
-	functionMap, ok := this.GetDeviceTypeSelectablesValues[functionId]
-	if !ok {
-		//no function found
-		return result, nil, http.StatusOK
+	return result, nil
+}
+
+func mergeSelectables(a model.DeviceTypeSelectable, b model.DeviceTypeSelectable) (result model.DeviceTypeSelectable) {
+	result = model.DeviceTypeSelectable{DeviceTypeId: a.DeviceTypeId, ServicePathOptions: map[string][]model.ServicePathOption{}}
+	known := map[string]bool{}
+	for _, service := range append(a.Services, b.Services...) {
+		if !known[service.Id] {
+			known[service.Id] = true
+			result.Services = append(result.Services, service)
+		}
 	}
-	result, ok = functionMap[aspectId]
-	if !ok {
-		//no aspect found
-		return result, nil, http.StatusOK
+	knownOptions := map[string]bool{}
+	for _, options := range []map[string][]model.ServicePathOption{a.ServicePathOptions, b.ServicePathOptions} {
+		for serviceId, list := range options {
+			for _, option := range list {
+				key := serviceId + "." + option.Path + "." + option.FunctionId + "." + option.AspectNode.Id
+				if !knownOptions[key] {
+					knownOptions[key] = true
+					result.ServicePathOptions[serviceId] = append(result.ServicePathOptions[serviceId], option)
+				}
+			}
+		}
 	}
-	return result, nil, http.StatusOK
+	return result
 }
 
-func (this *DevicesMock) GetDeviceInfosOfDevices(deviceIds []string) (devices []model.Device, deviceTypeIds []string, err error, code int) {
+func (this *DevicesMock) GetDeviceInfosOfDevices(ctx context.Context, deviceIds []string) (devices []model.Device, deviceTypeIds []string, err error) {
 	allDevices := map[string]model.Device{}
 	for _, group := range this.GetDeviceInfosOfGroupValues {
 		for _, device := range group {
@@ -247,15 +271,15 @@ func (this *DevicesMock) GetDeviceInfosOfDevices(deviceIds []string) (devices []
 			deviceTypeIds = append(deviceTypeIds, device.DeviceTypeId)
 		}
 	}
-	return devices, deviceTypeIds, nil, 200
+	return devices, deviceTypeIds, nil
 }
 
-func (this *DevicesMock) GetDeviceInfosOfGroup(groupId string) (devices []model.Device, deviceTypeIds []string, err error, code int) {
+func (this *DevicesMock) GetDeviceInfosOfGroup(ctx context.Context, groupId string) (devices []model.Device, deviceTypeIds []string, err error) {
 	if this.GetDeviceInfosOfGroupValues == nil {
-		return nil, nil, errors.New("DevicesMock.GetDeviceInfosOfGroupValues not set"), 500
+		return nil, nil, errors.New("DevicesMock.GetDeviceInfosOfGroupValues not set")
 	}
 	if devices, ok := this.GetDeviceInfosOfGroupValues[groupId]; !ok {
-		return nil, nil, errors.New("DevicesMock.GetDeviceInfosOfGroupValues[" + groupId + "] not set"), 500
+		return nil, nil, errors.New("DevicesMock.GetDeviceInfosOfGroupValues[" + groupId + "] not set")
 	} else {
 		done := map[string]bool{}
 		for _, d := range devices {
@@ -264,22 +288,47 @@ func (this *DevicesMock) GetDeviceInfosOfGroup(groupId string) (devices []model.
 				deviceTypeIds = append(deviceTypeIds, d.DeviceTypeId)
 			}
 		}
-		return devices, deviceTypeIds, nil, 200
+		return devices, deviceTypeIds, nil
+	}
+}
+
+// GetDeviceGroups returns the groups of GetDeviceInfosOfGroupValues, with the ids of their devices
+func (this *DevicesMock) GetDeviceGroups(ctx context.Context, groupIds []string) (result []model.DeviceGroup, err error) {
+	for _, groupId := range groupIds {
+		devices, _, err := this.GetDeviceInfosOfGroup(ctx, groupId)
+		if err != nil {
+			return result, err
+		}
+		group := model.DeviceGroup{Id: groupId, DeviceIds: []string{}}
+		for _, device := range devices {
+			group.DeviceIds = append(group.DeviceIds, device.Id)
+		}
+		result = append(result, group)
+	}
+	return result, nil
+}
+
+func (this *DevicesMock) GetDeviceTypes(ctx context.Context, deviceTypeIds []string) (result []model.DeviceType, err error) {
+	for _, id := range deviceTypeIds {
+		if deviceType, ok := this.DeviceTypes[id]; ok {
+			result = append(result, deviceType)
+		}
 	}
+	return result, nil
 }
 
-func (this *DevicesMock) GetConcept(conceptId string) (result model.Concept, err error, code int) {
+func (this *DevicesMock) GetConcept(ctx context.Context, conceptId string) (result model.Concept, err error) {
 	if result, ok := this.Concepts[conceptId]; ok {
-		return result, nil, http.StatusOK
+		return result, nil
 	} else {
-		return result, errors.New("not found"), http.StatusNotFound
+		return result, errs.NotFound("device-repository", errors.New("not found"))
 	}
 }
 
-func (this *DevicesMock) GetFunction(functionId string) (result model.Function, err error, code int) {
+func (this *DevicesMock) GetFunction(ctx context.Context, functionId string) (result model.Function, err error) {
 	if result, ok := this.Functions[functionId]; ok {
-		return result, nil, http.StatusOK
+		return result, nil
 	} else {
-		return result, errors.New("not found"), http.StatusNotFound
+		return result, errs.NotFound("device-repository", errors.New("not found"))
 	}
 }
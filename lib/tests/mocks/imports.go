@@ -17,23 +17,24 @@
 package mocks
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"github.com/SENERGY-Platform/event-deployment/lib/errs"
 	"github.com/SENERGY-Platform/models/go/models"
-	"net/http"
 	"strings"
 )
 
 type ImportsMock struct{}
 
-func (this *ImportsMock) GetImportInstance(user string, importId string) (importInstance models.Import, err error, code int) {
+func (this *ImportsMock) GetImportInstance(ctx context.Context, user string, importId string) (importInstance models.Import, err error) {
 	return models.Import{
 		Id:           importId,
 		ImportTypeId: "urn:infai:ses:import-type:a93420ae-ff5f-4c44-ee6b-5d3313f946d2",
-	}, nil, http.StatusOK
+	}, nil
 }
 
-func (this *ImportsMock) GetImportType(user string, importTypeId string) (importType models.ImportType, err error, code int) {
+func (this *ImportsMock) GetImportType(ctx context.Context, user string, importTypeId string) (importType models.ImportType, err error) {
 	str := `{
    "id":"urn:infai:ses:import-type:a93420ae-ff5f-4c44-ee6b-5d3313f946d2",
    "name":"yr-forecast",
@@ -319,13 +320,13 @@ func (this *ImportsMock) GetImportType(user string, importTypeId string) (import
 	if importTypeId == id {
 		err = json.Unmarshal([]byte(str), &importType)
 		if err != nil {
-			return importType, err, http.StatusInternalServerError
+			return importType, err
 		}
-		return importType, nil, http.StatusOK
+		return importType, nil
 	}
-	return importType, errors.New("not found"), http.StatusNotFound
+	return importType, errs.NotFound("import-repository", errors.New("not found"))
 }
 
-func (this *ImportsMock) GetTopic(_ string, importId string) (topic string, err error, code int) {
-	return strings.ReplaceAll(importId, ":", "_"), nil, http.StatusOK
+func (this *ImportsMock) GetTopic(ctx context.Context, _ string, importId string) (topic string, err error) {
+	return strings.ReplaceAll(importId, ":", "_"), nil
 }
@@ -39,7 +39,7 @@ func main() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	err = lib.StartDefault(ctx, config)
+	wg, err := lib.StartDefault(ctx, config)
 	if err != nil {
 		log.Println(err)
 		cancel()
@@ -53,6 +53,22 @@ func main() {
 		cancel()
 	}()
 
-	<-ctx.Done()                //waiting for context end; may happen by shutdown signal
-	time.Sleep(1 * time.Second) //give go routines time for cleanup
+	<-ctx.Done() //waiting for context end; may happen by shutdown signal
+
+	shutdownTimeout, err := time.ParseDuration(config.ShutdownTimeout)
+	if err != nil {
+		log.Println("WARNING: invalid shutdown timeout --> use 10s\n", err)
+		shutdownTimeout = 10 * time.Second
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait() //waiting for in-flight commands and requests and the following cleanup
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Println("shutdown complete")
+	case <-time.After(shutdownTimeout):
+		log.Println("WARNING: shutdown timeout exceeded")
+	}
 }